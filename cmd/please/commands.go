@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	"github.com/iishyfishyy/please/internal/customcmd"
+	"github.com/iishyfishyy/please/internal/shell"
+	"github.com/iishyfishyy/please/internal/ui"
+
+	"github.com/spf13/cobra"
+)
+
+// Flags for 'please commands lint'
+var lintStrict bool
+
+// Flags for 'please commands generate'
+var (
+	generateFrom   string
+	generateDepth  int
+	generateOutput string
+)
+
+// Flags for 'please commands import'
+var (
+	importFormat string
+	importOutput string
+	importForce  bool
+	importGroup  string
+)
+
+// runCommandsLint checks command docs and fails if any have errors (or, with
+// --strict, warnings), so it can run in CI
+func runCommandsLint(cmd *cobra.Command, args []string) error {
+	paths := args
+	if len(paths) == 0 {
+		dirs, err := customcmd.GetCommandDirs()
+		if err != nil {
+			return err
+		}
+		for _, dir := range dirs {
+			if _, err := os.Stat(dir.Path); err == nil {
+				paths = append(paths, dir.Path)
+			}
+		}
+	}
+
+	result, err := customcmd.Lint(paths)
+	if err != nil {
+		return err
+	}
+
+	red := color.New(color.FgRed, color.Bold)
+	yellow := color.New(color.FgYellow, color.Bold)
+	for _, issue := range result.Issues {
+		location := issue.File
+		if issue.Line > 0 {
+			location = fmt.Sprintf("%s:%d", issue.File, issue.Line)
+		}
+		severity := yellow.Sprint(issue.Severity)
+		if issue.Severity == customcmd.SeverityError {
+			severity = red.Sprint(issue.Severity)
+		}
+		fmt.Printf("%s: %s: %s\n", location, severity, issue.Message)
+	}
+
+	errors := result.Count(customcmd.SeverityError)
+	warnings := result.Count(customcmd.SeverityWarning)
+	summary := fmt.Sprintf("%d files checked: %d errors, %d warnings", result.Files, errors, warnings)
+	switch {
+	case errors > 0 || (lintStrict && warnings > 0):
+		fmt.Println()
+		ui.ShowError(summary)
+		return fmt.Errorf("lint failed")
+	case warnings > 0:
+		fmt.Println()
+		ui.ShowWarning(summary)
+	default:
+		ui.ShowSuccess(summary)
+	}
+
+	return nil
+}
+
+// runCommandsGenerate writes a command doc for a tool from its --help output
+// or man page, showing a preview before saving it
+func runCommandsGenerate(cmd *cobra.Command, args []string) error {
+	binary := args[0]
+	ctx := context.Background()
+
+	ui.ShowInfo(fmt.Sprintf("Reading documentation for %s...", binary))
+	help, err := customcmd.GatherHelp(ctx, binary, generateFrom, generateDepth)
+	if err != nil {
+		return fmt.Errorf("failed to read documentation: %w", err)
+	}
+	if help.Source == customcmd.HelpFromMan {
+		ui.ShowInfo("Using the man page")
+	} else {
+		ui.ShowInfo(fmt.Sprintf("Read --help for %d commands", len(help.Sections)))
+	}
+	if help.Truncated {
+		ui.ShowWarning("Documentation was too long; some of it was left out")
+	}
+	if debug {
+		fmt.Fprintf(os.Stderr, "[DEBUG] Generate: %d chars of reference from %s\n", len(help.String()), help.Source)
+	}
+
+	_, ag, _, err := loadAgent(shell.Detect())
+	if err != nil || ag == nil {
+		return err
+	}
+
+	path := generateOutput
+	if path == "" {
+		commandsDir, err := customcmd.GetCommandsDir()
+		if err != nil {
+			return err
+		}
+		path = filepath.Join(commandsDir, help.Binary+".md")
+	}
+
+	for {
+		ui.ShowInfo("Writing doc...")
+		doc, err := ag.GenerateCommandDoc(ctx, help.Binary, help.String())
+		if err != nil {
+			return fmt.Errorf("failed to generate doc: %w", err)
+		}
+
+		ui.ShowSection("Preview")
+		fmt.Println(doc)
+		showDocIssues(doc)
+
+		saveLabel := fmt.Sprintf("Save to %s", path)
+		if _, err := os.Stat(path); err == nil {
+			saveLabel = fmt.Sprintf("Overwrite %s", path)
+		}
+		choice, err := ui.ShowMenu("What would you like to do?", []string{saveLabel, "Regenerate", "Cancel"})
+		if err != nil {
+			return fmt.Errorf("failed to get user choice: %w", err)
+		}
+
+		switch choice {
+		case 0:
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return fmt.Errorf("failed to create directory: %w", err)
+			}
+			if err := os.WriteFile(path, []byte(doc), 0644); err != nil {
+				return fmt.Errorf("failed to write doc: %w", err)
+			}
+			ui.ShowSuccess(fmt.Sprintf("Saved to %s", path))
+			ui.ShowInfo("Review the examples, then run 'please index' to use it")
+			return nil
+		case 1:
+			continue
+		default:
+			ui.ShowInfo("Cancelled")
+			return nil
+		}
+	}
+}
+
+// showDocIssues lints a generated doc and shows what's wrong with it, so
+// problems can be fixed before it's saved
+func showDocIssues(doc string) {
+	tmp, err := os.CreateTemp("", "please-doc-*.md")
+	if err != nil {
+		return
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.WriteString(doc)
+	tmp.Close()
+	if err != nil {
+		return
+	}
+
+	result, err := customcmd.Lint([]string{tmp.Name()})
+	if err != nil {
+		return
+	}
+	for _, issue := range result.Issues {
+		message := issue.Message
+		if issue.Line > 0 {
+			message = fmt.Sprintf("line %d: %s", issue.Line, message)
+		}
+		if issue.Severity == customcmd.SeverityError {
+			ui.ShowError(message)
+		} else {
+			ui.ShowWarning(message)
+		}
+	}
+}
+
+// runCommandsImport converts docs from another format into command docs
+func runCommandsImport(cmd *cobra.Command, args []string) error {
+	var docs []customcmd.ImportedDoc
+	for _, path := range args {
+		var imported []customcmd.ImportedDoc
+		var err error
+		switch importFormat {
+		case customcmd.ImportFormatTldr:
+			imported, err = customcmd.ImportTldr(path)
+		case customcmd.ImportFormatOpenAPI:
+			imported, err = customcmd.ImportOpenAPI(path, importGroup)
+		default:
+			return fmt.Errorf("unknown import format: %s", importFormat)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to import %s: %w", path, err)
+		}
+		docs = append(docs, imported...)
+	}
+
+	dir := importOutput
+	if dir == "" {
+		commandsDir, err := customcmd.GetCommandsDir()
+		if err != nil {
+			return err
+		}
+		dir = commandsDir
+	}
+
+	written, skipped, err := customcmd.WriteImported(dir, docs, importForce)
+	for _, path := range written {
+		fmt.Printf("  • %s\n", path)
+	}
+	if err != nil {
+		return err
+	}
+
+	ui.ShowSuccess(fmt.Sprintf("Imported %d docs into %s", len(written), dir))
+	if len(skipped) > 0 {
+		ui.ShowWarning(fmt.Sprintf("Skipped %d docs that already exist (use --force to overwrite)", len(skipped)))
+	}
+	if len(written) > 0 {
+		ui.ShowInfo("Run 'please index' to use them")
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -14,6 +15,8 @@ import (
 	"github.com/iishyfishyy/please/internal/customcmd"
 	"github.com/iishyfishyy/please/internal/executor"
 	"github.com/iishyfishyy/please/internal/history"
+	"github.com/iishyfishyy/please/internal/placeholder"
+	"github.com/iishyfishyy/please/internal/shell"
 	"github.com/iishyfishyy/please/internal/ui"
 
 	"github.com/spf13/cobra"
@@ -28,6 +31,9 @@ var (
 	// CLI flags
 	forceReindex bool
 	debug        bool
+	timeout      time.Duration
+	maxCPU       int
+	maxMemoryMB  int
 )
 
 func main() {
@@ -43,6 +49,11 @@ func main() {
 	// Add global debug flag
 	rootCmd.PersistentFlags().BoolVarP(&debug, "debug", "d", false, "Enable debug logging")
 
+	// Execution limits (override config)
+	rootCmd.Flags().DurationVarP(&timeout, "timeout", "t", 0, "Kill the command after this duration (e.g. 30s, 5m)")
+	rootCmd.Flags().IntVar(&maxCPU, "max-cpu", 0, "CPU time limit for the command in seconds")
+	rootCmd.Flags().IntVar(&maxMemoryMB, "max-memory", 0, "Memory limit for the command in MB")
+
 	configureCmd := &cobra.Command{
 		Use:   "configure",
 		Short: "Configure please with your preferred LLM agent",
@@ -62,9 +73,85 @@ func main() {
 		RunE:  runListCommands,
 	}
 
+	scriptCmd := &cobra.Command{
+		Use:   "script [task description]",
+		Short: "Generate a multi-line bash script for a task",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  runScript,
+	}
+	scriptCmd.Flags().DurationVarP(&timeout, "timeout", "t", 0, "Kill the script after this duration (e.g. 30s, 5m)")
+	scriptCmd.Flags().IntVar(&maxCPU, "max-cpu", 0, "CPU time limit for the script in seconds")
+	scriptCmd.Flags().IntVar(&maxMemoryMB, "max-memory", 0, "Memory limit for the script in MB")
+
+	commandsCmd := &cobra.Command{
+		Use:   "commands",
+		Short: "Work with custom command docs",
+	}
+	commandsLintCmd := &cobra.Command{
+		Use:   "lint [path...]",
+		Short: "Check command docs for errors (all command directories by default)",
+		Long: "Check command docs for errors and warnings, with file and line numbers.\n" +
+			"Exits non-zero if there are errors, or warnings with --strict.",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE:          runCommandsLint,
+	}
+	commandsLintCmd.Flags().BoolVar(&lintStrict, "strict", false, "Fail on warnings as well as errors")
+	commandsCmd.AddCommand(commandsLintCmd)
+	commandsGenerateCmd := &cobra.Command{
+		Use:   "generate <binary>",
+		Short: "Write a command doc for a tool from its --help output or man page",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runCommandsGenerate,
+	}
+	commandsGenerateCmd.Flags().StringVar(&generateFrom, "from", customcmd.HelpFromAuto, "Where to read the tool's documentation: auto, help or man")
+	commandsGenerateCmd.Flags().IntVar(&generateDepth, "depth", 1, "How many levels of subcommands to read help for")
+	commandsGenerateCmd.Flags().StringVarP(&generateOutput, "output", "o", "", "Where to write the doc (default: ~/.please/commands/<binary>.md)")
+	commandsCmd.AddCommand(commandsGenerateCmd)
+	commandsImportCmd := &cobra.Command{
+		Use:   "import <path...>",
+		Short: "Convert docs in another format (tldr pages, OpenAPI specs) into command docs",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  runCommandsImport,
+	}
+	commandsImportCmd.Flags().StringVar(&importFormat, "format", customcmd.ImportFormatTldr, "Format of the docs: tldr or openapi")
+	commandsImportCmd.Flags().StringVar(&importGroup, "group", customcmd.OpenAPIByTag, "With --format openapi, write a doc per tag or per operation")
+	commandsImportCmd.Flags().StringVarP(&importOutput, "output", "o", "", "Directory to write the docs to (default: ~/.please/commands)")
+	commandsImportCmd.Flags().BoolVarP(&importForce, "force", "f", false, "Overwrite existing docs")
+	commandsCmd.AddCommand(commandsImportCmd)
+
+	sourcesCmd := &cobra.Command{
+		Use:   "sources",
+		Short: "Manage git repositories of shared command docs",
+	}
+	sourcesAddCmd := &cobra.Command{
+		Use:   "add <git-url|path>",
+		Short: "Clone a repository of command docs and index it",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runSourcesAdd,
+	}
+	sourcesAddCmd.Flags().StringVar(&sourceName, "name", "", "Name of the source (default: the repository name)")
+	sourcesAddCmd.Flags().StringVar(&sourceRef, "ref", "", "Branch, tag or commit to pin (default: the default branch)")
+	sourcesAddCmd.Flags().StringVar(&sourceDir, "dir", "", "Subdirectory of the repository holding the docs")
+	sourcesCmd.AddCommand(sourcesAddCmd)
+	sourcesCmd.AddCommand(&cobra.Command{
+		Use:   "sync [name...]",
+		Short: "Pull the latest docs for each source's ref and reindex",
+		RunE:  runSourcesSync,
+	})
+	sourcesCmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List command doc sources",
+		Args:  cobra.NoArgs,
+		RunE:  runSourcesList,
+	})
+
 	rootCmd.AddCommand(configureCmd)
 	rootCmd.AddCommand(indexCmd)
 	rootCmd.AddCommand(listCommandsCmd)
+	rootCmd.AddCommand(scriptCmd)
+	rootCmd.AddCommand(sourcesCmd)
+	rootCmd.AddCommand(commandsCmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
@@ -396,34 +483,9 @@ func enableCustomCommands(cfg *config.Config) error {
 		return err
 	}
 
-	var customCfg *config.CustomCommands
-
-	switch provider {
-	case "ollama":
-		if err := customcmd.SetupOllama(); err != nil {
-			return err
-		}
-		customCfg = config.NewDefaultCustomCommands(config.ProviderOllama)
-
-	case "openai":
-		apiKey, useEnv, err := customcmd.SetupOpenAI()
-		if err != nil {
-			return err
-		}
-		customCfg = config.NewDefaultCustomCommands(config.ProviderOpenAI)
-		if !useEnv {
-			customCfg.OpenAI.APIKey = apiKey
-		}
-		customCfg.OpenAI.UseEnvVar = useEnv
-
-	case "none":
-		if err := customcmd.SetupKeywordOnly(); err != nil {
-			return err
-		}
-		customCfg = config.NewDefaultCustomCommands(config.ProviderNone)
-
-	default:
-		return fmt.Errorf("unknown provider: %s", provider)
+	customCfg, err := setupProvider(provider)
+	if err != nil {
+		return err
 	}
 
 	cfg.CustomCommands = customCfg
@@ -448,6 +510,73 @@ func enableCustomCommands(cfg *config.Config) error {
 	return nil
 }
 
+// setupProvider runs the setup flow for an embedding provider chosen in the
+// wizard and returns the custom commands config for it
+func setupProvider(provider string) (*config.CustomCommands, error) {
+	switch provider {
+	case "ollama":
+		if err := customcmd.SetupOllama(); err != nil {
+			return nil, err
+		}
+		return config.NewDefaultCustomCommands(config.ProviderOllama), nil
+
+	case "openai":
+		apiKey, useEnv, err := customcmd.SetupOpenAI()
+		if err != nil {
+			return nil, err
+		}
+		customCfg := config.NewDefaultCustomCommands(config.ProviderOpenAI)
+		if !useEnv {
+			customCfg.OpenAI.APIKey = apiKey
+		}
+		customCfg.OpenAI.UseEnvVar = useEnv
+		return customCfg, nil
+
+	case "tei":
+		url, err := customcmd.SetupTEI()
+		if err != nil {
+			return nil, err
+		}
+		customCfg := config.NewDefaultCustomCommands(config.ProviderTEI)
+		customCfg.TEI.URL = url
+		return customCfg, nil
+
+	case "llamacpp":
+		url, err := customcmd.SetupLlamaCpp()
+		if err != nil {
+			return nil, err
+		}
+		customCfg := config.NewDefaultCustomCommands(config.ProviderLlamaCpp)
+		customCfg.LlamaCpp.URL = url
+		return customCfg, nil
+
+	case "cohere":
+		apiKey, useEnv, err := customcmd.SetupCohere()
+		if err != nil {
+			return nil, err
+		}
+		customCfg := config.NewDefaultCustomCommands(config.ProviderCohere)
+		if !useEnv {
+			customCfg.Cohere.APIKey = apiKey
+		}
+		customCfg.Cohere.UseEnvVar = useEnv
+		return customCfg, nil
+
+	case "local":
+		customcmd.SetupLocal()
+		return config.NewDefaultCustomCommands(config.ProviderLocal), nil
+
+	case "none":
+		if err := customcmd.SetupKeywordOnly(); err != nil {
+			return nil, err
+		}
+		return config.NewDefaultCustomCommands(config.ProviderNone), nil
+
+	default:
+		return nil, fmt.Errorf("unknown provider: %s", provider)
+	}
+}
+
 // disableCustomCommands disables custom commands feature
 func disableCustomCommands(cfg *config.Config) error {
 	confirmed, err := ui.PromptYesNo("Are you sure you want to disable custom commands?", false)
@@ -485,34 +614,9 @@ func changeEmbeddingProvider(cfg *config.Config) error {
 		return nil
 	}
 
-	var customCfg *config.CustomCommands
-
-	switch provider {
-	case "ollama":
-		if err := customcmd.SetupOllama(); err != nil {
-			return err
-		}
-		customCfg = config.NewDefaultCustomCommands(config.ProviderOllama)
-
-	case "openai":
-		apiKey, useEnv, err := customcmd.SetupOpenAI()
-		if err != nil {
-			return err
-		}
-		customCfg = config.NewDefaultCustomCommands(config.ProviderOpenAI)
-		if !useEnv {
-			customCfg.OpenAI.APIKey = apiKey
-		}
-		customCfg.OpenAI.UseEnvVar = useEnv
-
-	case "none":
-		if err := customcmd.SetupKeywordOnly(); err != nil {
-			return err
-		}
-		customCfg = config.NewDefaultCustomCommands(config.ProviderNone)
-
-	default:
-		return fmt.Errorf("unknown provider: %s", provider)
+	customCfg, err := setupProvider(provider)
+	if err != nil {
+		return err
 	}
 
 	cfg.CustomCommands = customCfg
@@ -536,22 +640,7 @@ func reindexCommands(cfg *config.Config) error {
 		return fmt.Errorf("failed to create manager: %w", err)
 	}
 
-	// Configure embeddings if enabled
-	if cfg.CustomCommands.Provider != config.ProviderNone {
-		provider := string(cfg.CustomCommands.Provider)
-		model := ""
-		dims := 0
-
-		if cfg.CustomCommands.Provider == config.ProviderOllama {
-			model = cfg.CustomCommands.Ollama.Model
-			dims = 384
-		} else if cfg.CustomCommands.Provider == config.ProviderOpenAI {
-			model = "text-embedding-3-small"
-			dims = 1536
-		}
-
-		manager.SetEmbeddingConfig(provider, model, dims)
-	}
+	configureManager(manager, cfg)
 
 	ctx := context.Background()
 	if err := manager.Index(ctx, true); err != nil { // force=true
@@ -647,92 +736,15 @@ func runCommand(cmd *cobra.Command, args []string) error {
 		fmt.Fprintf(os.Stderr, "[DEBUG] Main: starting with request: %q\n", request)
 	}
 
-	// Load configuration
-	if debug {
-		configPath, _ := config.GetConfigPath()
-		fmt.Fprintf(os.Stderr, "[DEBUG] Config: loading from %s\n", configPath)
-	}
-
-	cfg, err := config.Load()
-	if err != nil {
-		return fmt.Errorf("failed to load configuration: %w", err)
-	}
-
-	if cfg == nil {
-		ui.ShowError("No configuration found. Please run 'please configure' first.")
-		return nil
-	}
-
+	// Detect the shell commands will be generated for and run in
+	sh := shell.Detect()
 	if debug {
-		fmt.Fprintf(os.Stderr, "[DEBUG] Config: loaded successfully (agent=%s, custom_commands=%v)\n",
-			cfg.Agent, cfg.CustomCommands != nil && cfg.CustomCommands.Enabled)
-	}
-
-	// Check if Claude CLI is installed
-	if !agent.IsClaudeCLIInstalled() {
-		ui.ShowError("Claude CLI not found!")
-		ui.ShowInfo("Please install and authenticate with Claude CLI, then run 'please configure'")
-		return nil
+		fmt.Fprintf(os.Stderr, "[DEBUG] Shell: detected %s\n", sh)
 	}
 
-	// Create agent
-	var ag agent.Agent
-	var claudeAg *agent.ClaudeAgent
-	switch cfg.Agent {
-	case config.AgentClaude:
-		if debug {
-			fmt.Fprintf(os.Stderr, "[DEBUG] Agent: creating Claude agent\n")
-		}
-		claudeAg = agent.NewClaudeAgent()
-		claudeAg.SetDebug(debug)
-		ag = claudeAg
-	default:
-		return fmt.Errorf("unknown agent type: %s", cfg.Agent)
-	}
-
-	// Setup custom commands if enabled
-	if cfg.CustomCommands != nil && cfg.CustomCommands.Enabled && claudeAg != nil {
-		if debug {
-			fmt.Fprintf(os.Stderr, "[DEBUG] CustomCmd: setting up (provider=%s, strategy=%s)\n",
-				cfg.CustomCommands.Provider, cfg.CustomCommands.Matching.Strategy)
-		}
-		cmdManager, err := setupCustomCommands(cfg)
-		if err != nil {
-			ui.ShowWarning(fmt.Sprintf("Custom commands setup failed: %v", err))
-			if debug {
-				fmt.Fprintf(os.Stderr, "[DEBUG] CustomCmd: setup failed: %v\n", err)
-			}
-		} else if cmdManager != nil {
-			if debug {
-				fmt.Fprintf(os.Stderr, "[DEBUG] CustomCmd: manager created with %d commands\n", cmdManager.Count())
-			}
-			// Set up the custom doc getter function
-			claudeAg.SetCustomDocGetter(func(request string, maxDocs int) []agent.CustomCommandDoc {
-				docs := cmdManager.GetRelevantDocsForAgent(request, maxDocs)
-				if debug {
-					fmt.Fprintf(os.Stderr, "[DEBUG] CustomCmd: matched %d docs for request %q\n", len(docs), request)
-					for _, doc := range docs {
-						fmt.Fprintf(os.Stderr, "[DEBUG] CustomCmd:   - %s (%d examples)\n", doc.Command, len(doc.Examples))
-					}
-				}
-				// Convert to agent types
-				agentDocs := make([]agent.CustomCommandDoc, len(docs))
-				for i, doc := range docs {
-					agentDocs[i] = agent.CustomCommandDoc{
-						Command:  doc.Command,
-						Content:  doc.Content,
-						Examples: make([]agent.CommandExample, len(doc.Examples)),
-					}
-					for j, ex := range doc.Examples {
-						agentDocs[i].Examples[j] = agent.CommandExample{
-							UserRequest: ex.UserRequest,
-							Command:     ex.Command,
-						}
-					}
-				}
-				return agentDocs
-			})
-		}
+	cfg, ag, manager, err := loadAgent(sh)
+	if err != nil || cfg == nil {
+		return err
 	}
 
 	// Load history
@@ -765,6 +777,16 @@ func runCommand(cmd *cobra.Command, args []string) error {
 
 	// Interactive loop for modification
 	for {
+		// Check syntax with the shell's no-exec mode before offering to run
+		if err := sh.Validate(currentCommand); err != nil {
+			ui.ShowWarning(fmt.Sprintf("Syntax check failed: %v", err))
+		}
+
+		if placeholders := placeholder.Find(currentCommand); len(placeholders) > 0 {
+			ui.ShowWarning(fmt.Sprintf("Unresolved placeholders: %s (you'll be asked for values before running)",
+				describePlaceholders(placeholders)))
+		}
+
 		// Show command and get user action
 		action, err := ui.ConfirmCommand(currentCommand)
 		if err != nil {
@@ -776,16 +798,36 @@ func runCommand(cmd *cobra.Command, args []string) error {
 			if debug {
 				fmt.Fprintf(os.Stderr, "[DEBUG] User: chose to run command\n")
 			}
+			// Fill in any <placeholders> the model left in the command
+			filled, err := fillPlaceholders(ctx, currentCommand, placeholderSpecs(cfg, manager, request), sh)
+			if err != nil {
+				ui.ShowError(fmt.Sprintf("Placeholders not filled: %v", err))
+				continue
+			}
+			if filled != currentCommand {
+				currentCommand = filled
+				ui.ShowInfo(fmt.Sprintf("Running: %s", currentCommand))
+			}
+
 			// Execute the command
-			if err := executor.ExecuteWithDebug(currentCommand, debug); err != nil {
+			result, err := executor.Run(currentCommand, executionOptions(cfg, sh))
+			status := history.StatusSucceeded
+			if errors.Is(err, executor.ErrTimeout) {
+				status = history.StatusTimedOut
+				ui.ShowError(fmt.Sprintf("Command killed by timeout after %s", result.Duration.Round(time.Millisecond)))
+			} else if err != nil {
+				status = history.StatusFailed
 				ui.ShowError(fmt.Sprintf("Command failed: %v", err))
 			}
 
 			// Save to history
 			if debug {
-				fmt.Fprintf(os.Stderr, "[DEBUG] History: saving entry (executed=true, modifications=%d)\n", len(modifications))
+				fmt.Fprintf(os.Stderr, "[DEBUG] History: saving entry (executed=true, status=%s, modifications=%d)\n", status, len(modifications))
 			}
 			entry := history.NewEntry(request, currentCommand, true, modifications)
+			entry.Status = status
+			entry.ExitCode = result.ExitCode
+			entry.DurationMs = result.Duration.Milliseconds()
 			hist.AddEntry(entry)
 			if err := hist.Save(); err != nil {
 				// Log error but don't fail
@@ -808,6 +850,38 @@ func runCommand(cmd *cobra.Command, args []string) error {
 
 			// Loop continues to show the command again
 
+		case ui.ActionStep:
+			if debug {
+				fmt.Fprintf(os.Stderr, "[DEBUG] User: chose to step through command\n")
+			}
+			filled, err := fillPlaceholders(ctx, currentCommand, placeholderSpecs(cfg, manager, request), sh)
+			if err != nil {
+				ui.ShowError(fmt.Sprintf("Placeholders not filled: %v", err))
+				continue
+			}
+			currentCommand = filled
+
+			outcome, ok := stepCommand(ctx, ag, cfg, sh, currentCommand)
+			if !ok {
+				continue
+			}
+			currentCommand = outcome.Command
+			if !outcome.Ran {
+				// Nothing ran yet; go back to the menu with any stage edits applied
+				continue
+			}
+
+			entry := history.NewEntry(request, currentCommand, true, modifications)
+			entry.Status = outcome.Status
+			entry.ExitCode = outcome.ExitCode
+			entry.DurationMs = outcome.Duration.Milliseconds()
+			hist.AddEntry(entry)
+			if err := hist.Save(); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to save history: %v\n", err)
+			}
+
+			return nil
+
 		case ui.ActionCopy:
 			// Copy to clipboard
 			if err := clipboard.WriteAll(currentCommand); err != nil {
@@ -823,6 +897,7 @@ func runCommand(cmd *cobra.Command, args []string) error {
 
 			// Save to history (not executed)
 			entry := history.NewEntry(request, currentCommand, false, modifications)
+			entry.Status = history.StatusCancelled
 			hist.AddEntry(entry)
 			if err := hist.Save(); err != nil {
 				fmt.Fprintf(os.Stderr, "Warning: failed to save history: %v\n", err)
@@ -864,6 +939,136 @@ func runCommand(cmd *cobra.Command, args []string) error {
 	}
 }
 
+// loadAgent loads the configuration and creates the configured agent, wiring
+// up custom command docs when enabled (the manager is nil otherwise). It
+// returns a nil config (and no error) when please isn't set up yet; the user
+// has already been told what to do.
+func loadAgent(sh *shell.Shell) (*config.Config, agent.Agent, *customcmd.Manager, error) {
+	// Load configuration
+	if debug {
+		configPath, _ := config.GetConfigPath()
+		fmt.Fprintf(os.Stderr, "[DEBUG] Config: loading from %s\n", configPath)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	if cfg == nil {
+		ui.ShowError("No configuration found. Please run 'please configure' first.")
+		return nil, nil, nil, nil
+	}
+
+	if debug {
+		fmt.Fprintf(os.Stderr, "[DEBUG] Config: loaded successfully (agent=%s, custom_commands=%v)\n",
+			cfg.Agent, cfg.CustomCommands != nil && cfg.CustomCommands.Enabled)
+	}
+
+	// Check if Claude CLI is installed
+	if !agent.IsClaudeCLIInstalled() {
+		ui.ShowError("Claude CLI not found!")
+		ui.ShowInfo("Please install and authenticate with Claude CLI, then run 'please configure'")
+		return nil, nil, nil, nil
+	}
+
+	// Create agent
+	var ag agent.Agent
+	var claudeAg *agent.ClaudeAgent
+	switch cfg.Agent {
+	case config.AgentClaude:
+		if debug {
+			fmt.Fprintf(os.Stderr, "[DEBUG] Agent: creating Claude agent\n")
+		}
+		claudeAg = agent.NewClaudeAgent()
+		claudeAg.SetDebug(debug)
+		claudeAg.SetShell(sh)
+		ag = claudeAg
+	default:
+		return nil, nil, nil, fmt.Errorf("unknown agent type: %s", cfg.Agent)
+	}
+
+	// Setup custom commands if enabled
+	var manager *customcmd.Manager
+	if cfg.CustomCommands != nil && cfg.CustomCommands.Enabled && claudeAg != nil {
+		if debug {
+			fmt.Fprintf(os.Stderr, "[DEBUG] CustomCmd: setting up (provider=%s, strategy=%s)\n",
+				cfg.CustomCommands.Provider, cfg.CustomCommands.Matching.Strategy)
+		}
+		cmdManager, err := setupCustomCommands(cfg)
+		if err != nil {
+			ui.ShowWarning(fmt.Sprintf("Custom commands setup failed: %v", err))
+			if debug {
+				fmt.Fprintf(os.Stderr, "[DEBUG] CustomCmd: setup failed: %v\n", err)
+			}
+		} else if cmdManager != nil {
+			manager = cmdManager
+			if debug {
+				fmt.Fprintf(os.Stderr, "[DEBUG] CustomCmd: manager created with %d commands\n", cmdManager.Count())
+			}
+			// Set up the custom doc getter function and the prompt budget for its docs
+			matching := cfg.CustomCommands.Matching
+			claudeAg.SetContextLimits(matching.MaxDocsPerReq, matching.TokenBudget)
+			claudeAg.SetCustomDocGetter(func(request string, maxDocs int) []agent.CustomCommandDoc {
+				docs := cmdManager.GetRelevantDocsForAgent(request, maxDocs)
+				if debug {
+					fmt.Fprintf(os.Stderr, "[DEBUG] CustomCmd: matched %d docs for request %q\n", len(docs), request)
+					for _, doc := range docs {
+						fmt.Fprintf(os.Stderr, "[DEBUG] CustomCmd:   - %s (%d examples)\n", doc.Command, len(doc.Examples))
+					}
+				}
+				// Convert to agent types
+				agentDocs := make([]agent.CustomCommandDoc, len(docs))
+				for i, doc := range docs {
+					agentDocs[i] = agent.CustomCommandDoc{
+						Command:  doc.Command,
+						Content:  doc.Content,
+						Examples: make([]agent.CommandExample, len(doc.Examples)),
+						Sections: doc.Sections,
+					}
+					for j, ex := range doc.Examples {
+						agentDocs[i].Examples[j] = agent.CommandExample{
+							UserRequest: ex.UserRequest,
+							Command:     ex.Command,
+						}
+					}
+				}
+				return agentDocs
+			})
+		}
+	}
+
+	return cfg, ag, manager, nil
+}
+
+// executionOptions builds executor options from config, with CLI flags taking precedence
+func executionOptions(cfg *config.Config, sh *shell.Shell) executor.Options {
+	opts := executor.Options{Shell: sh, Debug: debug}
+
+	if cfg.Execution != nil {
+		opts.Timeout = time.Duration(cfg.Execution.TimeoutSeconds) * time.Second
+		opts.CPUSeconds = cfg.Execution.MaxCPUSeconds
+		opts.MemoryMB = cfg.Execution.MaxMemoryMB
+	}
+
+	if timeout > 0 {
+		opts.Timeout = timeout
+	}
+	if maxCPU > 0 {
+		opts.CPUSeconds = maxCPU
+	}
+	if maxMemoryMB > 0 {
+		opts.MemoryMB = maxMemoryMB
+	}
+
+	if debug {
+		fmt.Fprintf(os.Stderr, "[DEBUG] Executor: options (timeout=%s, cpu=%ds, memory=%dMB)\n",
+			opts.Timeout, opts.CPUSeconds, opts.MemoryMB)
+	}
+
+	return opts
+}
+
 // setupCustomCommands creates and initializes the custom command manager
 func setupCustomCommands(cfg *config.Config) (*customcmd.Manager, error) {
 	if debug {
@@ -890,27 +1095,47 @@ func setupCustomCommands(cfg *config.Config) (*customcmd.Manager, error) {
 		return manager, nil
 	}
 
-	// Load/index commands (auto-index on first run or if stale)
-	if !manager.IsIndexed() || manager.NeedsReindex() {
-		if debug {
-			fmt.Fprintf(os.Stderr, "[DEBUG] CustomCmd: needs indexing (indexed=%v, stale=%v)\n",
-				manager.IsIndexed(), manager.NeedsReindex())
-		}
-		ui.ShowInfo("Indexing custom commands...")
+	configureManager(manager, cfg)
+
+	// Keyword-only matching just needs the docs loaded
+	if cfg.CustomCommands.Provider == config.ProviderNone || cfg.CustomCommands.Matching.Strategy == "keyword" {
 		if err := manager.Load(); err != nil {
-			return nil, fmt.Errorf("failed to index commands: %w", err)
+			return nil, fmt.Errorf("failed to load commands: %w", err)
 		}
-		ui.ShowSuccess(fmt.Sprintf("Indexed %d custom commands", manager.Count()))
 		if debug {
-			fmt.Fprintf(os.Stderr, "[DEBUG] CustomCmd: indexed %d commands successfully\n", manager.Count())
+			fmt.Fprintf(os.Stderr, "[DEBUG] CustomCmd: loaded %d commands for keyword matching\n", manager.Count())
 		}
-	} else if debug {
-		fmt.Fprintf(os.Stderr, "[DEBUG] CustomCmd: using cached index (%d commands)\n", manager.Count())
+		return manager, nil
+	}
+
+	// Load docs and the cached embeddings, re-embedding if the cache is stale
+	if err := manager.Index(context.Background(), false); err != nil {
+		ui.ShowWarning(fmt.Sprintf("Semantic search unavailable: %v", err))
+		ui.ShowInfo("Falling back to keyword matching")
+		if err := manager.Load(); err != nil {
+			return nil, fmt.Errorf("failed to load commands: %w", err)
+		}
+	}
+
+	if debug {
+		fmt.Fprintf(os.Stderr, "[DEBUG] CustomCmd: loaded %d commands (semantic=%v)\n",
+			manager.Count(), manager.IsSemanticIndexed())
 	}
 
 	return manager, nil
 }
 
+// configureManager applies the embedding and matching settings from the config
+func configureManager(manager *customcmd.Manager, cfg *config.Config) {
+	manager.SetMatchingConfig(cfg.CustomCommands.Matching)
+
+	if cfg.CustomCommands.Provider == config.ProviderNone {
+		return
+	}
+
+	manager.SetEmbeddingConfig(*cfg.CustomCommands)
+}
+
 // runIndex indexes custom command documentation
 func runIndex(cmd *cobra.Command, args []string) error {
 	ui.ShowSection("Indexing Custom Commands")
@@ -952,22 +1177,7 @@ func runIndex(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create manager: %w", err)
 	}
 
-	// Configure embeddings if enabled
-	if cfg.CustomCommands.Provider != config.ProviderNone {
-		provider := string(cfg.CustomCommands.Provider)
-		model := ""
-		dims := 0
-
-		if cfg.CustomCommands.Provider == config.ProviderOllama {
-			model = cfg.CustomCommands.Ollama.Model
-			dims = 384 // nomic-embed-text
-		} else if cfg.CustomCommands.Provider == config.ProviderOpenAI {
-			model = "text-embedding-3-small"
-			dims = 1536
-		}
-
-		manager.SetEmbeddingConfig(provider, model, dims)
-	}
+	configureManager(manager, cfg)
 
 	if forceReindex {
 		ui.ShowInfo("Force reindexing (--force flag)")
@@ -986,8 +1196,8 @@ func runIndex(cmd *cobra.Command, args []string) error {
 	fmt.Println()
 	ui.ShowInfo("Commands indexed:")
 	for _, doc := range docs {
-		fmt.Printf("  • %s (%d examples, %d keywords)\n",
-			doc.Command, len(doc.Examples), len(doc.Keywords))
+		fmt.Printf("  • %s (%s, %d examples, %d keywords)\n",
+			doc.Name(), doc.Source, len(doc.Examples), len(doc.Keywords))
 	}
 
 	return nil
@@ -1048,7 +1258,8 @@ func runListCommands(cmd *cobra.Command, args []string) error {
 	fmt.Printf("Indexed %s ago\n\n", formatDuration(manager.GetIndexTime()))
 
 	for _, doc := range docs {
-		fmt.Printf("📦 %s\n", doc.Command)
+		fmt.Printf("📦 %s\n", doc.Name())
+		fmt.Printf("   Source: %s (%s)\n", doc.Source, doc.Filename)
 		if len(doc.Aliases) > 0 {
 			fmt.Printf("   Aliases: %s\n", strings.Join(doc.Aliases, ", "))
 		}
@@ -1073,13 +1284,27 @@ func runListCommands(cmd *cobra.Command, args []string) error {
 		providerName = fmt.Sprintf("Ollama (%s)", cfg.CustomCommands.Ollama.Model)
 	case config.ProviderOpenAI:
 		providerName = fmt.Sprintf("OpenAI (%s)", cfg.CustomCommands.OpenAI.Model)
+	case config.ProviderTEI:
+		providerName = fmt.Sprintf("Text Embeddings Inference (%s)", cfg.CustomCommands.TEI.URL)
+	case config.ProviderLlamaCpp:
+		providerName = fmt.Sprintf("llama.cpp server (%s)", cfg.CustomCommands.LlamaCpp.URL)
+	case config.ProviderCohere:
+		providerName = fmt.Sprintf("Cohere (%s)", cfg.CustomCommands.Cohere.Model)
+	case config.ProviderLocal:
+		providerName = "built-in embeddings"
 	}
 
 	fmt.Printf("Provider: %s\n", providerName)
 	fmt.Printf("Strategy: %s\n", cfg.CustomCommands.Matching.Strategy)
 
-	commandsDir, _ := customcmd.GetCommandsDir()
-	fmt.Printf("\nCommands directory: %s\n", commandsDir)
+	fmt.Println("\nCommand directories (earlier ones take precedence):")
+	for _, dir := range manager.Dirs() {
+		status := ""
+		if _, err := os.Stat(dir.Path); err != nil {
+			status = " (not found)"
+		}
+		fmt.Printf("  %-8s %s%s\n", dir.Source+":", dir.Path, status)
+	}
 	ui.ShowInfo("Run 'please index' to re-index after changes")
 
 	return nil
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/iishyfishyy/please/internal/agent"
+	"github.com/iishyfishyy/please/internal/config"
+	"github.com/iishyfishyy/please/internal/customcmd"
+	"github.com/iishyfishyy/please/internal/placeholder"
+	"github.com/iishyfishyy/please/internal/shell"
+	"github.com/iishyfishyy/please/internal/ui"
+)
+
+// placeholderSpec is a placeholder spec and where the doc declaring it was
+// loaded from
+type placeholderSpec struct {
+	placeholder.Spec
+	Scope string // e.g. customcmd.SourceUser
+}
+
+// trusted reports whether the spec's source command may run without asking:
+// only docs in ~/.please/commands and /etc/please/commands are trusted, not
+// ones from the project directory or a git source, which anyone can write
+func (s placeholderSpec) trusted() bool {
+	return s.Scope == customcmd.SourceUser || s.Scope == customcmd.SourceSystem
+}
+
+// placeholderSpecs collects placeholder specs from the custom command docs
+// relevant to the request
+func placeholderSpecs(cfg *config.Config, manager *customcmd.Manager, request string) map[string]placeholderSpec {
+	specs := make(map[string]placeholderSpec)
+	if manager == nil {
+		return specs
+	}
+
+	maxDocs := agent.DefaultMaxDocs
+	if cfg.CustomCommands != nil && cfg.CustomCommands.Matching.MaxDocsPerReq > 0 {
+		maxDocs = cfg.CustomCommands.Matching.MaxDocsPerReq
+	}
+
+	for _, doc := range manager.GetRelevantDocs(request, maxDocs) {
+		for name, spec := range doc.Placeholders {
+			// The most relevant doc wins when several declare the same name
+			if _, ok := specs[name]; !ok {
+				specs[name] = placeholderSpec{Spec: spec, Scope: doc.Source}
+			}
+		}
+	}
+
+	return specs
+}
+
+// describePlaceholders lists the placeholders for display, e.g. "<pod-name>, $NAMESPACE"
+func describePlaceholders(placeholders []placeholder.Placeholder) string {
+	tokens := make([]string, len(placeholders))
+	for i, p := range placeholders {
+		tokens[i] = p.Tokens[0]
+	}
+	return strings.Join(tokens, ", ")
+}
+
+// fillPlaceholders prompts for every unresolved placeholder in the command
+// and returns the command with the values substituted
+func fillPlaceholders(ctx context.Context, command string, specs map[string]placeholderSpec, sh *shell.Shell) (string, error) {
+	placeholders := placeholder.Find(command)
+	if len(placeholders) == 0 {
+		return command, nil
+	}
+
+	ui.ShowInfo(fmt.Sprintf("Fill in %d placeholder(s) before running:", len(placeholders)))
+
+	values := make(map[string]string)
+	for _, p := range placeholders {
+		spec := specs[p.Name]
+
+		var options []string
+		if spec.Source != "" {
+			run, err := confirmSource(p.Name, spec)
+			if err != nil {
+				return command, err
+			}
+			if run {
+				options, err = placeholder.Options(ctx, spec.Spec, values, sh)
+				if err != nil && debug {
+					fmt.Fprintf(os.Stderr, "[DEBUG] Placeholder: source for %s failed: %v\n", p.Name, err)
+				}
+				if debug {
+					fmt.Fprintf(os.Stderr, "[DEBUG] Placeholder: %s has %d completion values\n", p.Name, len(options))
+				}
+			}
+		}
+
+		value, err := ui.PromptPlaceholder(p.Name, spec.Description, options, spec.Default)
+		if err != nil {
+			return command, err
+		}
+		// An empty answer keeps the token as written, for literals the model
+		// left unquoted, like <br> in a sed expression
+		if value != "" {
+			values[p.Name] = value
+		}
+	}
+
+	return placeholder.Fill(command, placeholders, values), nil
+}
+
+// confirmSource shows an untrusted doc's source command and asks before
+// running it, so a cloned repo's .please/commands can't run commands just
+// by declaring a placeholder
+func confirmSource(name string, spec placeholderSpec) (bool, error) {
+	if spec.trusted() {
+		return true, nil
+	}
+	ui.ShowWarning(fmt.Sprintf("A %s command doc lists values for <%s> by running:\n  %s", spec.Scope, name, spec.Source))
+	run, err := ui.PromptYesNo("Run it to offer completions?", false)
+	if err != nil {
+		return false, fmt.Errorf("failed to get user confirmation: %w", err)
+	}
+	return run, nil
+}
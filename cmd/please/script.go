@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/atotto/clipboard"
+	"github.com/iishyfishyy/please/internal/agent"
+	"github.com/iishyfishyy/please/internal/executor"
+	"github.com/iishyfishyy/please/internal/history"
+	"github.com/iishyfishyy/please/internal/shell"
+	"github.com/iishyfishyy/please/internal/ui"
+
+	"github.com/spf13/cobra"
+)
+
+// runScript generates a multi-line bash script for a task description
+func runScript(cmd *cobra.Command, args []string) error {
+	request := strings.Join(args, " ")
+
+	if debug {
+		fmt.Fprintf(os.Stderr, "[DEBUG] Main: starting script mode with request: %q\n", request)
+	}
+
+	// Scripts are always bash (they use set -euo pipefail), whatever the interactive shell is
+	bash := shell.Lookup("bash")
+
+	cfg, ag, _, err := loadAgent(bash)
+	if err != nil || cfg == nil {
+		return err
+	}
+
+	hist, err := history.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load history: %w", err)
+	}
+
+	modifications := []string{}
+	ctx := context.Background()
+
+	ui.ShowInfo("Writing script...")
+	script, err := ag.GenerateScript(ctx, request)
+	if err != nil {
+		return fmt.Errorf("failed to generate script: %w", err)
+	}
+	if debug {
+		fmt.Fprintf(os.Stderr, "[DEBUG] Agent: generated script (%d lines)\n", strings.Count(script, "\n"))
+	}
+
+	for {
+		if err := bash.Validate(script); err != nil {
+			ui.ShowWarning(fmt.Sprintf("Syntax check failed: %v", err))
+		}
+
+		action, err := ui.ConfirmScript(script)
+		if err != nil {
+			return fmt.Errorf("failed to get user confirmation: %w", err)
+		}
+
+		switch action {
+		case ui.ActionRun:
+			result, err := executor.Run(script, executionOptions(cfg, bash))
+			status := history.StatusSucceeded
+			if errors.Is(err, executor.ErrTimeout) {
+				status = history.StatusTimedOut
+				ui.ShowError(fmt.Sprintf("Script killed by timeout after %s", result.Duration.Round(time.Millisecond)))
+			} else if err != nil {
+				status = history.StatusFailed
+				ui.ShowError(fmt.Sprintf("Script failed: %v", err))
+			}
+
+			entry := history.NewEntry(request, script, true, modifications)
+			entry.Status = status
+			entry.ExitCode = result.ExitCode
+			entry.DurationMs = result.Duration.Milliseconds()
+			hist.AddEntry(entry)
+			if err := hist.Save(); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to save history: %v\n", err)
+			}
+
+			return nil
+
+		case ui.ActionExplain:
+			ui.ShowInfo("Explaining...")
+			explanation, err := ag.ExplainCommand(ctx, script, request)
+			if err != nil {
+				ui.ShowError(fmt.Sprintf("Failed to get explanation: %v", err))
+			} else {
+				fmt.Println("\n" + ui.FormatMarkdown(explanation) + "\n")
+			}
+
+		case ui.ActionSave:
+			path, err := saveScript(script)
+			if err != nil {
+				ui.ShowError(fmt.Sprintf("Failed to save script: %v", err))
+			} else if path != "" {
+				ui.ShowSuccess(fmt.Sprintf("Saved to %s", path))
+			}
+
+		case ui.ActionCopy:
+			if err := clipboard.WriteAll(script); err != nil {
+				ui.ShowError(fmt.Sprintf("Failed to copy to clipboard: %v", err))
+			} else {
+				ui.ShowSuccess("Script copied to clipboard!")
+			}
+
+		case ui.ActionCancel:
+			ui.ShowInfo("Cancelled.")
+
+			entry := history.NewEntry(request, script, false, modifications)
+			entry.Status = history.StatusCancelled
+			hist.AddEntry(entry)
+			if err := hist.Save(); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to save history: %v\n", err)
+			}
+
+			return nil
+
+		case ui.ActionModify:
+			modRequest, err := ui.PromptForModification()
+			if err != nil {
+				return fmt.Errorf("failed to get modification: %w", err)
+			}
+			modifications = append(modifications, modRequest)
+
+			ui.ShowInfo("Refining...")
+			script, err = ag.RefineScript(ctx, script, modRequest)
+			if err != nil {
+				return fmt.Errorf("failed to refine script: %w", err)
+			}
+		}
+	}
+}
+
+// saveScript asks for a file name and writes the script there as an
+// executable file. It returns an empty path if the user declined to overwrite.
+func saveScript(script string) (string, error) {
+	path, err := ui.PromptInput("Save script as:", "script.sh")
+	if err != nil {
+		return "", err
+	}
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return "", fmt.Errorf("no file name given")
+	}
+
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[2:])
+		}
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		overwrite, err := ui.PromptYesNo(fmt.Sprintf("%s already exists. Overwrite?", path), false)
+		if err != nil {
+			return "", err
+		}
+		if !overwrite {
+			ui.ShowInfo("Not saved")
+			return "", nil
+		}
+	}
+
+	if !strings.HasPrefix(script, "#!") {
+		script = agent.ScriptShebang + "\n" + script
+	}
+
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		return "", err
+	}
+	// WriteFile doesn't change the mode of an existing file
+	if err := os.Chmod(path, 0755); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/iishyfishyy/please/internal/config"
+	"github.com/iishyfishyy/please/internal/customcmd"
+	"github.com/iishyfishyy/please/internal/ui"
+
+	"github.com/spf13/cobra"
+)
+
+// Flags for 'please sources add'
+var (
+	sourceName string
+	sourceRef  string
+	sourceDir  string
+)
+
+// runSourcesAdd clones a git repository of command docs and indexes it
+func runSourcesAdd(cmd *cobra.Command, args []string) error {
+	sources, err := customcmd.LoadSources()
+	if err != nil {
+		return err
+	}
+
+	ui.ShowInfo(fmt.Sprintf("Cloning %s...", args[0]))
+	src, err := sources.Add(context.Background(), args[0], sourceName, sourceRef, sourceDir)
+	if err != nil {
+		return fmt.Errorf("failed to add source: %w", err)
+	}
+	if err := sources.Save(); err != nil {
+		return err
+	}
+
+	ui.ShowSuccess(fmt.Sprintf("Added %s at %s", src.Name, shortCommit(src.Commit)))
+	return reindexSources()
+}
+
+// runSourcesSync pulls the named git sources, or all of them, and indexes
+// the docs that changed
+func runSourcesSync(cmd *cobra.Command, args []string) error {
+	sources, err := customcmd.LoadSources()
+	if err != nil {
+		return err
+	}
+	if len(sources.Sources) == 0 {
+		ui.ShowWarning("No sources to sync")
+		ui.ShowInfo("Add one with 'please sources add <git-url|path>'")
+		return nil
+	}
+
+	var targets []*customcmd.GitSource
+	if len(args) == 0 {
+		for i := range sources.Sources {
+			targets = append(targets, &sources.Sources[i])
+		}
+	}
+	for _, name := range args {
+		src, ok := sources.Get(name)
+		if !ok {
+			return fmt.Errorf("unknown source: %s", name)
+		}
+		targets = append(targets, src)
+	}
+
+	ctx := context.Background()
+	failed := 0
+	for _, src := range targets {
+		previous := src.Commit
+		if err := src.Sync(ctx); err != nil {
+			ui.ShowError(fmt.Sprintf("%s: %v", src.Name, err))
+			failed++
+			continue
+		}
+		if src.Commit == previous {
+			ui.ShowInfo(fmt.Sprintf("%s: up to date at %s", src.Name, shortCommit(src.Commit)))
+		} else {
+			ui.ShowSuccess(fmt.Sprintf("%s: %s → %s", src.Name, shortCommit(previous), shortCommit(src.Commit)))
+		}
+	}
+
+	if err := sources.Save(); err != nil {
+		return err
+	}
+	if err := reindexSources(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d sources failed to sync", failed, len(targets))
+	}
+	return nil
+}
+
+// runSourcesList lists the git sources
+func runSourcesList(cmd *cobra.Command, args []string) error {
+	sources, err := customcmd.LoadSources()
+	if err != nil {
+		return err
+	}
+	if len(sources.Sources) == 0 {
+		ui.ShowWarning("No sources added")
+		ui.ShowInfo("Add one with 'please sources add <git-url|path>'")
+		return nil
+	}
+
+	ui.ShowSection("Command Doc Sources")
+	for _, src := range sources.Sources {
+		fmt.Printf("📚 %s\n", src.Name)
+		fmt.Printf("   URL: %s\n", src.URL)
+		ref := src.Ref
+		if ref == "" {
+			ref = "default branch"
+		}
+		fmt.Printf("   Ref: %s (%s)\n", ref, shortCommit(src.Commit))
+		if path, err := src.CommandsPath(); err == nil {
+			status := ""
+			if _, err := os.Stat(path); err != nil {
+				status = " (not found, run 'please sources sync')"
+			}
+			fmt.Printf("   Path: %s%s\n", path, status)
+		}
+		if !src.SyncedAt.IsZero() {
+			fmt.Printf("   Synced: %s\n", formatDuration(src.SyncedAt))
+		}
+		fmt.Println()
+	}
+
+	return nil
+}
+
+// reindexSources brings the index up to date after sources change. Only docs
+// whose content changed are re-embedded.
+func reindexSources() error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if cfg == nil || cfg.CustomCommands == nil || !cfg.CustomCommands.Enabled {
+		ui.ShowInfo("Custom commands are not enabled; run 'please configure' to use these docs")
+		return nil
+	}
+
+	ui.ShowInfo("Indexing...")
+	manager, err := setupCustomCommands(cfg)
+	if err != nil {
+		return err
+	}
+	ui.ShowSuccess(fmt.Sprintf("Indexed %d custom commands", manager.Count()))
+	return nil
+}
+
+// shortCommit abbreviates a commit hash for display
+func shortCommit(commit string) string {
+	if commit == "" {
+		return "none"
+	}
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/iishyfishyy/please/internal/agent"
+	"github.com/iishyfishyy/please/internal/config"
+	"github.com/iishyfishyy/please/internal/executor"
+	"github.com/iishyfishyy/please/internal/history"
+	"github.com/iishyfishyy/please/internal/shell"
+	"github.com/iishyfishyy/please/internal/ui"
+)
+
+const (
+	// stageOutputLines is how many lines of a piped stage's output are shown
+	stageOutputLines = 20
+	// fixContextBytes is how much of a failed stage's output is sent to the agent
+	fixContextBytes = 2000
+)
+
+var (
+	// maxStageOutput caps how much of a stage's output is held in memory. A
+	// piped stage that writes more is cut off, as if the next stage stopped
+	// reading.
+	maxStageOutput = 8 << 20
+	// pipedStageTimeout stops a piped stage that never finishes on its own,
+	// like tail -f, when no execution timeout is configured
+	pipedStageTimeout = time.Minute
+)
+
+// errStageOutputFull stops a piped stage once maxStageOutput is reached
+var errStageOutputFull = errors.New("stage output limit reached")
+
+// stageBuffer collects a stage's output up to a limit. Once full it either
+// fails writes, so a piped producer sees its pipe close and stops, or quietly
+// drops the rest when it only holds a copy of output shown live.
+type stageBuffer struct {
+	mu        sync.Mutex
+	buf       bytes.Buffer
+	limit     int
+	stop      bool // Fail writes once full instead of dropping them
+	truncated bool
+}
+
+func (b *stageBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	room := b.limit - b.buf.Len()
+	if len(p) <= room {
+		return b.buf.Write(p)
+	}
+
+	b.buf.Write(p[:room])
+	b.truncated = true
+	if b.stop {
+		return room, errStageOutputFull
+	}
+	return len(p), nil
+}
+
+// stepOutcome summarises a step-by-step run for history
+type stepOutcome struct {
+	Command  string // Final command line, including any per-stage fixes
+	Status   string
+	ExitCode int
+	Duration time.Duration
+	Ran      bool // Whether any stage was executed
+}
+
+// stepCommand runs a command one stage at a time, showing intermediate output
+// and exit codes and letting the user fix a failing stage via RefineCommand.
+// It returns false if the command can't be split into stages.
+func stepCommand(ctx context.Context, ag agent.Agent, cfg *config.Config, sh *shell.Shell, command string) (*stepOutcome, bool) {
+	if !sh.IsPOSIX() && sh.Kind != shell.KindFish {
+		ui.ShowWarning(fmt.Sprintf("Step mode isn't supported for %s pipelines", sh.Kind))
+		return nil, false
+	}
+
+	stages, err := shell.Split(command)
+	if err != nil {
+		ui.ShowWarning(fmt.Sprintf("Can't step through this command: %v", err))
+		return nil, false
+	}
+	if len(stages) < 2 {
+		ui.ShowInfo("This command has a single stage; run it directly instead")
+		return nil, false
+	}
+
+	if debug {
+		fmt.Fprintf(os.Stderr, "[DEBUG] Step: split command into %d stages\n", len(stages))
+	}
+
+	outcome := &stepOutcome{Status: history.StatusSucceeded}
+	defer func() {
+		outcome.Command = shell.Join(stages)
+	}()
+
+	var piped []byte // output of the previous stage when it feeds this one
+	lastExit := 0
+
+	for i := 0; i < len(stages); i++ {
+		// Honour && and || between pipelines, skipping the whole next pipeline
+		if i > 0 {
+			prevOp := stages[i-1].Op
+			if (prevOp == "&&" && lastExit != 0) || (prevOp == "||" && lastExit == 0) {
+				start := i
+				for i < len(stages)-1 && stages[i].IsPipe() {
+					i++
+				}
+				ui.ShowInfo(fmt.Sprintf("Skipping stages %d-%d (%s after exit code %d)", start+1, i+1, prevOp, lastExit))
+				continue
+			}
+		}
+
+		stage := &stages[i]
+		fromPipe := i > 0 && stages[i-1].IsPipe()
+		input := piped
+
+	prompt:
+		for {
+			action, err := ui.ConfirmStep(i+1, len(stages), stage.Command)
+			if err != nil {
+				outcome.Status = history.StatusStopped
+				return outcome, true
+			}
+
+			switch action {
+			case ui.ActionCancel:
+				outcome.Status = history.StatusStopped
+				return outcome, true
+
+			case ui.ActionSkip:
+				// A skipped pipeline stage passes its input straight through
+				if !stage.IsPipe() {
+					piped = nil
+				}
+				lastExit = 0
+				break prompt
+
+			case ui.ActionModify:
+				if err := modifyStage(ctx, ag, stage, ""); err != nil {
+					ui.ShowError(err.Error())
+				}
+
+			case ui.ActionRun:
+				// Each attempt gets a fresh reader over the previous stage's output
+				var stdin io.Reader
+				if fromPipe {
+					stdin = bytes.NewReader(input)
+				}
+				result, output := runStage(cfg, sh, stage, stdin)
+				outcome.Ran = true
+				outcome.Duration += result.Duration
+				outcome.ExitCode = result.ExitCode
+
+				if result.ExitCode == 0 {
+					if stage.IsPipe() {
+						piped = output
+					}
+					lastExit = 0
+					break prompt
+				}
+
+				// Stage failed: let the user fix just this stage
+				for {
+					choice, err := ui.ConfirmStepFailure(result.ExitCode)
+					if err != nil || choice == ui.ActionCancel {
+						outcome.Status = history.StatusFailed
+						return outcome, true
+					}
+
+					if choice == ui.ActionContinue {
+						if stage.IsPipe() {
+							piped = output
+						}
+						lastExit = result.ExitCode
+						break prompt
+					}
+
+					if choice == ui.ActionRun {
+						continue prompt
+					}
+
+					failure := ""
+					if choice == ui.ActionFix {
+						failure = describeFailure(result.ExitCode, output)
+					}
+					if err := modifyStage(ctx, ag, stage, failure); err != nil {
+						ui.ShowError(err.Error())
+						continue
+					}
+					continue prompt
+				}
+			}
+		}
+	}
+
+	if lastExit != 0 {
+		outcome.Status = history.StatusFailed
+	}
+	return outcome, true
+}
+
+// runStage executes one stage. Piped stages have their output captured and
+// previewed; the final stage of a pipeline writes to the terminal as usual.
+func runStage(cfg *config.Config, sh *shell.Shell, stage *shell.Stage, stdin io.Reader) (*executor.Result, []byte) {
+	output := &stageBuffer{limit: maxStageOutput, stop: stage.IsPipe()}
+	opts := executionOptions(cfg, sh)
+	opts.Stdin = stdin
+
+	defaultTimeout := false
+	if stage.IsPipe() {
+		opts.Stdout = output
+		if stage.Op == "|&" {
+			opts.Stderr = output
+		}
+		if opts.Timeout == 0 {
+			opts.Timeout = pipedStageTimeout
+			defaultTimeout = true
+		}
+	} else {
+		// Show output live, but keep a copy in case the agent needs it for a fix
+		opts.Stdout = io.MultiWriter(os.Stdout, output)
+		opts.Stderr = io.MultiWriter(os.Stderr, output)
+	}
+
+	result, err := executor.Run(stage.Command, opts)
+	if stage.IsPipe() && output.truncated && !result.TimedOut {
+		// The producer was cut off by the limit rather than failing on its own
+		ui.ShowWarning(fmt.Sprintf("Stage output passed %d MB; only the first %d MB is passed on",
+			maxStageOutput>>20, maxStageOutput>>20))
+		result.ExitCode = 0
+	} else if errors.Is(err, executor.ErrTimeout) {
+		ui.ShowError("Stage killed by timeout")
+		if defaultTimeout {
+			ui.ShowInfo(fmt.Sprintf("Piped stages are stopped after %s; use --timeout to allow longer", pipedStageTimeout))
+		}
+	} else if err != nil && result.ExitCode < 0 {
+		ui.ShowError(fmt.Sprintf("Stage failed: %v", err))
+	}
+
+	if stage.IsPipe() {
+		ui.ShowStageOutput(output.buf.String(), result.ExitCode, stageOutputLines)
+	} else if result.ExitCode == 0 {
+		ui.ShowSuccess("exit code 0")
+	} else {
+		ui.ShowError(fmt.Sprintf("exit code %d", result.ExitCode))
+	}
+
+	return result, output.buf.Bytes()
+}
+
+// modifyStage refines a single stage with the agent. With a failure
+// description it asks for a fix; otherwise it prompts the user for a change.
+func modifyStage(ctx context.Context, ag agent.Agent, stage *shell.Stage, failure string) error {
+	modRequest := failure
+	if modRequest == "" {
+		var err error
+		modRequest, err = ui.PromptForModification()
+		if err != nil {
+			return fmt.Errorf("failed to get modification: %w", err)
+		}
+	}
+
+	ui.ShowInfo("Refining stage...")
+	if debug {
+		fmt.Fprintf(os.Stderr, "[DEBUG] Step: refining stage %q with %q\n", stage.Command, modRequest)
+	}
+	refined, err := ag.RefineCommand(ctx, stage.Command, modRequest)
+	if err != nil {
+		return fmt.Errorf("failed to refine stage: %w", err)
+	}
+
+	stage.Command = refined
+	return nil
+}
+
+// describeFailure builds a fix request from a stage's exit code and output
+func describeFailure(exitCode int, output []byte) string {
+	if len(output) > fixContextBytes {
+		output = output[len(output)-fixContextBytes:]
+	}
+	return fmt.Sprintf("This command failed with exit code %d. Fix it so it succeeds. Its output was:\n%s",
+		exitCode, bytes.TrimSpace(output))
+}
@@ -0,0 +1,184 @@
+//go:build !windows
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iishyfishyy/please/internal/agent"
+	"github.com/iishyfishyy/please/internal/config"
+	"github.com/iishyfishyy/please/internal/history"
+	"github.com/iishyfishyy/please/internal/shell"
+)
+
+var stepShell = &shell.Shell{Kind: shell.KindSh, Path: "/bin/sh"}
+
+// fixAgent answers RefineCommand with a fixed command and records the request
+type fixAgent struct {
+	agent.Agent
+	fixed    string
+	requests []string
+}
+
+func (a *fixAgent) RefineCommand(ctx context.Context, originalCommand, modificationRequest string) (string, error) {
+	a.requests = append(a.requests, modificationRequest)
+	return a.fixed, nil
+}
+
+// pressKeys feeds keys to the step prompts through a fake stdin
+func pressKeys(t *testing.T, keys string) {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.WriteString(keys); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	saved := os.Stdin
+	os.Stdin = r
+	t.Cleanup(func() {
+		os.Stdin = saved
+		r.Close()
+	})
+}
+
+func readOutput(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+	return string(data)
+}
+
+func TestStepCommandRunsStages(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+	pressKeys(t, "rr")
+
+	command := fmt.Sprintf("printf 'b\\na\\n' | sort > %s", out)
+	outcome, ok := stepCommand(context.Background(), &fixAgent{}, &config.Config{}, stepShell, command)
+	if !ok {
+		t.Fatal("stepCommand() ok = false, want true")
+	}
+	if outcome.Status != history.StatusSucceeded || !outcome.Ran {
+		t.Errorf("stepCommand() outcome = %+v, want a succeeded run", outcome)
+	}
+	if outcome.Command != command {
+		t.Errorf("stepCommand() command = %q, want %q", outcome.Command, command)
+	}
+	if got := readOutput(t, out); got != "a\nb\n" {
+		t.Errorf("pipeline output = %q, want %q", got, "a\nb\n")
+	}
+}
+
+func TestStepCommandSkipPassesInputThrough(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+	pressKeys(t, "rsr")
+
+	command := fmt.Sprintf("echo x | tr x y | cat > %s", out)
+	if _, ok := stepCommand(context.Background(), &fixAgent{}, &config.Config{}, stepShell, command); !ok {
+		t.Fatal("stepCommand() ok = false, want true")
+	}
+	if got := readOutput(t, out); got != "x\n" {
+		t.Errorf("pipeline output = %q, want the skipped stage's input %q", got, "x\n")
+	}
+}
+
+func TestStepCommandCancel(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+	pressKeys(t, "q")
+
+	outcome, _ := stepCommand(context.Background(), &fixAgent{}, &config.Config{}, stepShell, "echo x > "+out+"; echo y")
+	if outcome.Status != history.StatusStopped || outcome.Ran {
+		t.Errorf("stepCommand() outcome = %+v, want stopped before running", outcome)
+	}
+	if _, err := os.Stat(out); err == nil {
+		t.Error("cancelled stage ran")
+	}
+}
+
+func TestStepCommandSkipsAfterFailedAnd(t *testing.T) {
+	dir := t.TempDir()
+	skipped := filepath.Join(dir, "skipped")
+	ran := filepath.Join(dir, "ran")
+	// Run the failing stage, continue past the failure, then run the last stage
+	pressKeys(t, "rcr")
+
+	command := fmt.Sprintf("false && touch %s; touch %s", skipped, ran)
+	outcome, _ := stepCommand(context.Background(), &fixAgent{}, &config.Config{}, stepShell, command)
+	if outcome.Status != history.StatusSucceeded {
+		t.Errorf("stepCommand() status = %q, want %q", outcome.Status, history.StatusSucceeded)
+	}
+	if _, err := os.Stat(skipped); err == nil {
+		t.Error("stage after a failed && ran")
+	}
+	if _, err := os.Stat(ran); err != nil {
+		t.Error("stage after ; didn't run")
+	}
+}
+
+func TestStepCommandFixesFailingStage(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+	ag := &fixAgent{fixed: "grep hi > " + out}
+	// Run the first stage, run the broken one, ask for a fix, run the fix
+	pressKeys(t, "rrfr")
+
+	command := "echo hi | no-such-command-please hi > " + out
+	outcome, _ := stepCommand(context.Background(), ag, &config.Config{}, stepShell, command)
+	if outcome.Status != history.StatusSucceeded {
+		t.Errorf("stepCommand() status = %q, want %q", outcome.Status, history.StatusSucceeded)
+	}
+	if want := "echo hi | grep hi > " + out; outcome.Command != want {
+		t.Errorf("stepCommand() command = %q, want %q", outcome.Command, want)
+	}
+	if len(ag.requests) != 1 || !strings.Contains(ag.requests[0], "exit code 127") {
+		t.Errorf("fix requests = %q, want one describing exit code 127", ag.requests)
+	}
+	if got := readOutput(t, out); got != "hi\n" {
+		t.Errorf("pipeline output = %q, want %q", got, "hi\n")
+	}
+}
+
+func TestStepCommandCapsPipedOutput(t *testing.T) {
+	saved := maxStageOutput
+	maxStageOutput = 1 << 20
+	defer func() { maxStageOutput = saved }()
+
+	out := filepath.Join(t.TempDir(), "out")
+	pressKeys(t, "rr")
+
+	outcome, _ := stepCommand(context.Background(), &fixAgent{}, &config.Config{}, stepShell, "yes | head -n 2 > "+out)
+	if outcome.Status != history.StatusSucceeded {
+		t.Errorf("stepCommand() status = %q, want %q", outcome.Status, history.StatusSucceeded)
+	}
+	if got := readOutput(t, out); got != "y\ny\n" {
+		t.Errorf("pipeline output = %q, want %q", got, "y\ny\n")
+	}
+}
+
+func TestStepCommandTimesOutPipedStage(t *testing.T) {
+	saved := pipedStageTimeout
+	pipedStageTimeout = 200 * time.Millisecond
+	defer func() { pipedStageTimeout = saved }()
+
+	pressKeys(t, "rq")
+
+	start := time.Now()
+	outcome, _ := stepCommand(context.Background(), &fixAgent{}, &config.Config{}, stepShell, "sleep 30 | cat")
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("stepCommand() took %s, want the piped stage stopped by the default timeout", elapsed)
+	}
+	if outcome.Status != history.StatusFailed {
+		t.Errorf("stepCommand() status = %q, want %q", outcome.Status, history.StatusFailed)
+	}
+}
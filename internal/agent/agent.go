@@ -13,4 +13,14 @@ type Agent interface {
 	// ExplainCommand takes a command and original request, returns a human-readable explanation
 	// request is used to match custom command documentation for context
 	ExplainCommand(ctx context.Context, command string, request string) (string, error)
+
+	// GenerateScript takes a task description and returns a multi-line bash script
+	GenerateScript(ctx context.Context, request string) (string, error)
+
+	// RefineScript takes a script and modification request and returns a refined script
+	RefineScript(ctx context.Context, originalScript, modificationRequest string) (string, error)
+
+	// GenerateCommandDoc takes a tool's name and reference documentation (e.g. its --help
+	// output) and returns a custom command doc for it
+	GenerateCommandDoc(ctx context.Context, command, reference string) (string, error)
 }
@@ -2,6 +2,7 @@ package agent
 
 import (
 	"context"
+	"fmt"
 	"testing"
 )
 
@@ -15,6 +16,9 @@ func TestAgentInterface(t *testing.T) {
 type MockAgent struct {
 	TranslateFn func(context.Context, string) (string, error)
 	RefineFn    func(context.Context, string, string) (string, error)
+	ExplainFn   func(context.Context, string, string) (string, error)
+	ScriptFn    func(context.Context, string) (string, error)
+	DocFn       func(context.Context, string, string) (string, error)
 }
 
 func (m *MockAgent) TranslateToCommand(ctx context.Context, request string) (string, error) {
@@ -31,6 +35,69 @@ func (m *MockAgent) RefineCommand(ctx context.Context, originalCommand, modifica
 	return "echo refined", nil
 }
 
+func (m *MockAgent) ExplainCommand(ctx context.Context, command string, request string) (string, error) {
+	if m.ExplainFn != nil {
+		return m.ExplainFn(ctx, command, request)
+	}
+	return "mock explanation", nil
+}
+
+func (m *MockAgent) GenerateScript(ctx context.Context, request string) (string, error) {
+	if m.ScriptFn != nil {
+		return m.ScriptFn(ctx, request)
+	}
+	return "#!/usr/bin/env bash\nset -euo pipefail\necho mock", nil
+}
+
+func (m *MockAgent) RefineScript(ctx context.Context, originalScript, modificationRequest string) (string, error) {
+	return originalScript + "\necho refined", nil
+}
+
+func (m *MockAgent) GenerateCommandDoc(ctx context.Context, command, reference string) (string, error) {
+	if m.DocFn != nil {
+		return m.DocFn(ctx, command, reference)
+	}
+	return "---\ncommand: " + command + "\n---\n", nil
+}
+
+func TestNormalizeDoc(t *testing.T) {
+	want := "---\ncommand: jq\n---\n\n# jq\n"
+	tests := []string{
+		"---\ncommand: jq\n---\n\n# jq",
+		"```markdown\n---\ncommand: jq\n---\n\n# jq\n```",
+		"Here is the doc:\n\n---\ncommand: jq\n---\n\n# jq\n",
+	}
+	for _, output := range tests {
+		if got := normalizeDoc(output); got != want {
+			t.Errorf("normalizeDoc(%q) = %q, want %q", output, got, want)
+		}
+	}
+}
+
+func TestNormalizeScript(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{"complete header", "#!/usr/bin/env bash\nset -euo pipefail\n\necho hi", "#!/usr/bin/env bash\nset -euo pipefail\n\necho hi\n"},
+		{"no header", "echo hi", "#!/usr/bin/env bash\nset -euo pipefail\necho hi\n"},
+		{"fenced", "```bash\n#!/usr/bin/env bash\nset -euo pipefail\necho hi\n```", "#!/usr/bin/env bash\nset -euo pipefail\necho hi\n"},
+		{"shebang only", "#!/bin/bash\n# Say hi\necho hi", "#!/bin/bash\nset -euo pipefail\n# Say hi\necho hi\n"},
+		{"set line only", "set -euo pipefail\necho hi", "#!/usr/bin/env bash\nset -euo pipefail\necho hi\n"},
+		{"partial set line", "#!/usr/bin/env bash\nset -e\necho hi", "#!/usr/bin/env bash\nset -euo pipefail\necho hi\n"},
+		{"long options", "#!/usr/bin/env bash\n# Strict\nset -o errexit -o nounset -o pipefail\necho hi", "#!/usr/bin/env bash\n# Strict\nset -o errexit -o nounset -o pipefail\necho hi\n"},
+		{"extra options", "#!/usr/bin/env bash\nset -euxo pipefail\necho hi", "#!/usr/bin/env bash\nset -euxo pipefail\necho hi\n"},
+		{"sh shebang", "#!/bin/sh\nset -eu\necho hi", "#!/usr/bin/env bash\nset -euo pipefail\necho hi\n"},
+		{"later set line", "#!/usr/bin/env bash\necho hi\nset +e\nfalse", "#!/usr/bin/env bash\nset -euo pipefail\necho hi\nset +e\nfalse\n"},
+	}
+	for _, tt := range tests {
+		if got := normalizeScript(tt.output); got != tt.want {
+			t.Errorf("%s: normalizeScript(%q) = %q, want %q", tt.name, tt.output, got, tt.want)
+		}
+	}
+}
+
 // Example of how to use MockAgent in tests
 func ExampleMockAgent() {
 	// Create a mock agent with custom behavior
@@ -45,5 +112,6 @@ func ExampleMockAgent() {
 
 	// Use the mock in your test
 	cmd, _ := mock.TranslateToCommand(context.Background(), "list files")
-	println(cmd) // Output: ls -la
+	fmt.Println(cmd)
+	// Output: ls -la
 }
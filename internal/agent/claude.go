@@ -10,11 +10,16 @@ import (
 	"runtime"
 	"sort"
 	"strings"
+
+	"github.com/iishyfishyy/please/internal/shell"
 )
 
 // ClaudeAgent implements the Agent interface using Claude CLI
 type ClaudeAgent struct {
 	customCmdGetter CustomDocGetter
+	maxDocs         int // Custom command docs to retrieve per request
+	tokenBudget     int // Token budget for the custom command context
+	shell           *shell.Shell
 	debug           bool
 }
 
@@ -27,6 +32,7 @@ type CustomCommandDoc struct {
 	Command  string
 	Content  string
 	Examples []CommandExample
+	Sections []string // Sections of Content that matched the request; when set, only these are used
 }
 
 // CommandExample represents a command example
@@ -37,7 +43,17 @@ type CommandExample struct {
 
 // NewClaudeAgent creates a new Claude agent
 func NewClaudeAgent() *ClaudeAgent {
-	return &ClaudeAgent{}
+	return &ClaudeAgent{shell: shell.Detect()}
+}
+
+// SetShell sets the shell that generated commands must target
+func (c *ClaudeAgent) SetShell(sh *shell.Shell) {
+	c.shell = sh
+}
+
+// Shell returns the shell that generated commands target
+func (c *ClaudeAgent) Shell() *shell.Shell {
+	return c.shell
 }
 
 // SetCustomDocGetter sets the custom command doc getter function
@@ -45,6 +61,13 @@ func (c *ClaudeAgent) SetCustomDocGetter(getter CustomDocGetter) {
 	c.customCmdGetter = getter
 }
 
+// SetContextLimits sets how many custom command docs are retrieved per
+// request and the token budget they're packed into (0 = default)
+func (c *ClaudeAgent) SetContextLimits(maxDocs, tokenBudget int) {
+	c.maxDocs = maxDocs
+	c.tokenBudget = tokenBudget
+}
+
 // SetDebug enables or disables debug logging
 func (c *ClaudeAgent) SetDebug(debug bool) {
 	c.debug = debug
@@ -124,10 +147,6 @@ Modified command:`,
 // request is the original user request (used to match custom commands)
 func (c *ClaudeAgent) ExplainCommand(ctx context.Context, command string, request string) (string, error) {
 	osInfo := runtime.GOOS
-	shell := os.Getenv("SHELL")
-	if shell == "" {
-		shell = "/bin/sh"
-	}
 
 	// Get relevant custom commands if available (for proprietary tools)
 	customDocs := c.getRelevantCustomDocs(request)
@@ -141,6 +160,7 @@ func (c *ClaudeAgent) ExplainCommand(ctx context.Context, command string, reques
 Environment:
 - Operating System: %s
 - Shell: %s
+- Shell dialect: %s
 %s
 Command to explain: %s
 
@@ -150,7 +170,7 @@ Provide a concise explanation that covers:
 3. Any important warnings or notes
 
 Keep it brief but informative. Use plain language that non-experts can understand.`,
-		osInfo, shell, customContext, command)
+		osInfo, c.shell, c.shell.Dialect(), customContext, command)
 
 	return c.callClaude(ctx, prompt)
 }
@@ -234,10 +254,6 @@ func (c *ClaudeAgent) summarizeDirectory() string {
 // buildSystemPrompt creates the system prompt for Claude with context
 func (c *ClaudeAgent) buildSystemPrompt() string {
 	osInfo := runtime.GOOS
-	shell := os.Getenv("SHELL")
-	if shell == "" {
-		shell = "/bin/sh"
-	}
 
 	// Gather runtime context
 	contextInfo := c.gatherContext()
@@ -251,12 +267,13 @@ func (c *ClaudeAgent) buildSystemPrompt() string {
 
 Environment:
 - Operating System: %s
-- Shell: %s%s
+- Shell: %s
+- Shell dialect: %s%s
 
 CRITICAL RULES:
 1. Output ONLY the raw command - no explanations, no markdown, no code blocks
 2. Generate safe, correct shell commands for the current environment
-3. Prefer portable commands when possible (use standard Unix/Linux utilities)
+3. Use syntax that is valid in the shell dialect above; prefer standard utilities available on this OS
 4. Make reasonable assumptions for ambiguous requests
 5. Consider the current directory context when generating commands
 
@@ -279,7 +296,7 @@ Command: find . -name "*.go" -exec wc -l {} + | tail -1
 Request: "show git log"
 Command: git log -10 --oneline
 
-Remember: Respond with ONLY the command itself, nothing else.`, osInfo, shell, contextSection)
+Remember: Respond with ONLY the command itself, nothing else.`, osInfo, c.shell, c.shell.Dialect(), contextSection)
 }
 
 // getRelevantCustomDocs retrieves relevant custom command docs
@@ -288,54 +305,20 @@ func (c *ClaudeAgent) getRelevantCustomDocs(request string) []CustomCommandDoc {
 		return nil
 	}
 
-	// Get up to 3 most relevant docs
-	return c.customCmdGetter(request, 3)
+	maxDocs := c.maxDocs
+	if maxDocs <= 0 {
+		maxDocs = DefaultMaxDocs
+	}
+	return c.customCmdGetter(request, maxDocs)
 }
 
 // buildCustomCommandContext builds the custom commands section of the prompt
 func (c *ClaudeAgent) buildCustomCommandContext(docs []CustomCommandDoc) string {
-	if len(docs) == 0 {
-		return ""
-	}
-
-	var context strings.Builder
-	context.WriteString("\n\nCUSTOM COMMANDS AVAILABLE:\n")
-	context.WriteString("The following custom/internal tools are available:\n\n")
-
-	for _, doc := range docs {
-		context.WriteString(fmt.Sprintf("## %s\n", doc.Command))
-
-		// Include examples (most useful for matching)
-		if len(doc.Examples) > 0 {
-			context.WriteString("Examples:\n")
-			for i, ex := range doc.Examples {
-				if i >= 5 { // Limit to 5 examples per command to save tokens
-					break
-				}
-				context.WriteString(fmt.Sprintf("  User: \"%s\"\n", ex.UserRequest))
-				context.WriteString(fmt.Sprintf("  Command: %s\n", ex.Command))
-			}
-		}
-
-		// Include common patterns (extract from content, limited)
-		patterns := extractCommonPatterns(doc.Content, 10)
-		if patterns != "" {
-			context.WriteString("\nCommon patterns:\n")
-			for _, line := range strings.Split(patterns, "\n") {
-				if strings.TrimSpace(line) != "" {
-					context.WriteString("  " + line + "\n")
-				}
-			}
-		}
-
-		context.WriteString("\n")
-	}
-
-	return context.String()
+	return packCustomContext(docs, c.tokenBudget, c.debug)
 }
 
-// extractCommonPatterns extracts command patterns from markdown content
-func extractCommonPatterns(content string, maxLines int) string {
+// extractCommonPatterns extracts command lines from code blocks in markdown content
+func extractCommonPatterns(content string) []string {
 	lines := strings.Split(content, "\n")
 	var patterns []string
 	inCodeBlock := false
@@ -355,19 +338,12 @@ func extractCommonPatterns(content string, maxLines int) string {
 		}
 
 		// Capture lines in code blocks (likely command examples)
-		if inCodeBlock && line != "" {
+		if inCodeBlock {
 			patterns = append(patterns, line)
-			if len(patterns) >= maxLines {
-				break
-			}
 		}
 	}
 
-	if len(patterns) == 0 {
-		return ""
-	}
-
-	return strings.Join(patterns, "\n")
+	return patterns
 }
 
 // callClaude calls the Claude CLI with the given prompt
@@ -0,0 +1,88 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// GenerateCommandDoc asks Claude to write a custom command doc for a tool
+// from its reference documentation (--help output or man page)
+func (c *ClaudeAgent) GenerateCommandDoc(ctx context.Context, command, reference string) (string, error) {
+	if c.debug {
+		fmt.Fprintf(os.Stderr, "[DEBUG] Agent: generating command doc for %s from %d chars of reference\n", command, len(reference))
+	}
+
+	prompt := fmt.Sprintf(`You are writing documentation that teaches a natural-language shell assistant how to use the command-line tool "%s". Below is the tool's own reference documentation.
+
+Reference:
+%s
+
+Write a markdown command doc in exactly this format:
+
+---
+command: %s
+aliases: [other names people call the tool, if any]
+keywords: [10-20 words users would say when they need this tool: tasks, objects, synonyms]
+categories: [1-3 broad areas, e.g. devops, database, networking]
+priority: medium
+placeholders:
+  name-of-value:
+    description: What the value is
+    source: a read-only shell command that lists possible values, one per line (omit if there is none)
+---
+
+# %s - One-line description
+
+## Overview
+Two or three sentences on what the tool does.
+
+## Common Patterns
+Sections with "##"/"###" headings for the main tasks, each with a bash code block of commands and short comments.
+
+## Examples
+
+User: "a natural request"
+Command: the exact command
+
+DOC RULES:
+1. Write 10-15 examples covering the most common tasks; every User line must be quoted and followed directly by its Command line
+2. Only use subcommands and flags that appear in the reference
+3. Write values the user must supply as <placeholder-name>, outside single quotes, and declare the important ones under placeholders
+4. Prefer read-only or safe commands in examples unless the tool exists to change things
+
+IMPORTANT: Respond with ONLY the markdown doc, starting with the --- line. No explanations before or after it. No markdown code fences around it.`,
+		command, reference, command, command)
+
+	output, err := c.callClaude(ctx, prompt)
+	if err != nil {
+		return "", err
+	}
+
+	return normalizeDoc(output), nil
+}
+
+// normalizeDoc strips a surrounding code fence and any preamble before the
+// frontmatter
+func normalizeDoc(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+
+	// Drop a surrounding ```markdown ... ``` block if the model added one anyway
+	if len(lines) > 0 && strings.HasPrefix(strings.TrimSpace(lines[0]), "```") {
+		lines = lines[1:]
+		if n := len(lines); n > 0 && strings.TrimSpace(lines[n-1]) == "```" {
+			lines = lines[:n-1]
+		}
+	}
+
+	// Skip anything said before the frontmatter
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "---" {
+			lines = lines[i:]
+			break
+		}
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n")) + "\n"
+}
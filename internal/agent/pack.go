@@ -0,0 +1,228 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Defaults for the custom command context when the config doesn't set them
+const (
+	DefaultMaxDocs     = 3
+	DefaultTokenBudget = 1500
+)
+
+// charsPerToken is the rough ratio used to estimate tokens from text length
+const charsPerToken = 4
+
+const customContextHeader = "\n\nCUSTOM COMMANDS AVAILABLE:\nThe following custom/internal tools are available:\n\n"
+
+// EstimateTokens approximates how many tokens text uses in a prompt
+func EstimateTokens(text string) int {
+	return (len(text) + charsPerToken - 1) / charsPerToken
+}
+
+// packedDoc is the material chosen from one doc
+type packedDoc struct {
+	doc      CustomCommandDoc
+	examples []CommandExample
+	sections []string
+	patterns []string
+}
+
+// contextPacker greedily fills a token budget with custom command material
+type contextPacker struct {
+	docs      []*packedDoc
+	remaining int // Characters left in the budget
+}
+
+// add reserves room for text plus any labels it needs, reporting whether it fit
+func (p *contextPacker) add(d *packedDoc, label, text string) bool {
+	cost := len(text)
+	if d.empty() {
+		cost += len(docOverhead(d.doc))
+	}
+	cost += len(label)
+
+	if cost > p.remaining {
+		return false
+	}
+	p.remaining -= cost
+	return true
+}
+
+// empty reports whether nothing has been packed for the doc yet
+func (d *packedDoc) empty() bool {
+	return len(d.examples) == 0 && len(d.sections) == 0 && len(d.patterns) == 0
+}
+
+// packCustomContext builds the custom commands section of the prompt within
+// tokenBudget. Material is added greedily in order of value: examples first
+// (round robin across docs, so every doc gets its best examples before any
+// gets its tenth), then the sections that matched the request, then code
+// lines from the docs that have no matched sections. Docs are assumed to be
+// ordered by relevance.
+func packCustomContext(docs []CustomCommandDoc, tokenBudget int, debug bool) string {
+	if len(docs) == 0 {
+		return ""
+	}
+	if tokenBudget <= 0 {
+		tokenBudget = DefaultTokenBudget
+	}
+
+	p := &contextPacker{remaining: tokenBudget*charsPerToken - len(customContextHeader)}
+	for _, doc := range docs {
+		p.docs = append(p.docs, &packedDoc{doc: doc})
+	}
+
+	// 1. Examples, round robin
+	for i := 0; ; i++ {
+		more := false
+		for _, d := range p.docs {
+			if i >= len(d.doc.Examples) {
+				continue
+			}
+			more = true
+			label := ""
+			if len(d.examples) == 0 {
+				label = examplesLabel
+			}
+			if len(d.examples) == i && p.add(d, label, formatExample(d.doc.Examples[i])) {
+				d.examples = append(d.examples, d.doc.Examples[i])
+			}
+		}
+		if !more {
+			break
+		}
+	}
+
+	// 2. Matched sections, in relevance order
+	for _, d := range p.docs {
+		for _, section := range d.doc.Sections {
+			label := ""
+			if len(d.sections) == 0 {
+				label = sectionsLabel
+			}
+			if p.add(d, label, formatSection(section)) {
+				d.sections = append(d.sections, section)
+			}
+		}
+	}
+
+	// 3. Code lines from docs without matched sections, round robin
+	patterns := make([][]string, len(p.docs))
+	for i, d := range p.docs {
+		if len(d.doc.Sections) == 0 {
+			patterns[i] = extractCommonPatterns(d.doc.Content)
+		}
+	}
+	for i := 0; ; i++ {
+		more := false
+		for j, d := range p.docs {
+			if i >= len(patterns[j]) {
+				continue
+			}
+			more = true
+			label := ""
+			if len(d.patterns) == 0 {
+				label = patternsLabel
+			}
+			if len(d.patterns) == i && p.add(d, label, formatPattern(patterns[j][i])) {
+				d.patterns = append(d.patterns, patterns[j][i])
+			}
+		}
+		if !more {
+			break
+		}
+	}
+
+	context := p.render()
+
+	if debug {
+		var examples, sections, lines, used int
+		for _, d := range p.docs {
+			examples += len(d.examples)
+			sections += len(d.sections)
+			lines += len(d.patterns)
+			if !d.empty() {
+				used++
+			}
+		}
+		fmt.Fprintf(os.Stderr, "[DEBUG] Agent: packed custom context ~%d/%d tokens (%d docs, %d examples, %d sections, %d pattern lines)\n",
+			EstimateTokens(context), tokenBudget, used, examples, sections, lines)
+	}
+
+	return context
+}
+
+// Labels and formatting for packed material
+const (
+	examplesLabel = "Examples:\n"
+	sectionsLabel = "\nRelevant documentation:\n"
+	patternsLabel = "\nCommon patterns:\n"
+)
+
+// docOverhead is the text each packed doc adds around its material
+func docOverhead(doc CustomCommandDoc) string {
+	return fmt.Sprintf("## %s\n", doc.Command) + "\n"
+}
+
+func formatExample(ex CommandExample) string {
+	return fmt.Sprintf("  User: \"%s\"\n  Command: %s\n", ex.UserRequest, ex.Command)
+}
+
+func formatSection(section string) string {
+	return strings.TrimSpace(section) + "\n\n"
+}
+
+func formatPattern(line string) string {
+	return "  " + line + "\n"
+}
+
+// render writes the packed material in doc order, skipping empty docs
+func (p *contextPacker) render() string {
+	var context strings.Builder
+	context.WriteString(customContextHeader)
+
+	packed := 0
+	for _, d := range p.docs {
+		if d.empty() {
+			continue
+		}
+		packed++
+
+		context.WriteString(fmt.Sprintf("## %s\n", d.doc.Command))
+
+		// Include examples (most useful for matching)
+		if len(d.examples) > 0 {
+			context.WriteString(examplesLabel)
+			for _, ex := range d.examples {
+				context.WriteString(formatExample(ex))
+			}
+		}
+
+		// Include the sections that matched the request
+		if len(d.sections) > 0 {
+			context.WriteString(sectionsLabel)
+			for _, section := range d.sections {
+				context.WriteString(formatSection(section))
+			}
+		}
+
+		// Include common patterns
+		if len(d.patterns) > 0 {
+			context.WriteString(patternsLabel)
+			for _, line := range d.patterns {
+				context.WriteString(formatPattern(line))
+			}
+		}
+
+		context.WriteString("\n")
+	}
+
+	if packed == 0 {
+		return ""
+	}
+
+	return context.String()
+}
@@ -0,0 +1,82 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func manyExamples(command string, n int) []CommandExample {
+	examples := make([]CommandExample, n)
+	for i := range examples {
+		examples[i] = CommandExample{
+			UserRequest: fmt.Sprintf("%s request number %d", command, i+1),
+			Command:     fmt.Sprintf("%s --example %d", command, i+1),
+		}
+	}
+	return examples
+}
+
+func TestPackCustomContextBudget(t *testing.T) {
+	docs := []CustomCommandDoc{
+		{Command: "deployctl", Examples: manyExamples("deployctl", 40), Content: "```\ndeployctl status\n```"},
+		{Command: "dbctl", Examples: manyExamples("dbctl", 40)},
+	}
+
+	for _, budget := range []int{100, 300, 1500} {
+		context := packCustomContext(docs, budget, false)
+		if got := EstimateTokens(context); got > budget {
+			t.Errorf("budget %d: packed %d tokens", budget, got)
+		}
+	}
+
+	// A small budget is shared between docs before either gets more examples
+	context := packCustomContext(docs, 150, false)
+	for _, want := range []string{"deployctl request number 1\"", "dbctl request number 1\""} {
+		if !strings.Contains(context, want) {
+			t.Errorf("packed context missing %q:\n%s", want, context)
+		}
+	}
+	if strings.Contains(context, "deployctl request number 20\"") {
+		t.Errorf("first doc took the whole budget:\n%s", context)
+	}
+	if strings.Contains(context, "Common patterns") {
+		t.Errorf("patterns packed before examples were exhausted:\n%s", context)
+	}
+}
+
+func TestPackCustomContextOrder(t *testing.T) {
+	docs := []CustomCommandDoc{
+		{
+			Command:  "deployctl",
+			Examples: manyExamples("deployctl", 2),
+			Content:  "# Rollback\n```\ndeployctl rollback\n```",
+			Sections: []string{"# Rollback\nUse deployctl rollback to undo."},
+		},
+		{
+			Command: "dbctl",
+			Content: "```\ndbctl backup\ndbctl restore\n```",
+		},
+	}
+
+	context := packCustomContext(docs, 1500, false)
+
+	for _, want := range []string{
+		"## deployctl\nExamples:\n",
+		"Relevant documentation:\n# Rollback\nUse deployctl rollback to undo.",
+		"## dbctl\n\nCommon patterns:\n  dbctl backup\n  dbctl restore\n",
+	} {
+		if !strings.Contains(context, want) {
+			t.Errorf("packed context missing %q:\n%s", want, context)
+		}
+	}
+
+	// Docs with matched sections don't fall back to the whole doc's patterns
+	if strings.Contains(context, "  deployctl rollback\n") {
+		t.Errorf("patterns included for a doc with matched sections:\n%s", context)
+	}
+
+	if packCustomContext(nil, 1500, false) != "" {
+		t.Error("empty docs should produce no context")
+	}
+}
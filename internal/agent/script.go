@@ -0,0 +1,174 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// ScriptShebang is the interpreter line every generated script starts with
+const ScriptShebang = "#!/usr/bin/env bash"
+
+// GenerateScript asks Claude for a multi-line bash script that performs the task
+func (c *ClaudeAgent) GenerateScript(ctx context.Context, request string) (string, error) {
+	customDocs := c.getRelevantCustomDocs(request)
+	if c.debug && len(customDocs) > 0 {
+		fmt.Fprintf(os.Stderr, "[DEBUG] Agent: retrieved %d custom command docs for script\n", len(customDocs))
+	}
+	customContext := c.buildCustomCommandContext(customDocs)
+
+	prompt := fmt.Sprintf(`%s
+%s
+Write a bash script for this task: "%s"
+
+SCRIPT RULES:
+1. The first line must be: %s
+2. The second line must be: %s
+3. Put each step on its own line, with a short comment above each logical step
+4. Quote all variable expansions and define configurable values as variables at the top
+5. Print progress messages to stderr so the user can follow along
+
+IMPORTANT: Respond with ONLY the script itself. No explanations before or after it. No markdown code fences.`,
+		c.buildScriptContext(), customContext, request, ScriptShebang, ScriptStrictMode)
+
+	output, err := c.callClaude(ctx, prompt)
+	if err != nil {
+		return "", err
+	}
+
+	return normalizeScript(output), nil
+}
+
+// RefineScript modifies an existing script based on a modification request
+func (c *ClaudeAgent) RefineScript(ctx context.Context, originalScript, modificationRequest string) (string, error) {
+	if c.debug {
+		fmt.Fprintf(os.Stderr, "[DEBUG] Agent: refining script (%d lines) with modification %q\n",
+			strings.Count(originalScript, "\n")+1, modificationRequest)
+	}
+
+	prompt := fmt.Sprintf(`%s
+
+Original script:
+%s
+
+Modification request: %s
+
+Output the complete modified script, keeping "%s" and "%s" as the first two lines.
+
+IMPORTANT: Respond with ONLY the script itself. No explanations before or after it. No markdown code fences.`,
+		c.buildScriptContext(), originalScript, modificationRequest, ScriptShebang, ScriptStrictMode)
+
+	output, err := c.callClaude(ctx, prompt)
+	if err != nil {
+		return "", err
+	}
+
+	return normalizeScript(output), nil
+}
+
+// buildScriptContext describes the environment for script generation
+func (c *ClaudeAgent) buildScriptContext() string {
+	var contextSection string
+	if contextInfo := c.gatherContext(); contextInfo != "" {
+		contextSection = "\nContext:\n" + contextInfo
+	}
+
+	return fmt.Sprintf(`You are a command-line expert that writes robust, readable bash scripts.
+
+Environment:
+- Operating System: %s
+- Script interpreter: bash%s
+
+SAFETY GUIDELINES:
+- Avoid destructive commands without clear intent (rm -rf, dd, mkfs, etc.)
+- Prefer dry-run friendly constructs and fail early on missing inputs
+- Use common utilities that are widely available on this OS`, runtime.GOOS, contextSection)
+}
+
+// ScriptStrictMode is the line after the shebang that makes a generated
+// script stop at the first failing command, unset variable or pipe stage
+const ScriptStrictMode = "set -euo pipefail"
+
+// normalizeScript strips markdown fences and enforces the script header: a
+// bash shebang followed by strict mode. A shebang for another interpreter is
+// replaced, since scripts are always run with bash, and a set line that
+// doesn't turn on all of strict mode is replaced with one that does.
+func normalizeScript(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+
+	// Drop a surrounding ```bash ... ``` block if the model added one anyway
+	if len(lines) > 0 && strings.HasPrefix(strings.TrimSpace(lines[0]), "```") {
+		lines = lines[1:]
+		if n := len(lines); n > 0 && strings.TrimSpace(lines[n-1]) == "```" {
+			lines = lines[:n-1]
+		}
+	}
+
+	body := strings.Split(strings.TrimSpace(strings.Join(lines, "\n")), "\n")
+	shebang := ScriptShebang
+	if strings.HasPrefix(body[0], "#!") {
+		if strings.Contains(body[0], "bash") {
+			shebang = body[0]
+		}
+		body = body[1:]
+	}
+
+	// The first command should be the set line; comments and blank lines
+	// before it are kept in place
+	for i, line := range body {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if isSetLine(trimmed) {
+			if !hasStrictMode(trimmed) {
+				body = append(body[:i:i], body[i+1:]...)
+			} else {
+				return strings.Join(append([]string{shebang}, body...), "\n") + "\n"
+			}
+		}
+		break
+	}
+
+	script := shebang + "\n" + ScriptStrictMode
+	if rest := strings.TrimSpace(strings.Join(body, "\n")); rest != "" {
+		script += "\n" + rest
+	}
+	return script + "\n"
+}
+
+// isSetLine reports whether a line runs the set builtin with options
+func isSetLine(line string) bool {
+	fields := strings.Fields(line)
+	return len(fields) > 1 && fields[0] == "set" && strings.HasPrefix(fields[1], "-")
+}
+
+// hasStrictMode reports whether a set line turns on errexit, nounset and
+// pipefail, in any spelling: set -euo pipefail, set -e -u -o pipefail or
+// set -o errexit -o nounset -o pipefail
+func hasStrictMode(line string) bool {
+	on := make(map[string]bool)
+	fields := strings.Fields(line)
+	for i := 1; i < len(fields); i++ {
+		field := fields[i]
+		if !strings.HasPrefix(field, "-") || strings.HasPrefix(field, "--") {
+			continue
+		}
+		for _, flag := range field[1:] {
+			switch flag {
+			case 'e':
+				on["errexit"] = true
+			case 'u':
+				on["nounset"] = true
+			case 'o':
+				if i+1 < len(fields) {
+					i++
+					on[fields[i]] = true
+				}
+			}
+		}
+	}
+	return on["errexit"] && on["nounset"] && on["pipefail"]
+}
@@ -29,12 +29,25 @@ const (
 	ProviderNone   EmbeddingProvider = "none"
 	ProviderOllama EmbeddingProvider = "ollama"
 	ProviderOpenAI EmbeddingProvider = "openai"
+
+	ProviderTEI      EmbeddingProvider = "tei"      // Hugging Face Text Embeddings Inference
+	ProviderLlamaCpp EmbeddingProvider = "llamacpp" // llama.cpp server
+	ProviderCohere   EmbeddingProvider = "cohere"
+	ProviderLocal    EmbeddingProvider = "local" // Built-in, no external service
 )
 
 // Config represents the application configuration
 type Config struct {
 	Agent          AgentType       `json:"agent"`
 	CustomCommands *CustomCommands `json:"custom_commands,omitempty"`
+	Execution      *Execution      `json:"execution,omitempty"`
+}
+
+// Execution controls how generated commands are run
+type Execution struct {
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"` // Kill commands after this many seconds (0 = no limit)
+	MaxCPUSeconds  int `json:"max_cpu_seconds,omitempty"` // CPU time limit (0 = no limit)
+	MaxMemoryMB    int `json:"max_memory_mb,omitempty"`   // Memory limit (0 = no limit)
 }
 
 // CustomCommands configuration
@@ -44,21 +57,28 @@ type CustomCommands struct {
 	Matching MatchingConfig    `json:"matching,omitempty"`
 	Ollama   OllamaConfig      `json:"ollama,omitempty"`
 	OpenAI   OpenAIConfig      `json:"openai,omitempty"`
+	TEI      ServerConfig      `json:"tei,omitempty"`
+	LlamaCpp ServerConfig      `json:"llamacpp,omitempty"`
+	Cohere   CohereConfig      `json:"cohere,omitempty"`
+
+	Quantization string `json:"quantization,omitempty"` // Cached vector encoding: "none" (default), "int8" or "binary"
 }
 
 // MatchingConfig controls matching behavior
 type MatchingConfig struct {
-	Strategy         string `json:"strategy,omitempty"`          // "keyword", "semantic", "hybrid"
-	KeywordThreshold int    `json:"keyword_threshold,omitempty"` // Score threshold for keyword matches
-	MaxDocsPerReq    int    `json:"max_docs_per_request,omitempty"`
-	TokenBudget      int    `json:"token_budget,omitempty"`
+	Strategy         string  `json:"strategy,omitempty"`          // "keyword", "semantic", "hybrid"
+	KeywordThreshold int     `json:"keyword_threshold,omitempty"` // Score threshold for keyword matches
+	KeywordWeight    float64 `json:"keyword_weight,omitempty"`    // Share of the hybrid score from keywords (0-1)
+	MinScore         float64 `json:"min_score,omitempty"`         // Drop docs whose semantic/hybrid score is below this (0-1)
+	MaxDocsPerReq    int     `json:"max_docs_per_request,omitempty"`
+	TokenBudget      int     `json:"token_budget,omitempty"`
 }
 
 // OllamaConfig for local embeddings
 type OllamaConfig struct {
 	URL        string `json:"url,omitempty"`
 	Model      string `json:"model,omitempty"`
-	Dimensions int    `json:"dimensions,omitempty"`
+	Dimensions int    `json:"dimensions,omitempty"` // Informational; the size is detected from responses
 }
 
 // OpenAIConfig for OpenAI embeddings
@@ -67,7 +87,50 @@ type OpenAIConfig struct {
 	APIKeyEnv  string `json:"api_key_env,omitempty"`
 	UseEnvVar  bool   `json:"use_env_var,omitempty"`
 	Model      string `json:"model,omitempty"`
-	Dimensions int    `json:"dimensions,omitempty"`
+	Dimensions int    `json:"dimensions,omitempty"` // Requested size for models that support shortening
+	BaseURL    string `json:"base_url,omitempty"`   // OpenAI-compatible server (Azure, LocalAI, TEI); default api.openai.com
+}
+
+// ResolveAPIKey returns the API key from the configured environment variable
+// (OPENAI_API_KEY by default), falling back to the key stored in the config.
+// A stored key takes precedence when the config opts out of the env var.
+func (c OpenAIConfig) ResolveAPIKey() string {
+	return resolveAPIKey(c.APIKey, c.APIKeyEnv, "OPENAI_API_KEY", c.UseEnvVar)
+}
+
+// ServerConfig for self-hosted embedding servers that host a single model
+type ServerConfig struct {
+	URL string `json:"url,omitempty"`
+}
+
+// CohereConfig for Cohere embeddings
+type CohereConfig struct {
+	APIKey    string `json:"api_key,omitempty"`
+	APIKeyEnv string `json:"api_key_env,omitempty"`
+	UseEnvVar bool   `json:"use_env_var,omitempty"`
+	Model     string `json:"model,omitempty"`
+	BaseURL   string `json:"base_url,omitempty"` // Cohere-compatible server; default api.cohere.com
+}
+
+// ResolveAPIKey returns the API key from the configured environment variable
+// (COHERE_API_KEY by default), falling back to the key stored in the config
+func (c CohereConfig) ResolveAPIKey() string {
+	return resolveAPIKey(c.APIKey, c.APIKeyEnv, "COHERE_API_KEY", c.UseEnvVar)
+}
+
+func resolveAPIKey(stored, envVar, defaultEnvVar string, useEnvVar bool) string {
+	if !useEnvVar && stored != "" {
+		return stored
+	}
+
+	if envVar == "" {
+		envVar = defaultEnvVar
+	}
+	if key := os.Getenv(envVar); key != "" {
+		return key
+	}
+
+	return stored
 }
 
 // GetConfigDir returns the path to the config directory
@@ -168,6 +231,8 @@ func NewDefaultCustomCommands(provider EmbeddingProvider) *CustomCommands {
 		Matching: MatchingConfig{
 			Strategy:         "hybrid",
 			KeywordThreshold: 50,
+			KeywordWeight:    0.5,
+			MinScore:         0.3,
 			MaxDocsPerReq:    3,
 			TokenBudget:      1500,
 		},
@@ -176,9 +241,8 @@ func NewDefaultCustomCommands(provider EmbeddingProvider) *CustomCommands {
 	switch provider {
 	case ProviderOllama:
 		cc.Ollama = OllamaConfig{
-			URL:        "http://localhost:11434",
-			Model:      "nomic-embed-text",
-			Dimensions: 384,
+			URL:   "http://localhost:11434",
+			Model: "nomic-embed-text",
 		}
 	case ProviderOpenAI:
 		cc.OpenAI = OpenAIConfig{
@@ -187,6 +251,19 @@ func NewDefaultCustomCommands(provider EmbeddingProvider) *CustomCommands {
 			Model:      "text-embedding-3-small",
 			Dimensions: 1536,
 		}
+	case ProviderTEI:
+		cc.TEI = ServerConfig{URL: "http://localhost:8080"}
+	case ProviderLlamaCpp:
+		cc.LlamaCpp = ServerConfig{URL: "http://localhost:8080"}
+	case ProviderCohere:
+		cc.Cohere = CohereConfig{
+			APIKeyEnv: "COHERE_API_KEY",
+			UseEnvVar: true,
+			Model:     "embed-english-v3.0",
+		}
+	case ProviderLocal:
+		// Hashed n-gram similarities run lower than a trained model's
+		cc.Matching.MinScore = 0.15
 	case ProviderNone:
 		cc.Matching.Strategy = "keyword"
 	}
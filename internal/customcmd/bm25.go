@@ -0,0 +1,359 @@
+package customcmd
+
+import (
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BM25 parameters: k1 controls term frequency saturation, b length normalisation
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// lexicalIndexVersion is bumped whenever the on-disk format or the analyzer changes
+const lexicalIndexVersion = 2
+
+// Indexed fields, in the order stored in postings
+const (
+	fieldName = iota
+	fieldAliases
+	fieldKeywords
+	fieldExamples
+	fieldBody
+	numFields
+)
+
+// fieldBoosts weight a term occurrence by where it appears
+var fieldBoosts = [numFields]float64{
+	fieldName:     5,
+	fieldAliases:  4,
+	fieldKeywords: 3,
+	fieldExamples: 2,
+	fieldBody:     1,
+}
+
+// stopWords are dropped from both documents and queries
+var stopWords = map[string]bool{
+	"a": true, "about": true, "after": true, "all": true, "also": true, "an": true,
+	"and": true, "any": true, "are": true, "as": true, "at": true, "be": true,
+	"been": true, "but": true, "by": true, "can": true, "could": true, "do": true,
+	"does": true, "for": true, "from": true, "had": true, "has": true,
+	"have": true, "how": true, "i": true, "if": true, "in": true, "into": true,
+	"is": true, "it": true, "its": true, "me": true, "my": true, "of": true,
+	"on": true, "or": true, "our": true, "please": true, "should": true, "so": true,
+	"some": true, "that": true, "the": true, "their": true, "them": true, "then": true,
+	"there": true, "these": true, "this": true, "those": true, "to": true, "us": true,
+	"want": true, "was": true, "we": true, "were": true, "what": true, "when": true,
+	"which": true, "will": true, "with": true, "would": true, "you": true, "your": true,
+}
+
+// LexicalIndex is a BM25 inverted index over command docs. It is persisted
+// next to the embeddings cache so it's only rebuilt when docs change.
+type LexicalIndex struct {
+	Version  int
+	Docs     []IndexedDoc
+	Postings map[string][]Posting // Stemmed term -> docs containing it
+	AvgLen   [numFields]float64   // Average field length in terms
+}
+
+// IndexedDoc records what was indexed for one doc, for cache validation
+type IndexedDoc struct {
+	ID       string
+	Filename string
+	Hash     string // contentHash of the doc when it was indexed
+	Length   [numFields]int
+	Priority string
+}
+
+// Posting is one doc's term frequencies for a term, per field
+type Posting struct {
+	Doc int // Index into LexicalIndex.Docs
+	TF  [numFields]uint16
+}
+
+// GetLexicalIndexPath returns where the lexical index over the docs of dirs
+// is persisted. The index covers the merged docs, so each set of command
+// directories (one per project, plus the shared ones) gets its own file and
+// switching projects doesn't rebuild it.
+func GetLexicalIndexPath(dirs []CommandDir) (string, error) {
+	cachePath, err := GetEmbeddingsCachePath()
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	for _, dir := range dirs {
+		fmt.Fprintf(h, "%s\x00%s\n", dir.Source, resolvePath(dir.Path))
+	}
+	name := hex.EncodeToString(h.Sum(nil)[:8]) + ".idx"
+	return filepath.Join(filepath.Dir(cachePath), "cache", "lexical", name), nil
+}
+
+// BuildLexicalIndex analyzes and indexes all docs
+func BuildLexicalIndex(docs []CommandDoc) *LexicalIndex {
+	idx := &LexicalIndex{
+		Version:  lexicalIndexVersion,
+		Docs:     make([]IndexedDoc, len(docs)),
+		Postings: make(map[string][]Posting),
+	}
+
+	var totalLen [numFields]int
+	for i, doc := range docs {
+		idx.Docs[i] = IndexedDoc{
+			ID:       docID(doc),
+			Filename: doc.Filename,
+			Hash:     contentHash(doc),
+			Priority: strings.ToLower(doc.Priority),
+		}
+
+		tf := make(map[string]*[numFields]uint16)
+		for field, text := range docFields(doc) {
+			terms := analyze(text)
+			idx.Docs[i].Length[field] = len(terms)
+			totalLen[field] += len(terms)
+			for _, term := range terms {
+				counts, ok := tf[term]
+				if !ok {
+					counts = &[numFields]uint16{}
+					tf[term] = counts
+				}
+				if counts[field] < math.MaxUint16 {
+					counts[field]++
+				}
+			}
+		}
+
+		for term, counts := range tf {
+			idx.Postings[term] = append(idx.Postings[term], Posting{Doc: i, TF: *counts})
+		}
+	}
+
+	if len(docs) > 0 {
+		for field := range totalLen {
+			idx.AvgLen[field] = float64(totalLen[field]) / float64(len(docs))
+		}
+	}
+
+	return idx
+}
+
+// LoadLexicalIndex reads a persisted index
+func LoadLexicalIndex(path string) (*LexicalIndex, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var idx LexicalIndex
+	if err := gob.NewDecoder(f).Decode(&idx); err != nil {
+		return nil, fmt.Errorf("failed to decode lexical index: %w", err)
+	}
+	if idx.Version != lexicalIndexVersion {
+		return nil, fmt.Errorf("lexical index version %d, want %d", idx.Version, lexicalIndexVersion)
+	}
+
+	return &idx, nil
+}
+
+// Save persists the index, replacing any existing file atomically
+func (idx *LexicalIndex) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create index directory: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to create lexical index: %w", err)
+	}
+	if err := gob.NewEncoder(f).Encode(idx); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("failed to encode lexical index: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// Matches reports whether the index was built from exactly these docs, in
+// this order and with this content. Content is compared rather than file
+// times, which a checkout or a copy can leave unchanged across an edit.
+func (idx *LexicalIndex) Matches(docs []CommandDoc) bool {
+	if len(idx.Docs) != len(docs) {
+		return false
+	}
+	for i, doc := range docs {
+		indexed := idx.Docs[i]
+		if indexed.ID != docID(doc) || indexed.Filename != doc.Filename ||
+			indexed.Hash != contentHash(doc) {
+			return false
+		}
+	}
+	return true
+}
+
+// Search returns BM25F scores keyed by doc position. Scores are scaled to a
+// percentage of the best score any doc could get for the query's known
+// terms, then adjusted by the doc's priority.
+func (idx *LexicalIndex) Search(query string) map[int]float64 {
+	scores := make(map[int]float64)
+	n := float64(len(idx.Docs))
+	if n == 0 {
+		return scores
+	}
+
+	seen := make(map[string]bool)
+	maxScore := 0.0
+	for _, term := range analyze(query) {
+		if seen[term] {
+			continue
+		}
+		seen[term] = true
+
+		postings := idx.Postings[term]
+		if len(postings) == 0 {
+			continue // Unknown terms can't be matched, so don't count them
+		}
+
+		df := float64(len(postings))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		maxScore += idf * (bm25K1 + 1)
+
+		for _, p := range postings {
+			doc := idx.Docs[p.Doc]
+			weighted := 0.0
+			for field := 0; field < numFields; field++ {
+				if p.TF[field] == 0 {
+					continue
+				}
+				norm := 1.0
+				if idx.AvgLen[field] > 0 {
+					norm = 1 - bm25B + bm25B*float64(doc.Length[field])/idx.AvgLen[field]
+				}
+				weighted += fieldBoosts[field] * float64(p.TF[field]) / norm
+			}
+			scores[p.Doc] += idf * weighted * (bm25K1 + 1) / (weighted + bm25K1)
+		}
+	}
+
+	if maxScore == 0 {
+		return scores
+	}
+
+	for i, score := range scores {
+		score = 100 * score / maxScore
+
+		// Priority boost
+		switch idx.Docs[i].Priority {
+		case "high":
+			score *= 1.3
+		case "medium":
+			score *= 1.1
+		}
+		scores[i] = score
+	}
+
+	return scores
+}
+
+// docFields returns the text of each indexed field
+func docFields(doc CommandDoc) [numFields]string {
+	var fields [numFields]string
+	fields[fieldName] = doc.Command
+	fields[fieldAliases] = strings.Join(doc.Aliases, " ")
+	fields[fieldKeywords] = strings.Join(append(append([]string{}, doc.Keywords...), doc.Categories...), " ")
+
+	var examples strings.Builder
+	for _, ex := range doc.Examples {
+		examples.WriteString(ex.UserRequest)
+		examples.WriteString(" ")
+	}
+	fields[fieldExamples] = examples.String()
+	fields[fieldBody] = doc.Content
+
+	return fields
+}
+
+// analyze turns text into index terms: lowercased words with stopwords
+// removed and suffixes stemmed. Hyphenated and underscored words are kept
+// whole and also split into their parts ("docker-compose" -> "docker-compose",
+// "docker", "compose").
+func analyze(text string) []string {
+	var terms []string
+	for _, word := range tokenize(strings.ToLower(text)) {
+		if stopWords[word] {
+			continue
+		}
+		terms = append(terms, stem(word))
+
+		if strings.ContainsAny(word, "-_") {
+			for _, part := range strings.FieldsFunc(word, func(r rune) bool { return r == '-' || r == '_' }) {
+				if len(part) > 1 && !stopWords[part] {
+					terms = append(terms, stem(part))
+				}
+			}
+		}
+	}
+	return terms
+}
+
+// stem strips common English inflections so "pods", "listing" and "listed"
+// match "pod" and "list". It is deliberately conservative: short words and
+// command-like tokens containing digits are left alone.
+func stem(word string) string {
+	if len(word) <= 3 || strings.ContainsAny(word, "0123456789-_") {
+		return word
+	}
+
+	switch {
+	case strings.HasSuffix(word, "sses"):
+		return word[:len(word)-2]
+	case strings.HasSuffix(word, "ies") && len(word) > 4:
+		return word[:len(word)-3] + "y"
+	case strings.HasSuffix(word, "ss"), strings.HasSuffix(word, "us"), strings.HasSuffix(word, "is"):
+		// "class", "status", "analysis" aren't plurals
+	case strings.HasSuffix(word, "s"):
+		word = word[:len(word)-1]
+	}
+
+	for _, suffix := range []string{"ing", "ed"} {
+		if strings.HasSuffix(word, suffix) && len(word)-len(suffix) >= 3 {
+			base := word[:len(word)-len(suffix)]
+			if !hasVowel(base) {
+				continue
+			}
+			// "running" -> "run", "stopped" -> "stop"
+			if n := len(base); n >= 2 && base[n-1] == base[n-2] && !strings.ContainsRune("lsz", rune(base[n-1])) {
+				base = base[:n-1]
+			}
+			return trimE(base)
+		}
+	}
+
+	return trimE(word)
+}
+
+// trimE drops a silent trailing e so "create" and "created" share a stem
+func trimE(word string) string {
+	if len(word) > 4 && strings.HasSuffix(word, "e") {
+		return word[:len(word)-1]
+	}
+	return word
+}
+
+// hasVowel reports whether s contains a vowel, so "sing" isn't stemmed to "s"
+func hasVowel(s string) bool {
+	return strings.ContainsAny(s, "aeiouy")
+}
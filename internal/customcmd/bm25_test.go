@@ -0,0 +1,152 @@
+package customcmd
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStem(t *testing.T) {
+	tests := map[string]string{
+		"pods":        "pod",
+		"listing":     "list",
+		"listed":      "list",
+		"create":      "creat",
+		"created":     "creat",
+		"running":     "run",
+		"stopped":     "stop",
+		"pulled":      "pull",
+		"queries":     "query",
+		"status":      "status",
+		"class":       "class",
+		"k8s":         "k8s",
+		"ls":          "ls",
+		"sing":        "sing",
+		"deployments": "deployment",
+	}
+
+	for word, want := range tests {
+		if got := stem(word); got != want {
+			t.Errorf("stem(%q) = %q, want %q", word, got, want)
+		}
+	}
+}
+
+func testDocs() []CommandDoc {
+	mtime := time.Unix(1700000000, 0)
+	return []CommandDoc{
+		{
+			Filename:  "kubectl.md",
+			Command:   "kubectl",
+			Aliases:   []string{"k8s"},
+			Keywords:  []string{"pods", "deployments", "logs"},
+			Examples:  []Example{{UserRequest: "show logs for a pod"}},
+			Content:   "Manage a Kubernetes cluster.",
+			UpdatedAt: mtime,
+		},
+		{
+			Filename:  "psql.md",
+			Command:   "psql",
+			Keywords:  []string{"database", "postgres"},
+			Examples:  []Example{{UserRequest: "list tables in the database"}},
+			Content:   "Connect to a Postgres database and run queries.",
+			UpdatedAt: mtime,
+		},
+		{
+			Filename:  "awk.md",
+			Command:   "awk",
+			Keywords:  []string{"text", "columns"},
+			Content:   "A pattern scanning language for a column of text.",
+			UpdatedAt: mtime,
+		},
+	}
+}
+
+func TestMatcherScore(t *testing.T) {
+	m := NewMatcher()
+	m.SetDocs(testDocs())
+
+	tests := []struct {
+		request string
+		want    []string
+	}{
+		{"tail the logs of the api pod", []string{"kubectl"}},
+		{"which tables are in the orders database", []string{"psql"}},
+		{"run a query", []string{"psql"}},
+		{"k8s deployment status", []string{"kubectl"}},
+		// "a" used to partially match every command name
+		{"a", nil},
+		{"make me a sandwich", nil},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, scored := range m.Score(tt.request) {
+			got = append(got, scored.Doc.Command)
+		}
+		if len(got) > len(tt.want) {
+			got = got[:len(tt.want)]
+		}
+		if len(got) != len(tt.want) || (len(got) > 0 && got[0] != tt.want[0]) {
+			t.Errorf("Score(%q) top = %v, want %v", tt.request, got, tt.want)
+		}
+	}
+}
+
+func TestLexicalIndexPersistence(t *testing.T) {
+	docs := testDocs()
+	path := filepath.Join(t.TempDir(), "lexical.idx")
+
+	if err := BuildLexicalIndex(docs).Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	idx, err := LoadLexicalIndex(path)
+	if err != nil {
+		t.Fatalf("LoadLexicalIndex() error = %v", err)
+	}
+	if !idx.Matches(docs) {
+		t.Fatal("loaded index doesn't match the docs it was built from")
+	}
+
+	scores := idx.Search("postgres")
+	if len(scores) != 1 || scores[1] <= 0 {
+		t.Errorf("Search(postgres) = %v, want only psql", scores)
+	}
+
+	// A checkout can rewrite a file without changing its mtime, or touch it
+	// without changing its content
+	docs[1].UpdatedAt = docs[1].UpdatedAt.Add(time.Second)
+	if !idx.Matches(docs) {
+		t.Error("index doesn't match after only a doc's mtime changed")
+	}
+	docs[1].Content += "\nMore content."
+	if idx.Matches(docs) {
+		t.Error("index matches after a doc was modified")
+	}
+	if idx.Matches(docs[:2]) {
+		t.Error("index matches after a doc was removed")
+	}
+}
+
+func TestGetLexicalIndexPath(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	shared := []CommandDir{{Source: SourceUser, Path: "/home/me/.please/commands"}, {Source: SourceSystem, Path: "/etc/please/commands"}}
+	projectA := append([]CommandDir{{Source: SourceProject, Path: "/src/a/.please/commands"}}, shared...)
+	projectB := append([]CommandDir{{Source: SourceProject, Path: "/src/b/.please/commands"}}, shared...)
+
+	pathA, err := GetLexicalIndexPath(projectA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pathB, _ := GetLexicalIndexPath(projectB)
+	again, _ := GetLexicalIndexPath(projectA)
+	outside, _ := GetLexicalIndexPath(shared)
+
+	if pathA != again {
+		t.Errorf("same dirs gave %s and %s", pathA, again)
+	}
+	if pathA == pathB || pathA == outside || pathB == outside {
+		t.Errorf("different dirs share an index: %s, %s, %s", pathA, pathB, outside)
+	}
+}
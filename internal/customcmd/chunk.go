@@ -0,0 +1,138 @@
+package customcmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// maxChunkChars is the size above which a section is split at blank lines
+const maxChunkChars = 2000
+
+// chunkIDSeparator separates the doc ID from the section slug in a chunk ID
+const chunkIDSeparator = "#"
+
+var (
+	headingRe = regexp.MustCompile(`^(#{1,6})\s+(.+?)\s*#*\s*$`)
+	slugRe    = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// Chunk is one heading-delimited section of a command doc
+type Chunk struct {
+	ID      string // Stable ID, e.g. "cmd_kubectl#pods/viewing-logs"
+	Heading string // Heading path, e.g. "Pods > Viewing logs"
+	Content string // Section text, including its heading line
+}
+
+// ChunkDoc splits a doc's markdown body into sections at headings. Each
+// chunk's ID is derived from its heading path, so it stays the same when
+// other sections are edited. Headings inside code blocks are ignored and
+// sections with no text of their own are skipped.
+func ChunkDoc(doc CommandDoc) []Chunk {
+	var chunks []Chunk
+	seen := make(map[string]int)
+
+	var path []string // Heading text by level
+	var section []string
+	inCodeBlock := false
+
+	flush := func() {
+		text := strings.TrimSpace(strings.Join(section, "\n"))
+		section = section[:0]
+		if text == "" || headingRe.MatchString(text) {
+			return // Nothing under this heading
+		}
+
+		var headings []string
+		for _, h := range path {
+			if h != "" {
+				headings = append(headings, h)
+			}
+		}
+
+		slug := "intro"
+		if len(headings) > 0 {
+			parts := make([]string, len(headings))
+			for i, h := range headings {
+				parts[i] = strings.Trim(slugRe.ReplaceAllString(strings.ToLower(h), "-"), "-")
+			}
+			slug = strings.Join(parts, "/")
+		}
+
+		for i, part := range splitSection(text) {
+			id := slug
+			if i > 0 {
+				id = fmt.Sprintf("%s-%d", slug, i+1)
+			}
+			if n := seen[id]; n > 0 {
+				// Repeated heading path, e.g. two "Examples" sections
+				seen[id] = n + 1
+				id = fmt.Sprintf("%s~%d", id, n+1)
+			} else {
+				seen[id] = 1
+			}
+
+			chunks = append(chunks, Chunk{
+				ID:      docID(doc) + chunkIDSeparator + id,
+				Heading: strings.Join(headings, " > "),
+				Content: part,
+			})
+		}
+	}
+
+	for _, line := range strings.Split(doc.Content, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCodeBlock = !inCodeBlock
+		}
+
+		if m := headingRe.FindStringSubmatch(line); m != nil && !inCodeBlock {
+			flush()
+			level := len(m[1])
+			for len(path) < level {
+				path = append(path, "")
+			}
+			path = append(path[:level-1], m[2])
+		}
+
+		section = append(section, line)
+	}
+	flush()
+
+	return chunks
+}
+
+// splitSection breaks an overlong section at blank lines outside code blocks
+func splitSection(text string) []string {
+	if len(text) <= maxChunkChars {
+		return []string{text}
+	}
+
+	var parts []string
+	var current strings.Builder
+	inCodeBlock := false
+
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCodeBlock = !inCodeBlock
+		}
+		if strings.TrimSpace(line) == "" && !inCodeBlock && current.Len() >= maxChunkChars/2 {
+			parts = append(parts, strings.TrimSpace(current.String()))
+			current.Reset()
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		parts = append(parts, rest)
+	}
+
+	return parts
+}
+
+// chunkDocID returns the ID of the doc a vector ID belongs to, which is the
+// ID itself for whole-doc vectors
+func chunkDocID(id string) string {
+	docPart, _, _ := strings.Cut(id, chunkIDSeparator)
+	return docPart
+}
@@ -0,0 +1,98 @@
+package customcmd
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/iishyfishyy/please/internal/customcmd/vectorstore"
+)
+
+const chunkTestContent = `Deploy tool for internal services.
+
+# Deploying
+
+## Rollout
+Run a rollout with deployctl rollout.
+
+` + "```bash" + `
+# not a heading
+deployctl rollout api
+` + "```" + `
+
+## Rollback
+Undo the last release with deployctl rollback.
+
+# Logs
+## Rollout
+Logs for a rollout: deployctl logs --rollout.
+`
+
+func TestChunkDoc(t *testing.T) {
+	doc := CommandDoc{Command: "deployctl", Content: chunkTestContent}
+	chunks := ChunkDoc(doc)
+
+	var ids, headings []string
+	for _, chunk := range chunks {
+		ids = append(ids, chunk.ID)
+		headings = append(headings, chunk.Heading)
+	}
+
+	wantIDs := []string{
+		"cmd_deployctl#intro",
+		"cmd_deployctl#deploying/rollout",
+		"cmd_deployctl#deploying/rollback",
+		"cmd_deployctl#logs/rollout",
+	}
+	if !reflect.DeepEqual(ids, wantIDs) {
+		t.Errorf("chunk IDs = %v, want %v", ids, wantIDs)
+	}
+
+	wantHeadings := []string{"", "Deploying > Rollout", "Deploying > Rollback", "Logs > Rollout"}
+	if !reflect.DeepEqual(headings, wantHeadings) {
+		t.Errorf("chunk headings = %v, want %v", headings, wantHeadings)
+	}
+
+	if !strings.Contains(chunks[1].Content, "# not a heading") {
+		t.Errorf("heading inside code block split the section: %q", chunks[1].Content)
+	}
+
+	// Editing one section leaves the other IDs unchanged
+	edited := doc
+	edited.Content = strings.Replace(chunkTestContent, "Undo the last release", "Revert", 1)
+	for i, chunk := range ChunkDoc(edited) {
+		if chunk.ID != wantIDs[i] {
+			t.Errorf("after edit, chunk %d ID = %s, want %s", i, chunk.ID, wantIDs[i])
+		}
+	}
+}
+
+func TestSemanticSearchMatchedSections(t *testing.T) {
+	doc := CommandDoc{Command: "deployctl", Keywords: []string{"deploy"}, Content: chunkTestContent}
+
+	embedder := &conceptEmbedder{concepts: [][]string{
+		{"rollback", "undo", "revert"},
+		{"logs", "output"},
+	}}
+	s := NewSemanticMatcher(embedder, vectorstore.NewMemoryStore())
+	if err := s.Index(context.Background(), []CommandDoc{doc}); err != nil {
+		t.Fatalf("Index() error = %v", err)
+	}
+
+	docs, _, err := s.Search(context.Background(), "undo rollback", 1)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(docs) != 1 {
+		t.Fatalf("Search() returned %d docs, want 1", len(docs))
+	}
+
+	var matched []string
+	for _, chunk := range docs[0].MatchedChunks {
+		matched = append(matched, chunk.ID)
+	}
+	if want := []string{"cmd_deployctl#deploying/rollback"}; !reflect.DeepEqual(matched, want) {
+		t.Errorf("matched sections = %v, want %v", matched, want)
+	}
+}
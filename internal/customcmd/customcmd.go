@@ -5,29 +5,35 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"sync"
 	"time"
 
 	"github.com/iishyfishyy/please/internal/config"
 	"github.com/iishyfishyy/please/internal/customcmd/embeddings"
 	"github.com/iishyfishyy/please/internal/customcmd/vectorstore"
+	"github.com/iishyfishyy/please/internal/placeholder"
 	"github.com/iishyfishyy/please/internal/ui"
 )
 
 // Manager coordinates loading, matching, and indexing of custom commands
 type Manager struct {
-	commandsDir      string
-	docs             []CommandDoc
-	matcher          *Matcher
-	semanticMatcher  *SemanticMatcher
-	indexed          bool
-	indexTime        time.Time
-	mu               sync.RWMutex
+	dirs            []CommandDir   // Highest precedence first
+	scopeDocs       [][]CommandDoc // Every doc loaded from each of dirs, including shadowed ones
+	docs            []CommandDoc   // Docs in effect, after precedence
+	matcher         *Matcher
+	semanticMatcher *SemanticMatcher
+	indexed         bool
+	indexTime       time.Time
+	mu              sync.RWMutex
 	// Embedding configuration (optional)
 	embeddingEnabled bool
+	embedding        config.CustomCommands
 	provider         string
-	model            string
-	dims             int
+	model            string // Cache key for the model, including any custom endpoint
+	// Matching configuration
+	matching config.MatchingConfig
+	scope    vectorstore.Filter // Restricts which docs are considered
 	// Debug flag
 	debug bool
 }
@@ -41,11 +47,37 @@ type CommandDoc struct {
 	Categories []string  // Categories (e.g., devops, database)
 	Priority   string    // Priority (high, medium, low)
 	Version    string    // Version of the tool
+	OS         []string  // Operating systems the doc applies to, as GOOS values (empty = all)
+	Source     string    // Where the doc was loaded from, e.g. SourceUser
+	Namespace  string    // Subdirectory the doc was loaded from, e.g. "aws" or "k8s/helm"
 	Content    string    // Full markdown content
 	Examples   []Example // Parsed examples
 	UpdatedAt  time.Time // File modification time
+
+	Placeholders map[string]placeholder.Spec // Parameter specs keyed by normalised name
+
+	MatchedChunks []Chunk // Sections that matched a search, set on search results only
+}
+
+// Name returns the command qualified by its namespace, e.g. "aws/deploy" for
+// aws/deploy.md. Docs at the top level of a commands dir are just their
+// command, so the same command can be documented once per namespace.
+func (d CommandDoc) Name() string {
+	if d.Namespace == "" {
+		return d.Command
+	}
+	return d.Namespace + "/" + d.Command
 }
 
+// Doc sources, in order of precedence: a project doc replaces a user, git
+// source or system doc with the same name
+const (
+	SourceProject = "project" // .please/commands in the working directory or a parent
+	SourceUser    = "user"    // ~/.please/commands
+	SourceGit     = "git"     // ~/.please/sources/<name>, as "git:<name>"
+	SourceSystem  = "system"  // /etc/please/commands
+)
+
 // Example represents a user request → command example
 type Example struct {
 	UserRequest string
@@ -59,20 +91,24 @@ func NewManager() (*Manager, error) {
 
 // NewManagerWithDebug creates a new custom command manager with debug logging
 func NewManagerWithDebug(debug bool) (*Manager, error) {
-	commandsDir, err := GetCommandsDir()
+	dirs, err := GetCommandDirs()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get commands directory: %w", err)
 	}
 
 	if debug {
-		fmt.Fprintf(os.Stderr, "[DEBUG] CustomCmd: manager created (commands_dir=%s)\n", commandsDir)
+		for _, dir := range dirs {
+			fmt.Fprintf(os.Stderr, "[DEBUG] CustomCmd: manager created (%s commands_dir=%s)\n", dir.Source, dir.Path)
+		}
 	}
 
 	m := &Manager{
-		commandsDir: commandsDir,
-		docs:        []CommandDoc{},
-		matcher:     NewMatcherWithDebug(debug),
-		debug:       debug,
+		dirs:     dirs,
+		docs:     []CommandDoc{},
+		matcher:  NewMatcherWithDebug(debug),
+		matching: config.MatchingConfig{Strategy: "keyword"},
+		scope:    vectorstore.Filter{OS: []string{runtime.GOOS}},
+		debug:    debug,
 	}
 
 	return m, nil
@@ -110,37 +146,88 @@ func EnsureCommandsDir() error {
 	return nil
 }
 
-// Load reads all command documentation files from the commands directory
+// Load reads all command documentation files from the commands
+// directories. Where several define the same command, the doc from the
+// highest-precedence directory is used.
 func (m *Manager) Load() error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if m.debug {
-		fmt.Fprintf(os.Stderr, "[DEBUG] CustomCmd: loading commands from %s\n", m.commandsDir)
-	}
-
 	loader := NewLoaderWithDebug(m.debug)
-	docs, err := loader.LoadAll(m.commandsDir)
-	if err != nil {
-		return fmt.Errorf("failed to load commands: %w", err)
+	scopeDocs := make([][]CommandDoc, len(m.dirs))
+	var docs []CommandDoc
+	seen := make(map[string]string) // Name → source of the doc in effect
+
+	for i, dir := range m.dirs {
+		if m.debug {
+			fmt.Fprintf(os.Stderr, "[DEBUG] CustomCmd: loading %s commands from %s\n", dir.Source, dir.Path)
+		}
+
+		loaded, err := loader.LoadAll(dir.Path)
+		if err != nil {
+			return fmt.Errorf("failed to load commands from %s: %w", dir.Path, err)
+		}
+
+		for j := range loaded {
+			loaded[j].Source = dir.Source
+			doc := loaded[j]
+			if source, ok := seen[doc.Name()]; ok {
+				if m.debug {
+					fmt.Fprintf(os.Stderr, "[DEBUG] CustomCmd: %s doc for %s overrides %s\n", source, doc.Name(), doc.Filename)
+				}
+				continue
+			}
+			seen[doc.Name()] = dir.Source
+			docs = append(docs, doc)
+		}
+		scopeDocs[i] = loaded
 	}
 
 	if m.debug {
 		fmt.Fprintf(os.Stderr, "[DEBUG] CustomCmd: loaded %d command docs\n", len(docs))
 		for _, doc := range docs {
-			fmt.Fprintf(os.Stderr, "[DEBUG] CustomCmd:   - %s (%d examples, %d keywords)\n",
-				doc.Command, len(doc.Examples), len(doc.Keywords))
+			fmt.Fprintf(os.Stderr, "[DEBUG] CustomCmd:   - %s (%s, %d examples, %d keywords)\n",
+				doc.Name(), doc.Source, len(doc.Examples), len(doc.Keywords))
 		}
 	}
 
 	m.docs = docs
-	m.matcher.SetDocs(docs)
+	m.scopeDocs = scopeDocs
+	m.matcher.SetIndex(docs, m.lexicalIndex(docs))
 	m.indexed = true
 	m.indexTime = time.Now()
 
 	return nil
 }
 
+// lexicalIndex returns the persisted BM25 index for docs, rebuilding and
+// saving it if it's missing or out of date
+func (m *Manager) lexicalIndex(docs []CommandDoc) *LexicalIndex {
+	path, err := GetLexicalIndexPath(m.dirs)
+	if err != nil {
+		return BuildLexicalIndex(docs)
+	}
+
+	if idx, err := LoadLexicalIndex(path); err == nil && idx.Matches(docs) {
+		if m.debug {
+			fmt.Fprintf(os.Stderr, "[DEBUG] CustomCmd: using cached lexical index from %s\n", path)
+		}
+		return idx
+	}
+
+	idx := BuildLexicalIndex(docs)
+	if err := idx.Save(path); err != nil {
+		// Non-fatal, the index is rebuilt next time
+		if m.debug {
+			fmt.Fprintf(os.Stderr, "[DEBUG] CustomCmd: failed to save lexical index: %v\n", err)
+		}
+	} else if m.debug {
+		fmt.Fprintf(os.Stderr, "[DEBUG] CustomCmd: rebuilt lexical index (%d terms)\n", len(idx.Postings))
+	}
+
+	return idx
+}
+
 // GetRelevantDocs finds the most relevant custom command docs for a request
 func (m *Manager) GetRelevantDocs(request string, maxDocs int) []CommandDoc {
 	m.mu.RLock()
@@ -153,7 +240,25 @@ func (m *Manager) GetRelevantDocs(request string, maxDocs int) []CommandDoc {
 		return []CommandDoc{}
 	}
 
-	results := m.matcher.FindRelevantDocs(request, maxDocs)
+	hybrid := &HybridMatcher{
+		keywordMatcher:  m.matcher,
+		semanticMatcher: m.semanticMatcher,
+		strategy:        m.matching.Strategy,
+		threshold:       m.matching.KeywordThreshold,
+		scope:           m.scope,
+		debug:           m.debug,
+	}
+	hybrid.SetFusion(m.matching.KeywordWeight, m.matching.MinScore)
+
+	results, err := hybrid.FindRelevantDocs(context.Background(), request, maxDocs)
+	if err != nil {
+		// Semantic search failed (e.g. embedding service unreachable), use keywords
+		if m.debug {
+			fmt.Fprintf(os.Stderr, "[DEBUG] CustomCmd: %s search failed, falling back to keywords: %v\n", m.matching.Strategy, err)
+		}
+		results = hybrid.keywordDocs(request, maxDocs)
+	}
+
 	if m.debug && len(results) > 0 {
 		fmt.Fprintf(os.Stderr, "[DEBUG] CustomCmd: found %d relevant docs for %q\n", len(results), request)
 		for i, doc := range results {
@@ -188,11 +293,18 @@ func (m *Manager) NeedsReindex() bool {
 		return true
 	}
 
-	// Check if any .md files are newer than index
-	pattern := filepath.Join(m.commandsDir, "*.md")
-	files, err := filepath.Glob(pattern)
-	if err != nil {
-		return false
+	// Check if any command files, or the ignore files deciding which
+	// files count, are newer than the index
+	var files []string
+	for _, dir := range m.dirs {
+		found, err := walkCommandDir(dir.Path)
+		if err != nil {
+			return false
+		}
+		for _, file := range found {
+			files = append(files, file.Path)
+		}
+		files = append(files, filepath.Join(dir.Path, IgnoreFileName))
 	}
 
 	for _, file := range files {
@@ -215,6 +327,13 @@ func (m *Manager) Count() int {
 	return len(m.docs)
 }
 
+// Dirs returns the directories docs are loaded from, highest precedence first
+func (m *Manager) Dirs() []CommandDir {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]CommandDir{}, m.dirs...)
+}
+
 // GetDocs returns all loaded command docs (for listing)
 func (m *Manager) GetDocs() []CommandDoc {
 	m.mu.RLock()
@@ -226,51 +345,129 @@ func (m *Manager) GetDocs() []CommandDoc {
 	return docs
 }
 
-// SetEmbeddingConfig configures the manager to use embeddings for semantic search
-func (m *Manager) SetEmbeddingConfig(provider, model string, dims int) {
+// SetEmbeddingConfig configures the manager to use embeddings for semantic
+// search with the provider settings from the config
+func (m *Manager) SetEmbeddingConfig(cc config.CustomCommands) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	m.embeddingEnabled = true
-	m.provider = provider
-	m.model = model
-	m.dims = dims
+	m.embedding = cc
+	m.provider = string(cc.Provider)
+
+	switch cc.Provider {
+	case config.ProviderOllama:
+		m.model = cc.Ollama.Model
+	case config.ProviderOpenAI:
+		m.model = cc.OpenAI.Model
+		if m.model == "" {
+			m.model = "text-embedding-3-small"
+		}
+		// The same model name on another server may produce other vectors
+		if cc.OpenAI.BaseURL != "" {
+			m.model += "@" + cc.OpenAI.BaseURL
+		}
+	case config.ProviderTEI:
+		m.model = cc.TEI.URL // The server decides the model; Index asks it which
+	case config.ProviderLlamaCpp:
+		m.model = cc.LlamaCpp.URL
+	case config.ProviderCohere:
+		m.model = cc.Cohere.Model
+		if m.model == "" {
+			m.model = "embed-english-v3.0"
+		}
+		if cc.Cohere.BaseURL != "" {
+			m.model += "@" + cc.Cohere.BaseURL
+		}
+	case config.ProviderLocal:
+		m.model = embeddings.LocalModel
+	}
+}
+
+// SetScope restricts GetRelevantDocs to docs whose categories, priority,
+// source and OS match filter. By default only docs for the current OS are
+// considered; the zero Filter considers every doc.
+func (m *Manager) SetScope(filter vectorstore.Filter) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.scope = filter
+}
+
+// SetMatchingConfig sets the strategy and score cutoffs used by GetRelevantDocs
+func (m *Manager) SetMatchingConfig(matching config.MatchingConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if matching.Strategy == "" {
+		matching.Strategy = "keyword"
+	}
+	m.matching = matching
+}
+
+// IsSemanticIndexed returns whether embeddings are available for semantic search
+func (m *Manager) IsSemanticIndexed() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.semanticMatcher != nil && m.semanticMatcher.IsIndexed()
 }
 
 // createEmbedder creates an embedder instance based on the configured provider
 func (m *Manager) createEmbedder() (embeddings.Embedder, error) {
-	switch m.provider {
-	case "ollama":
-		baseURL := "http://localhost:11434"
-		return embeddings.NewOllamaEmbedder(baseURL, m.model)
-
-	case "openai":
-		// Get API key from env var first
-		apiKey := os.Getenv("OPENAI_API_KEY")
-
-		// If not in env, try to load from config
-		if apiKey == "" {
-			cfg, err := config.Load()
-			if err == nil && cfg != nil && cfg.CustomCommands != nil {
-				apiKey = cfg.CustomCommands.OpenAI.APIKey
-			}
-		}
+	cfg := embeddings.Config{
+		Provider:         m.provider,
+		OllamaURL:        m.embedding.Ollama.URL,
+		OllamaModel:      m.embedding.Ollama.Model,
+		OpenAIModel:      m.embedding.OpenAI.Model,
+		OpenAIBaseURL:    m.embedding.OpenAI.BaseURL,
+		OpenAIDimensions: m.embedding.OpenAI.Dimensions,
+		TEIURL:           m.embedding.TEI.URL,
+		LlamaCppURL:      m.embedding.LlamaCpp.URL,
+		CohereModel:      m.embedding.Cohere.Model,
+		CohereBaseURL:    m.embedding.Cohere.BaseURL,
+	}
 
-		if apiKey == "" {
-			return nil, fmt.Errorf("OpenAI API key not found (set OPENAI_API_KEY or store in config)")
+	switch m.embedding.Provider {
+	case config.ProviderOpenAI:
+		cfg.OpenAIKey = m.embedding.OpenAI.ResolveAPIKey()
+		if cfg.OpenAIKey == "" && m.embedding.OpenAI.BaseURL == "" {
+			return nil, fmt.Errorf("OpenAI API key not found (set %s or store in config)",
+				envVarName(m.embedding.OpenAI.APIKeyEnv, "OPENAI_API_KEY"))
 		}
+	case config.ProviderCohere:
+		cfg.CohereKey = m.embedding.Cohere.ResolveAPIKey()
+		if cfg.CohereKey == "" && m.embedding.Cohere.BaseURL == "" {
+			return nil, fmt.Errorf("Cohere API key not found (set %s or store in config)",
+				envVarName(m.embedding.Cohere.APIKeyEnv, "COHERE_API_KEY"))
+		}
+	}
 
-		return embeddings.NewOpenAIEmbedder(apiKey, m.model)
-
-	default:
+	embedder, err := embeddings.NewEmbedder(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if embedder == nil {
 		return nil, fmt.Errorf("unknown embedding provider: %s", m.provider)
 	}
+	return embedder, nil
+}
+
+// envVarName returns the configured API key variable, or the provider's default
+func envVarName(configured, fallback string) string {
+	if configured != "" {
+		return configured
+	}
+	return fallback
 }
 
 // Index explicitly loads/reloads all command documentation
 // If force is true, bypasses cache and regenerates embeddings
 func (m *Manager) Index(ctx context.Context, force bool) error {
-	// 1. Load command docs
+	// 1. Load command docs (and the lexical index, rebuilt when forced)
+	if force {
+		if path, err := GetLexicalIndexPath(m.dirs); err == nil {
+			os.Remove(path)
+		}
+	}
 	if err := m.Load(); err != nil {
 		return err
 	}
@@ -280,84 +477,206 @@ func (m *Manager) Index(ctx context.Context, force bool) error {
 		return nil
 	}
 
-	// 3. Get cache path
-	cachePath, err := GetEmbeddingsCachePath()
+	// 3. Create embedder (needed for queries as well as changed docs)
+	embedder, err := m.createEmbedder()
+	if err != nil {
+		return fmt.Errorf("failed to create embedder: %w", err)
+	}
+	// A self-hosted server's model keys the cache, so serving another model
+	// from the same URL rebuilds it
+	if served, ok := embedder.(embeddings.ServedModel); ok {
+		m.mu.Lock()
+		m.model = served.Model()
+		m.mu.Unlock()
+	}
+	quant, err := vectorstore.ParseQuantization(m.embedding.Quantization)
+	if err != nil {
+		return err
+	}
+
+	// 4. Bring each directory's embeddings cache up to date and search them together
+	stores := vectorstore.NewMultiStore()
+	for i, dir := range m.dirs {
+		docs := m.scopeDocs[i]
+		if len(docs) == 0 {
+			continue
+		}
+		store, err := m.indexScope(ctx, embedder, quant, dir, docs, force)
+		if err != nil {
+			return err
+		}
+		stores.Set(dir.Source, store)
+	}
+
+	m.semanticMatcher = NewCachedSemanticMatcher(embedder, stores, m.docs)
+	m.semanticMatcher.SetDebug(m.debug)
+	return nil
+}
+
+// indexScope returns a store holding embeddings for the docs of one commands
+// directory, updating its cache incrementally or rebuilding it when it's
+// missing, was built with another model, or force is set
+func (m *Manager) indexScope(ctx context.Context, embedder embeddings.Embedder, quant vectorstore.Quantization, dir CommandDir, docs []CommandDoc, force bool) (vectorstore.Store, error) {
+	cachePath, err := GetScopeCachePath(dir)
 	if err != nil {
 		// Non-fatal, continue with in-memory
-		m.semanticMatcher = NewSemanticMatcher(nil, nil)
-		return nil
+		return m.indexInMemory(ctx, embedder, docs)
 	}
 
-	// 4. Try to load existing cache
+	// Try to update the existing cache incrementally
 	if !force {
-		sqlStore, err := vectorstore.OpenSQLiteStore(cachePath)
-		if err == nil {
-			// Convert docs to vectorstore.CommandDoc type
-			vstoreDocs := make([]vectorstore.CommandDoc, len(m.docs))
-			for i, doc := range m.docs {
-				vstoreDocs[i] = vectorstore.CommandDoc{
-					Filename:  doc.Filename,
-					UpdatedAt: doc.UpdatedAt,
+		if sqlStore, err := vectorstore.OpenSQLiteStore(cachePath); err == nil {
+			ok, reason := sqlStore.Matches(m.provider, m.model, embedder.Dimensions())
+			if ok {
+				// Re-encoding is lossy, so only coarser quantizations avoid a rebuild
+				if err := sqlStore.Quantize(ctx, quant); err != nil {
+					ok, reason = false, err.Error()
 				}
 			}
-
-			// Cache exists - validate it
-			valid, _ := sqlStore.IsValid(vstoreDocs, m.provider, m.model, m.dims)
-
-			if valid {
-				// Cache is valid - use it
-				m.semanticMatcher = &SemanticMatcher{
-					vectorStore: sqlStore,
-					indexed:     true,
+			if ok {
+				store, err := vectorstore.NewHNSWStore(ctx, sqlStore)
+				if err != nil {
+					sqlStore.Close()
+					return nil, fmt.Errorf("failed to load cache: %w", err)
 				}
-				return nil
+				if err := m.updateIndex(ctx, embedder, store, docs); err != nil {
+					return nil, err
+				}
+				return store, nil
+			} else if m.debug {
+				fmt.Fprintf(os.Stderr, "[DEBUG] CustomCmd: rebuilding %s embeddings cache (%s)\n", dir.Source, reason)
 			}
-
-			// Cache invalid - clear it
-			sqlStore.Clear(ctx)
 			sqlStore.Close()
 		}
 	}
 
-	// 5. Cache doesn't exist, is invalid, or force flag set - regenerate embeddings
-	ui.ShowInfo("Generating embeddings...")
+	// Cache doesn't exist, was built with another model, or force flag set - regenerate embeddings
+	ui.ShowInfo(fmt.Sprintf("Generating embeddings for %s commands...", dir.Source))
 
-	// Create embedder
-	embedder, err := m.createEmbedder()
-	if err != nil {
-		return fmt.Errorf("failed to create embedder: %w", err)
-	}
-
-	// Create new SQLite store
-	sqlStore, err := vectorstore.NewSQLiteStore(cachePath, m.provider, m.model, m.dims)
+	// Dimensions may still be unknown; they're recorded once the first vectors come back
+	sqlStore, err := vectorstore.NewSQLiteStore(cachePath, m.provider, m.model, embedder.Dimensions())
 	if err != nil {
 		// Fallback to in-memory if SQLite fails
 		ui.ShowWarning(fmt.Sprintf("Failed to create cache: %v", err))
 		ui.ShowWarning("Using in-memory storage (embeddings won't be persisted)")
-
-		m.semanticMatcher = NewSemanticMatcher(embedder, nil)
-		if err := m.semanticMatcher.Index(ctx, m.docs); err != nil {
-			return fmt.Errorf("failed to index: %w", err)
-		}
-
-		return nil
+		return m.indexInMemory(ctx, embedder, docs)
 	}
 
-	// Create semantic matcher with SQLite store
-	m.semanticMatcher = NewSemanticMatcher(embedder, sqlStore)
+	// Drop the old vectors before the search index loads them
+	if err := sqlStore.Clear(ctx); err != nil {
+		sqlStore.Close()
+		return nil, fmt.Errorf("failed to clear cache: %w", err)
+	}
+	if err := sqlStore.Quantize(ctx, quant); err != nil {
+		sqlStore.Close()
+		return nil, fmt.Errorf("failed to set quantization: %w", err)
+	}
+	store, err := vectorstore.NewHNSWStore(ctx, sqlStore)
+	if err != nil {
+		sqlStore.Close()
+		return nil, fmt.Errorf("failed to load cache: %w", err)
+	}
 
 	// Generate embeddings and store them
-	ui.ShowInfo(fmt.Sprintf("Processing %d commands...", len(m.docs)))
+	ui.ShowInfo(fmt.Sprintf("Processing %d commands...", len(docs)))
 	start := time.Now()
 
-	if err := m.semanticMatcher.Index(ctx, m.docs); err != nil {
-		sqlStore.Close()
-		return fmt.Errorf("failed to index: %w", err)
+	semantic := NewSemanticMatcher(embedder, store)
+	semantic.SetDebug(m.debug)
+	progress := ui.NewProgress("Embedding")
+	semantic.SetProgress(progress.Update)
+	err = semantic.Index(ctx, docs)
+	progress.Done()
+	if err != nil {
+		// Don't leave half-embedded docs behind looking up to date
+		store.Clear(ctx)
+		store.Close()
+		return nil, fmt.Errorf("failed to index: %w", err)
 	}
 
 	duration := time.Since(start)
 	ui.ShowSuccess(fmt.Sprintf("Generated and cached embeddings (%.1fs)", duration.Seconds()))
 
+	return store, nil
+}
+
+// indexInMemory embeds docs into a store that isn't persisted
+func (m *Manager) indexInMemory(ctx context.Context, embedder embeddings.Embedder, docs []CommandDoc) (vectorstore.Store, error) {
+	store := vectorstore.NewMemoryStore()
+	semantic := NewSemanticMatcher(embedder, store)
+	semantic.SetDebug(m.debug)
+	if err := semantic.Index(ctx, docs); err != nil {
+		return nil, fmt.Errorf("failed to index: %w", err)
+	}
+	return store, nil
+}
+
+// updateIndex brings an existing embeddings cache up to date with docs by
+// comparing content hashes, re-embedding only added and updated docs and
+// deleting the vectors of removed ones
+func (m *Manager) updateIndex(ctx context.Context, embedder embeddings.Embedder, store *vectorstore.HNSWStore, docs []CommandDoc) error {
+	byFile := make(map[string]CommandDoc, len(docs))
+	vstoreDocs := make([]vectorstore.CommandDoc, len(docs))
+	for i, doc := range docs {
+		byFile[doc.Filename] = doc
+		vstoreDocs[i] = vectorstore.CommandDoc{
+			Filename:    doc.Filename,
+			UpdatedAt:   doc.UpdatedAt,
+			ContentHash: contentHash(doc),
+		}
+	}
+
+	diff, err := store.Diff(vstoreDocs)
+	if err != nil {
+		store.Close()
+		return fmt.Errorf("failed to compare cache: %w", err)
+	}
+
+	if diff.Empty() {
+		// Cache is up to date - use it
+		if m.debug {
+			fmt.Fprintf(os.Stderr, "[DEBUG] CustomCmd: using cached embeddings (%s)\n", diff)
+		}
+		return nil
+	}
+
+	ui.ShowInfo("Updating embeddings...")
+	start := time.Now()
+
+	// Drop stale vectors, including sections that no longer exist in updated docs
+	stale := append([]string{}, diff.Removed...)
+	for _, doc := range diff.Updated {
+		stale = append(stale, doc.Filename)
+	}
+	if err := store.DeleteFiles(ctx, stale); err != nil {
+		store.Close()
+		return err
+	}
+
+	var changed []CommandDoc
+	for _, doc := range diff.Changed() {
+		changed = append(changed, byFile[doc.Filename])
+	}
+
+	semantic := NewSemanticMatcher(embedder, store)
+	semantic.SetDebug(m.debug)
+
+	progress := ui.NewProgress("Embedding")
+	semantic.SetProgress(progress.Update)
+	err = semantic.Update(ctx, docs, changed)
+	progress.Done()
+	if err != nil {
+		// Don't leave half-embedded docs behind looking up to date
+		var filenames []string
+		for _, doc := range changed {
+			filenames = append(filenames, doc.Filename)
+		}
+		store.DeleteFiles(ctx, filenames)
+		store.Close()
+		return fmt.Errorf("failed to index: %w", err)
+	}
+
+	ui.ShowSuccess(fmt.Sprintf("Embeddings: %s (%.1fs)", diff, time.Since(start).Seconds()))
 	return nil
 }
 
@@ -369,10 +688,13 @@ func (m *Manager) GetRelevantDocsForAgent(request string, maxDocs int) []AgentCo
 	agentDocs := make([]AgentCommandDoc, len(docs))
 	for i, doc := range docs {
 		agentDocs[i] = AgentCommandDoc{
-			Command: doc.Command,
-			Content: doc.Content,
+			Command:  doc.Command,
+			Content:  doc.Content,
 			Examples: make([]AgentExample, len(doc.Examples)),
 		}
+		for _, chunk := range doc.MatchedChunks {
+			agentDocs[i].Sections = append(agentDocs[i].Sections, chunk.Content)
+		}
 		for j, ex := range doc.Examples {
 			agentDocs[i].Examples[j] = AgentExample{
 				UserRequest: ex.UserRequest,
@@ -389,6 +711,7 @@ type AgentCommandDoc struct {
 	Command  string
 	Content  string
 	Examples []AgentExample
+	Sections []string // Doc sections that matched the request, if known
 }
 
 // AgentExample represents a command example for the agent
@@ -0,0 +1,158 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// cohereBaseURL is the default API endpoint
+const cohereBaseURL = "https://api.cohere.com"
+
+// cohereMaxBatch is the most texts Cohere accepts per request
+const cohereMaxBatch = 96
+
+// cohereModelDims are the sizes of Cohere's embedding models
+var cohereModelDims = map[string]int{
+	"embed-english-v3.0":            1024,
+	"embed-multilingual-v3.0":       1024,
+	"embed-english-light-v3.0":      384,
+	"embed-multilingual-light-v3.0": 384,
+}
+
+// CohereEmbedder implements Embedder using Cohere's /v1/embed API, or a
+// server that implements it. Docs are embedded as search documents and
+// requests as search queries, as Cohere's v3 models expect.
+type CohereEmbedder struct {
+	apiKey  string
+	model   string
+	baseURL string
+	client  *http.Client
+	dims    dimensions
+	retry   RetryPolicy
+}
+
+// NewCohereEmbedder creates a new Cohere embedder. baseURL points it at a
+// Cohere-compatible server instead of api.cohere.com.
+func NewCohereEmbedder(apiKey, model, baseURL string) (*CohereEmbedder, error) {
+	if baseURL == "" {
+		baseURL = cohereBaseURL
+	}
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	if apiKey == "" && baseURL == cohereBaseURL {
+		return nil, fmt.Errorf("Cohere API key is required")
+	}
+
+	if model == "" {
+		model = "embed-english-v3.0"
+	}
+
+	return &CohereEmbedder{
+		apiKey:  apiKey,
+		model:   model,
+		baseURL: baseURL,
+		client:  &http.Client{Timeout: 30 * time.Second},
+		retry:   DefaultRetryPolicy,
+	}, nil
+}
+
+// Embed generates an embedding for a single text. It's used for search
+// requests, so the text is embedded as a query.
+func (c *CohereEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := c.embed(ctx, []string{text}, "search_query")
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// EmbedBatch generates embeddings for multiple texts, embedded as documents
+func (c *CohereEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	return c.embed(ctx, texts, "search_document")
+}
+
+func (c *CohereEmbedder) embed(ctx context.Context, texts []string, inputType string) ([][]float32, error) {
+	headers := map[string]string{}
+	if c.apiKey != "" {
+		headers["Authorization"] = "Bearer " + c.apiKey
+	}
+
+	embeddings, err := embedInBatches(ctx, texts, cohereMaxBatch, func(ctx context.Context, batch []string) ([][]float32, error) {
+		reqBody := map[string]interface{}{
+			"model":           c.model,
+			"texts":           batch,
+			"input_type":      inputType,
+			"embedding_types": []string{"float"},
+			"truncate":        "END",
+		}
+
+		var result struct {
+			Embeddings json.RawMessage `json:"embeddings"`
+		}
+		if err := postJSON(ctx, c.client, c.retry, c.baseURL+"/v1/embed", headers, reqBody, &result); err != nil {
+			return nil, fmt.Errorf("cohere: %w", cohereError(err))
+		}
+		return parseCohereEmbeddings(result.Embeddings)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(embeddings) > 0 {
+		c.dims.observe(embeddings[0])
+	}
+	return embeddings, nil
+}
+
+// parseCohereEmbeddings reads embeddings either keyed by type, as returned
+// when embedding_types is set ({"float": [[...]]}), or as a bare list, as
+// older and compatible servers return
+func parseCohereEmbeddings(data json.RawMessage) ([][]float32, error) {
+	var byType struct {
+		Float [][]float32 `json:"float"`
+	}
+	if err := json.Unmarshal(data, &byType); err == nil {
+		return byType.Float, nil
+	}
+
+	var list [][]float32
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("cohere: unexpected embeddings: %w", err)
+	}
+	return list, nil
+}
+
+// cohereError replaces a StatusError's raw body with the API's error message
+func cohereError(err error) error {
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		return err
+	}
+
+	var errResp struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal([]byte(statusErr.Message), &errResp) == nil && errResp.Message != "" {
+		statusErr.Message = errResp.Message
+	}
+	return err
+}
+
+// Dimensions returns the embedding dimension size: what the server returned,
+// else the model's known size on api.cohere.com
+func (c *CohereEmbedder) Dimensions() int {
+	if c.baseURL == cohereBaseURL {
+		return c.dims.get(cohereModelDims[c.model])
+	}
+	return c.dims.get(0)
+}
+
+// Name returns the model name
+func (c *CohereEmbedder) Name() string {
+	return fmt.Sprintf("cohere/%s", c.model)
+}
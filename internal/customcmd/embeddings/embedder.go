@@ -1,6 +1,9 @@
 package embeddings
 
-import "context"
+import (
+	"context"
+	"sync/atomic"
+)
 
 // Embedder generates vector embeddings for text
 type Embedder interface {
@@ -10,13 +13,22 @@ type Embedder interface {
 	// EmbedBatch generates embeddings for multiple texts efficiently
 	EmbedBatch(ctx context.Context, texts []string) ([][]float32, error)
 
-	// Dimensions returns the size of the embedding vectors
+	// Dimensions returns the size of the embedding vectors, or 0 if it isn't
+	// known until the first response
 	Dimensions() int
 
 	// Name returns the name/model of this embedder
 	Name() string
 }
 
+// ServedModel is implemented by embedders for servers that host a single
+// model, chosen when the server starts, to report which one it is
+type ServedModel interface {
+	// Model returns the served model's ID, or the server URL if the server
+	// doesn't say
+	Model() string
+}
+
 // Config holds configuration for creating an embedder
 type Config struct {
 	Provider string
@@ -26,8 +38,19 @@ type Config struct {
 	OllamaModel string
 
 	// OpenAI config
-	OpenAIKey   string
-	OpenAIModel string
+	OpenAIKey        string
+	OpenAIModel      string
+	OpenAIBaseURL    string // OpenAI-compatible server; empty for api.openai.com
+	OpenAIDimensions int    // Requested vector size, for models that support it
+
+	// Self-hosted servers, which each host one model
+	TEIURL      string
+	LlamaCppURL string
+
+	// Cohere config
+	CohereKey     string
+	CohereModel   string
+	CohereBaseURL string // Cohere-compatible server; empty for api.cohere.com
 }
 
 // NewEmbedder creates an embedder based on the config
@@ -36,8 +59,37 @@ func NewEmbedder(cfg Config) (Embedder, error) {
 	case "ollama":
 		return NewOllamaEmbedder(cfg.OllamaURL, cfg.OllamaModel)
 	case "openai":
-		return NewOpenAIEmbedder(cfg.OpenAIKey, cfg.OpenAIModel)
+		return NewOpenAIEmbedder(cfg.OpenAIKey, cfg.OpenAIModel, cfg.OpenAIBaseURL, cfg.OpenAIDimensions)
+	case "tei":
+		return NewTEIEmbedder(cfg.TEIURL)
+	case "llamacpp":
+		return NewLlamaCppEmbedder(cfg.LlamaCppURL)
+	case "cohere":
+		return NewCohereEmbedder(cfg.CohereKey, cfg.CohereModel, cfg.CohereBaseURL)
+	case "local":
+		return NewLocalEmbedder(), nil
 	default:
 		return nil, nil // No embedder for keyword-only
 	}
 }
+
+// dimensions records the vector size seen in responses, so embedders whose
+// model size isn't known up front can report it after the first request
+type dimensions struct {
+	n atomic.Int64
+}
+
+// observe records the size of a returned vector
+func (d *dimensions) observe(vector []float32) {
+	if len(vector) > 0 {
+		d.n.Store(int64(len(vector)))
+	}
+}
+
+// get returns the observed size, or fallback if nothing has been observed
+func (d *dimensions) get(fallback int) int {
+	if n := d.n.Load(); n > 0 {
+		return int(n)
+	}
+	return fallback
+}
@@ -0,0 +1,98 @@
+package embeddings
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// postJSON sends body as JSON to url with retries and decodes the response
+// into out
+func postJSON(ctx context.Context, client *http.Client, policy RetryPolicy, url string, headers map[string]string, body, out interface{}) error {
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	resp, err := doWithRetry(ctx, client, policy, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		return req, nil
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// checkHealth confirms a self-hosted embedding server is reachable
+func checkHealth(client *http.Client, name, url string) error {
+	resp, err := client.Get(url)
+	if err != nil {
+		return fmt.Errorf("%s not running at %s: %w", name, url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned status %d from %s", name, resp.StatusCode, url)
+	}
+	return nil
+}
+
+// getJSON fetches url and decodes the response into out
+func getJSON(client *http.Client, url string, out interface{}) error {
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d from %s", resp.StatusCode, url)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// embedInBatches splits texts into requests of at most size texts, for APIs
+// that cap the batch size, and checks each returns one vector per text
+func embedInBatches(ctx context.Context, texts []string, size int, embed func(context.Context, []string) ([][]float32, error)) ([][]float32, error) {
+	embeddings := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += size {
+		end := start + size
+		if end > len(texts) {
+			end = len(texts)
+		}
+
+		vectors, err := embed(ctx, texts[start:end])
+		if err != nil {
+			return nil, err
+		}
+		if len(vectors) != end-start {
+			return nil, fmt.Errorf("server returned %d embeddings for %d texts", len(vectors), end-start)
+		}
+		for i, v := range vectors {
+			if len(v) == 0 {
+				return nil, fmt.Errorf("empty embedding returned for text %d", start+i)
+			}
+		}
+
+		embeddings = append(embeddings, vectors...)
+	}
+	return embeddings, nil
+}
@@ -0,0 +1,153 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// llamaCppMaxBatch keeps requests within a typical llama-server batch
+const llamaCppMaxBatch = 32
+
+// LlamaCppEmbedder implements Embedder using llama.cpp's server
+// (llama-server --embedding). The server hosts a single model.
+type LlamaCppEmbedder struct {
+	baseURL string
+	model   string
+	client  *http.Client
+	dims    dimensions
+	retry   RetryPolicy
+}
+
+// NewLlamaCppEmbedder creates a new llama.cpp embedder
+func NewLlamaCppEmbedder(baseURL string) (*LlamaCppEmbedder, error) {
+	if baseURL == "" {
+		baseURL = "http://localhost:8080"
+	}
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	if err := checkHealth(client, "llama.cpp server", baseURL+"/health"); err != nil {
+		return nil, err
+	}
+
+	return &LlamaCppEmbedder{
+		baseURL: baseURL,
+		model:   llamaCppModel(client, baseURL),
+		client:  client,
+		retry:   DefaultRetryPolicy,
+	}, nil
+}
+
+// llamaCppModel reads the served model from /props, or the OpenAI-compatible
+// /v1/models on servers without it, falling back to the URL
+func llamaCppModel(client *http.Client, baseURL string) string {
+	var props struct {
+		ModelPath string `json:"model_path"`
+	}
+	if err := getJSON(client, baseURL+"/props", &props); err == nil && props.ModelPath != "" {
+		return props.ModelPath
+	}
+
+	var models struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := getJSON(client, baseURL+"/v1/models", &models); err == nil && len(models.Data) > 0 && models.Data[0].ID != "" {
+		return models.Data[0].ID
+	}
+	return baseURL
+}
+
+// Embed generates an embedding for a single text
+func (l *LlamaCppEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := l.EmbedBatch(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// EmbedBatch generates embeddings for multiple texts
+func (l *LlamaCppEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings, err := embedInBatches(ctx, texts, llamaCppMaxBatch, func(ctx context.Context, batch []string) ([][]float32, error) {
+		reqBody := map[string]interface{}{
+			"content": batch,
+		}
+
+		var result json.RawMessage
+		if err := postJSON(ctx, l.client, l.retry, l.baseURL+"/embedding", nil, reqBody, &result); err != nil {
+			return nil, fmt.Errorf("llama.cpp: %w", err)
+		}
+		return parseLlamaCppEmbeddings(result, len(batch))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(embeddings) > 0 {
+		l.dims.observe(embeddings[0])
+	}
+	return embeddings, nil
+}
+
+// parseLlamaCppEmbeddings reads the /embedding response, which depends on the
+// server version: older servers return {"embedding": [...]} for a single
+// text, newer ones a list of {"index", "embedding"} where the embedding is
+// either a vector or a list holding one pooled vector
+func parseLlamaCppEmbeddings(data json.RawMessage, n int) ([][]float32, error) {
+	type item struct {
+		Index     int             `json:"index"`
+		Embedding json.RawMessage `json:"embedding"`
+	}
+
+	var items []item
+	if err := json.Unmarshal(data, &items); err != nil {
+		var single item
+		if err := json.Unmarshal(data, &single); err != nil {
+			return nil, fmt.Errorf("llama.cpp: unexpected response: %w", err)
+		}
+		items = []item{single}
+	}
+
+	embeddings := make([][]float32, n)
+	for _, it := range items {
+		if it.Index < 0 || it.Index >= n {
+			continue
+		}
+
+		var vector []float32
+		if err := json.Unmarshal(it.Embedding, &vector); err != nil {
+			var rows [][]float32
+			if err := json.Unmarshal(it.Embedding, &rows); err != nil {
+				return nil, fmt.Errorf("llama.cpp: unexpected embedding: %w", err)
+			}
+			if len(rows) != 1 {
+				return nil, fmt.Errorf("llama.cpp returned %d token embeddings per text; start llama-server with --pooling mean", len(rows))
+			}
+			vector = rows[0]
+		}
+		embeddings[it.Index] = vector
+	}
+
+	return embeddings, nil
+}
+
+// Dimensions returns the embedding dimension size, detected from the first response
+func (l *LlamaCppEmbedder) Dimensions() int {
+	return l.dims.get(0)
+}
+
+// Model returns the served model's path or alias
+func (l *LlamaCppEmbedder) Model() string {
+	return l.model
+}
+
+// Name returns the model this embedder uses
+func (l *LlamaCppEmbedder) Name() string {
+	return fmt.Sprintf("llamacpp/%s", l.model)
+}
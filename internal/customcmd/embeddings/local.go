@@ -0,0 +1,143 @@
+package embeddings
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// LocalModel names the local embedding scheme. Bump the version whenever the
+// features or weights change, so caches built with the old scheme are rebuilt.
+const LocalModel = "hashed-ngrams-v1"
+
+// localDims is the size of local embedding vectors
+const localDims = 1024
+
+// Feature weights: whole words carry the most meaning, adjacent word pairs
+// capture phrases like "roll back", and character n-grams match variants of
+// a word ("deploy", "deployment", "deploying") and survive typos
+const (
+	wordWeight   = 1.0
+	bigramWeight = 0.7
+	ngramWeight  = 0.3
+)
+
+// Character n-gram lengths taken from each word
+const (
+	minNgram = 3
+	maxNgram = 5
+)
+
+// localStopWords carry no meaning for matching
+var localStopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "can": true, "do": true, "for": true, "from": true,
+	"how": true, "i": true, "in": true, "is": true, "it": true, "me": true,
+	"my": true, "of": true, "on": true, "or": true, "please": true, "that": true,
+	"the": true, "this": true, "to": true, "want": true, "with": true, "you": true,
+}
+
+// LocalEmbedder implements Embedder in-process, without a model or service.
+// Texts are embedded by hashing their words, word pairs and character
+// n-grams into a fixed-size vector (the "hashing trick"), weighting repeated
+// features sublinearly. Similar wording lands close together; unlike a
+// trained model it knows nothing about synonyms.
+type LocalEmbedder struct{}
+
+// NewLocalEmbedder creates a new local embedder
+func NewLocalEmbedder() *LocalEmbedder {
+	return &LocalEmbedder{}
+}
+
+// Embed generates an embedding for a single text
+func (l *LocalEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return embedLocal(text), nil
+}
+
+// EmbedBatch generates embeddings for multiple texts
+func (l *LocalEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		embeddings[i] = embedLocal(text)
+	}
+	return embeddings, nil
+}
+
+// Dimensions returns the embedding dimension size
+func (l *LocalEmbedder) Dimensions() int {
+	return localDims
+}
+
+// Name returns the embedding scheme
+func (l *LocalEmbedder) Name() string {
+	return "local/" + LocalModel
+}
+
+// embedLocal hashes text's features into an L2-normalised vector
+func embedLocal(text string) []float32 {
+	features := make(map[string]float64)
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var prev string
+	for _, word := range words {
+		if localStopWords[word] {
+			prev = ""
+			continue
+		}
+
+		features["w:"+word] += wordWeight
+		if prev != "" {
+			features["b:"+prev+" "+word] += bigramWeight
+		}
+		prev = word
+
+		// Boundary markers let n-grams distinguish prefixes and suffixes
+		padded := []rune("<" + word + ">")
+		for n := minNgram; n <= maxNgram; n++ {
+			for i := 0; i+n <= len(padded); i++ {
+				features["c:"+string(padded[i:i+n])] += ngramWeight
+			}
+		}
+	}
+
+	vector := make([]float32, localDims)
+	for feature, weight := range features {
+		h := fnv.New64a()
+		h.Write([]byte(feature))
+		sum := h.Sum64()
+
+		// A hashed sign makes collisions cancel out on average
+		value := float32(1 + math.Log(weight))
+		if weight < 1 {
+			value = float32(weight)
+		}
+		if sum>>63 == 1 {
+			value = -value
+		}
+		vector[sum%localDims] += value
+	}
+
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v) * float64(v)
+	}
+	if norm > 0 {
+		scale := float32(1 / math.Sqrt(norm))
+		for i := range vector {
+			vector[i] *= scale
+		}
+	}
+
+	return vector
+}
@@ -0,0 +1,80 @@
+package embeddings
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func cosine(a, b []float32) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+func TestLocalEmbedder(t *testing.T) {
+	ctx := context.Background()
+	l := NewLocalEmbedder()
+
+	embed := func(text string) []float32 {
+		t.Helper()
+		v, err := l.Embed(ctx, text)
+		if err != nil {
+			t.Fatalf("Embed(%q) error = %v", text, err)
+		}
+		if len(v) != l.Dimensions() {
+			t.Fatalf("Embed(%q) returned %d dimensions, want %d", text, len(v), l.Dimensions())
+		}
+		return v
+	}
+
+	doc := embed("Restart a deployment and watch the rollout status")
+	tests := []struct {
+		closer, further string
+	}{
+		{"restart deployments", "backup the postgres database"},
+		{"rollout status of my deploy", "list files in a directory"},
+		{"restrat deployment", "show disk usage"}, // Typo
+	}
+	for _, tt := range tests {
+		near, far := cosine(doc, embed(tt.closer)), cosine(doc, embed(tt.further))
+		if near <= far {
+			t.Errorf("%q (%.3f) should be closer to the doc than %q (%.3f)", tt.closer, near, tt.further, far)
+		}
+	}
+
+	// Deterministic and normalised
+	a, b := embed("kubectl get pods"), embed("kubectl get pods")
+	if cosine(a, b) < 0.9999 {
+		t.Error("same text embedded differently")
+	}
+	var norm float64
+	for _, v := range a {
+		norm += float64(v) * float64(v)
+	}
+	if math.Abs(norm-1) > 1e-4 {
+		t.Errorf("vector norm = %.4f, want 1", math.Sqrt(norm))
+	}
+
+	// Stop words alone carry nothing
+	for _, v := range embed("please do it for me") {
+		if v != 0 {
+			t.Fatal("stop words produced a non-zero vector")
+		}
+	}
+
+	batch, err := l.EmbedBatch(ctx, []string{"kubectl get pods", "restart deployments"})
+	if err != nil {
+		t.Fatalf("EmbedBatch() error = %v", err)
+	}
+	if cosine(batch[0], a) < 0.9999 {
+		t.Error("EmbedBatch and Embed disagree")
+	}
+}
@@ -6,15 +6,25 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 )
 
+// ollamaConcurrency is how many requests an OllamaEmbedder keeps in flight,
+// across all of its callers. Ollama's /api/embeddings endpoint takes one text
+// at a time, so EmbedBatch fans out, and callers that embed batches in
+// parallel share the same limit rather than multiplying it.
+const ollamaConcurrency = 4
+
 // OllamaEmbedder implements Embedder using Ollama's local API
 type OllamaEmbedder struct {
 	baseURL string
 	model   string
 	client  *http.Client
-	dims    int
+	dims    dimensions
+	retry   RetryPolicy
+	slots   chan struct{} // Requests in flight, up to ollamaConcurrency
 }
 
 // NewOllamaEmbedder creates a new Ollama embedder
@@ -22,8 +32,9 @@ func NewOllamaEmbedder(baseURL, model string) (*OllamaEmbedder, error) {
 	if baseURL == "" {
 		baseURL = "http://localhost:11434"
 	}
+	baseURL = strings.TrimRight(baseURL, "/")
 	if model == "" {
-		model = "nomic-embed-text" // Default: 384 dimensions, fast
+		model = "nomic-embed-text" // Default: small and fast
 	}
 
 	// Test connection
@@ -38,17 +49,12 @@ func NewOllamaEmbedder(baseURL, model string) (*OllamaEmbedder, error) {
 		return nil, fmt.Errorf("ollama returned status %d", resp.StatusCode)
 	}
 
-	// Determine dimensions based on model
-	dims := 384 // nomic-embed-text
-	if model == "mxbai-embed-large" {
-		dims = 1024
-	}
-
 	return &OllamaEmbedder{
 		baseURL: baseURL,
 		model:   model,
 		client:  client,
-		dims:    dims,
+		retry:   DefaultRetryPolicy,
+		slots:   make(chan struct{}, ollamaConcurrency),
 	}, nil
 }
 
@@ -64,24 +70,28 @@ func (o *OllamaEmbedder) Embed(ctx context.Context, text string) ([]float32, err
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST",
-		o.baseURL+"/api/embeddings",
-		bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, err
+	select {
+	case o.slots <- struct{}{}:
+		defer func() { <-o.slots }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
 	}
-	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := o.client.Do(req)
+	resp, err := doWithRetry(ctx, o.client, o.retry, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST",
+			o.baseURL+"/api/embeddings",
+			bytes.NewReader(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, fmt.Errorf("ollama: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("ollama returned status %d", resp.StatusCode)
-	}
-
 	var result struct {
 		Embedding []float32 `json:"embedding"`
 	}
@@ -93,30 +103,73 @@ func (o *OllamaEmbedder) Embed(ctx context.Context, text string) ([]float32, err
 	if len(result.Embedding) == 0 {
 		return nil, fmt.Errorf("empty embedding returned")
 	}
+	o.dims.observe(result.Embedding)
 
 	return result.Embedding, nil
 }
 
-// EmbedBatch generates embeddings for multiple texts
+// EmbedBatch generates embeddings for multiple texts, keeping up to
+// ollamaConcurrency requests in flight between it and any other callers
 func (o *OllamaEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
 	embeddings := make([][]float32, len(texts))
 
-	// Ollama doesn't have native batch API, so we call individually
-	// Could optimize with goroutines if needed
-	for i, text := range texts {
-		emb, err := o.Embed(ctx, text)
-		if err != nil {
-			return nil, fmt.Errorf("failed to embed text %d: %w", i, err)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	next := make(chan int)
+
+	workers := ollamaConcurrency
+	if workers > len(texts) {
+		workers = len(texts)
+	}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				emb, err := o.Embed(ctx, texts[i])
+				if err != nil {
+					once.Do(func() {
+						firstErr = fmt.Errorf("failed to embed text %d: %w", i, err)
+						cancel()
+					})
+					continue
+				}
+				embeddings[i] = emb
+			}
+		}()
+	}
+
+feed:
+	for i := range texts {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			break feed
 		}
-		embeddings[i] = emb
+	}
+	close(next)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
 	return embeddings, nil
 }
 
-// Dimensions returns the embedding dimension size
+// Dimensions returns the embedding dimension size, detected from the first
+// response since Ollama models vary
 func (o *OllamaEmbedder) Dimensions() int {
-	return o.dims
+	return o.dims.get(0)
 }
 
 // Name returns the model name
@@ -4,22 +4,47 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 )
 
-// OpenAIEmbedder implements Embedder using OpenAI's API
+// openAIBaseURL is the default API endpoint
+const openAIBaseURL = "https://api.openai.com/v1"
+
+// openAIModelDims are the native sizes of OpenAI's embedding models
+var openAIModelDims = map[string]int{
+	"text-embedding-3-small": 1536,
+	"text-embedding-3-large": 3072,
+	"text-embedding-ada-002": 1536,
+}
+
+// OpenAIEmbedder implements Embedder using OpenAI's API, or any server that
+// implements its /embeddings endpoint
 type OpenAIEmbedder struct {
-	apiKey string
-	model  string
-	client *http.Client
-	dims   int
+	apiKey    string
+	model     string
+	baseURL   string
+	requested int // Vector size sent as "dimensions", 0 for the model's own
+	client    *http.Client
+	dims      dimensions
+	retry     RetryPolicy
 }
 
-// NewOpenAIEmbedder creates a new OpenAI embedder
-func NewOpenAIEmbedder(apiKey, model string) (*OpenAIEmbedder, error) {
-	if apiKey == "" {
+// NewOpenAIEmbedder creates a new OpenAI embedder. baseURL points it at an
+// OpenAI-compatible server instead of api.openai.com, and dims requests
+// shortened vectors from models that support it (text-embedding-3-*).
+func NewOpenAIEmbedder(apiKey, model, baseURL string, dims int) (*OpenAIEmbedder, error) {
+	if baseURL == "" {
+		baseURL = openAIBaseURL
+	}
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	// Self-hosted compatible servers often don't need a key
+	if apiKey == "" && baseURL == openAIBaseURL {
 		return nil, fmt.Errorf("OpenAI API key is required")
 	}
 
@@ -27,116 +52,70 @@ func NewOpenAIEmbedder(apiKey, model string) (*OpenAIEmbedder, error) {
 		model = "text-embedding-3-small" // Default: 1536 dims, $0.02/1M tokens
 	}
 
-	// Determine dimensions based on model
-	dims := 1536 // text-embedding-3-small
-	if model == "text-embedding-3-large" {
-		dims = 3072
-	} else if model == "text-embedding-ada-002" {
-		dims = 1536
+	requested := 0
+	if dims > 0 && strings.HasPrefix(model, "text-embedding-3") {
+		requested = dims
 	}
 
 	return &OpenAIEmbedder{
-		apiKey: apiKey,
-		model:  model,
-		client: &http.Client{Timeout: 30 * time.Second},
-		dims:   dims,
+		apiKey:    apiKey,
+		model:     model,
+		baseURL:   baseURL,
+		requested: requested,
+		client:    &http.Client{Timeout: 30 * time.Second},
+		retry:     DefaultRetryPolicy,
 	}, nil
 }
 
 // Embed generates an embedding for a single text
 func (o *OpenAIEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
-	reqBody := map[string]interface{}{
-		"model": o.model,
-		"input": text,
-	}
-
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST",
-		"https://api.openai.com/v1/embeddings",
-		bytes.NewBuffer(jsonData))
+	embeddings, err := o.EmbedBatch(ctx, []string{text})
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("Authorization", "Bearer "+o.apiKey)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := o.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		var errResp struct {
-			Error struct {
-				Message string `json:"message"`
-			} `json:"error"`
-		}
-		json.NewDecoder(resp.Body).Decode(&errResp)
-		return nil, fmt.Errorf("OpenAI API error (status %d): %s", resp.StatusCode, errResp.Error.Message)
-	}
-
-	var result struct {
-		Data []struct {
-			Embedding []float32 `json:"embedding"`
-		} `json:"data"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	if len(result.Data) == 0 || len(result.Data[0].Embedding) == 0 {
-		return nil, fmt.Errorf("empty embedding returned")
-	}
-
-	return result.Data[0].Embedding, nil
+	return embeddings[0], nil
 }
 
 // EmbedBatch generates embeddings for multiple texts
 func (o *OpenAIEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
 	// OpenAI supports batch embedding
 	reqBody := map[string]interface{}{
 		"model": o.model,
 		"input": texts,
 	}
+	if o.requested > 0 {
+		reqBody["dimensions"] = o.requested
+	}
 
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST",
-		"https://api.openai.com/v1/embeddings",
-		bytes.NewBuffer(jsonData))
+	endpoint, err := o.endpoint()
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("Authorization", "Bearer "+o.apiKey)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := o.client.Do(req)
+	resp, err := doWithRetry(ctx, o.client, o.retry, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		o.authorize(req)
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, fmt.Errorf("OpenAI API error: %w", openAIError(err))
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		var errResp struct {
-			Error struct {
-				Message string `json:"message"`
-			} `json:"error"`
-		}
-		json.NewDecoder(resp.Body).Decode(&errResp)
-		return nil, fmt.Errorf("OpenAI API error (status %d): %s", resp.StatusCode, errResp.Error.Message)
-	}
-
 	var result struct {
 		Data []struct {
 			Embedding []float32 `json:"embedding"`
@@ -151,17 +130,72 @@ func (o *OpenAIEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]fl
 	// Sort by index to maintain order
 	embeddings := make([][]float32, len(texts))
 	for _, item := range result.Data {
-		if item.Index < len(embeddings) {
+		if item.Index >= 0 && item.Index < len(embeddings) {
 			embeddings[item.Index] = item.Embedding
 		}
 	}
+	for i, emb := range embeddings {
+		if len(emb) == 0 {
+			return nil, fmt.Errorf("no embedding returned for text %d", i)
+		}
+	}
+	o.dims.observe(embeddings[0])
 
 	return embeddings, nil
 }
 
-// Dimensions returns the embedding dimension size
+// endpoint returns the embeddings URL, keeping any query string on the base
+// URL (Azure passes api-version that way)
+func (o *OpenAIEmbedder) endpoint() (string, error) {
+	u, err := url.Parse(o.baseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid base URL %q: %w", o.baseURL, err)
+	}
+	u.Path = strings.TrimRight(u.Path, "/") + "/embeddings"
+	return u.String(), nil
+}
+
+// authorize sets the API key header: Azure OpenAI expects api-key, everything
+// else a bearer token
+func (o *OpenAIEmbedder) authorize(req *http.Request) {
+	if o.apiKey == "" {
+		return
+	}
+	if strings.HasSuffix(req.URL.Hostname(), ".openai.azure.com") {
+		req.Header.Set("api-key", o.apiKey)
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+o.apiKey)
+}
+
+// openAIError replaces a StatusError's raw body with the API's error message
+func openAIError(err error) error {
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		return err
+	}
+
+	var errResp struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal([]byte(statusErr.Message), &errResp) == nil && errResp.Error.Message != "" {
+		statusErr.Message = errResp.Error.Message
+	}
+	return err
+}
+
+// Dimensions returns the embedding dimension size: what the server returned,
+// else the requested size, else the model's native size on api.openai.com
 func (o *OpenAIEmbedder) Dimensions() int {
-	return o.dims
+	if o.requested > 0 {
+		return o.dims.get(o.requested)
+	}
+	if o.baseURL == openAIBaseURL {
+		return o.dims.get(openAIModelDims[o.model])
+	}
+	return o.dims.get(0)
 }
 
 // Name returns the model name
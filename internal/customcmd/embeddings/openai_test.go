@@ -0,0 +1,97 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenAICompatibleServer(t *testing.T) {
+	var gotPath, gotQuery, gotAuth, gotAPIKey string
+	var gotBody map[string]interface{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotQuery = r.URL.Path, r.URL.RawQuery
+		gotAuth, gotAPIKey = r.Header.Get("Authorization"), r.Header.Get("api-key")
+		json.NewDecoder(r.Body).Decode(&gotBody)
+		w.Write([]byte(`{"data": [{"embedding": [0.1, 0.2, 0.3], "index": 0}]}`))
+	}))
+	defer server.Close()
+
+	// No key needed for a self-hosted server, and the size is unknown up front
+	o, err := NewOpenAIEmbedder("", "bge-small-en", server.URL+"/v1/?api-version=2024-02-01", 512)
+	if err != nil {
+		t.Fatalf("NewOpenAIEmbedder() error = %v", err)
+	}
+	if got := o.Dimensions(); got != 0 {
+		t.Errorf("Dimensions() before any request = %d, want 0", got)
+	}
+
+	if _, err := o.Embed(context.Background(), "hello"); err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+
+	if gotPath != "/v1/embeddings" || gotQuery != "api-version=2024-02-01" {
+		t.Errorf("request URL = %s?%s, want /v1/embeddings?api-version=2024-02-01", gotPath, gotQuery)
+	}
+	if gotAuth != "" || gotAPIKey != "" {
+		t.Errorf("sent credentials without a key: Authorization=%q api-key=%q", gotAuth, gotAPIKey)
+	}
+	if _, ok := gotBody["dimensions"]; ok {
+		t.Errorf("sent dimensions for a model that doesn't support them: %v", gotBody)
+	}
+	if got := o.Dimensions(); got != 3 {
+		t.Errorf("Dimensions() after a response = %d, want 3", got)
+	}
+}
+
+func TestOpenAIRequestedDimensions(t *testing.T) {
+	var gotBody map[string]interface{}
+	var gotAuth string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&gotBody)
+		w.Write([]byte(`{"data": [{"embedding": [0.1, 0.2], "index": 0}]}`))
+	}))
+	defer server.Close()
+
+	o, err := NewOpenAIEmbedder("sk-test", "text-embedding-3-large", server.URL, 256)
+	if err != nil {
+		t.Fatalf("NewOpenAIEmbedder() error = %v", err)
+	}
+	if got := o.Dimensions(); got != 256 {
+		t.Errorf("Dimensions() before any request = %d, want the requested 256", got)
+	}
+
+	if _, err := o.Embed(context.Background(), "hello"); err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+	if gotBody["dimensions"] != float64(256) {
+		t.Errorf("request dimensions = %v, want 256", gotBody["dimensions"])
+	}
+	if gotAuth != "Bearer sk-test" {
+		t.Errorf("Authorization = %q, want bearer token", gotAuth)
+	}
+
+	// What the server actually returned wins
+	if got := o.Dimensions(); got != 2 {
+		t.Errorf("Dimensions() after a response = %d, want 2", got)
+	}
+}
+
+func TestOpenAIRequiresKeyForDefaultEndpoint(t *testing.T) {
+	if _, err := NewOpenAIEmbedder("", "", "", 0); err == nil {
+		t.Error("NewOpenAIEmbedder() without a key for api.openai.com succeeded")
+	}
+
+	o, err := NewOpenAIEmbedder("sk-test", "text-embedding-3-small", "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := o.Dimensions(); got != 1536 {
+		t.Errorf("Dimensions() = %d, want 1536", got)
+	}
+}
@@ -0,0 +1,306 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// textVector embeds text as [len, first byte] so tests can check ordering
+func textVector(text string) []float32 {
+	return []float32{float32(len(text)), float32(text[0])}
+}
+
+// healthy answers /health and passes everything else to handler
+func healthy(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+func testTexts(n int) []string {
+	texts := make([]string, n)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("%c%s", 'a'+i%26, strings.Repeat("x", i))
+	}
+	return texts
+}
+
+func checkVectors(t *testing.T, texts []string, vectors [][]float32) {
+	t.Helper()
+	if len(vectors) != len(texts) {
+		t.Fatalf("got %d vectors for %d texts", len(vectors), len(texts))
+	}
+	for i, text := range texts {
+		want := textVector(text)
+		if len(vectors[i]) != 2 || vectors[i][0] != want[0] || vectors[i][1] != want[1] {
+			t.Errorf("vector %d = %v, want %v", i, vectors[i], want)
+		}
+	}
+}
+
+func TestTEIEmbedder(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(healthy(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/info" {
+			fmt.Fprint(w, `{"model_id": "BAAI/bge-small-en-v1.5", "model_sha": "5c38ec7", "max_input_length": 512}`)
+			return
+		}
+		if r.URL.Path != "/embed" {
+			http.NotFound(w, r)
+			return
+		}
+		requests++
+
+		var req struct {
+			Inputs   []string `json:"inputs"`
+			Truncate bool     `json:"truncate"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if len(req.Inputs) > teiMaxBatch {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		if !req.Truncate {
+			t.Error("request doesn't ask TEI to truncate long inputs")
+		}
+
+		var vectors [][]float32
+		for _, input := range req.Inputs {
+			vectors = append(vectors, textVector(input))
+		}
+		json.NewEncoder(w).Encode(vectors)
+	}))
+	defer server.Close()
+
+	tei, err := NewTEIEmbedder(server.URL + "/")
+	if err != nil {
+		t.Fatalf("NewTEIEmbedder() error = %v", err)
+	}
+
+	texts := testTexts(40)
+	vectors, err := tei.EmbedBatch(context.Background(), texts)
+	if err != nil {
+		t.Fatalf("EmbedBatch() error = %v", err)
+	}
+	checkVectors(t, texts, vectors)
+
+	if requests != 2 {
+		t.Errorf("%d requests for 40 texts, want 2", requests)
+	}
+	if got := tei.Dimensions(); got != 2 {
+		t.Errorf("Dimensions() = %d, want 2", got)
+	}
+	if got := tei.Name(); got != "tei/BAAI/bge-small-en-v1.5@5c38ec7" {
+		t.Errorf("Name() = %s, want the served model", got)
+	}
+}
+
+func TestServedModel(t *testing.T) {
+	tests := []struct {
+		name   string
+		create func(url string) (Embedder, error)
+		routes map[string]string
+		want   string // With {url} for the server URL
+	}{
+		{"tei", func(url string) (Embedder, error) { return NewTEIEmbedder(url) },
+			map[string]string{"/info": `{"model_id": "nomic-ai/nomic-embed-text-v1.5"}`}, "nomic-ai/nomic-embed-text-v1.5"},
+		{"tei without /info", func(url string) (Embedder, error) { return NewTEIEmbedder(url) },
+			nil, "{url}"},
+		{"llama.cpp props", func(url string) (Embedder, error) { return NewLlamaCppEmbedder(url) },
+			map[string]string{"/props": `{"model_path": "/models/nomic-embed-text-v1.5.Q8_0.gguf"}`}, "/models/nomic-embed-text-v1.5.Q8_0.gguf"},
+		{"llama.cpp models", func(url string) (Embedder, error) { return NewLlamaCppEmbedder(url) },
+			map[string]string{"/v1/models": `{"object": "list", "data": [{"id": "nomic-embed"}]}`}, "nomic-embed"},
+		{"llama.cpp unknown", func(url string) (Embedder, error) { return NewLlamaCppEmbedder(url) },
+			nil, "{url}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(healthy(func(w http.ResponseWriter, r *http.Request) {
+				body, ok := tt.routes[r.URL.Path]
+				if !ok {
+					http.NotFound(w, r)
+					return
+				}
+				fmt.Fprint(w, body)
+			}))
+			defer server.Close()
+
+			embedder, err := tt.create(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			served, ok := embedder.(ServedModel)
+			if !ok {
+				t.Fatalf("%T doesn't report its model", embedder)
+			}
+			if want := strings.ReplaceAll(tt.want, "{url}", server.URL); served.Model() != want {
+				t.Errorf("Model() = %s, want %s", served.Model(), want)
+			}
+		})
+	}
+}
+
+func TestTEIEmbedderNotRunning(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	if _, err := NewTEIEmbedder(server.URL); err == nil {
+		t.Error("NewTEIEmbedder() succeeded without a server")
+	}
+}
+
+func TestLlamaCppEmbedder(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(w http.ResponseWriter, texts []string)
+	}{
+		{"pooled list", func(w http.ResponseWriter, texts []string) {
+			var items []map[string]interface{}
+			for i, text := range texts {
+				items = append(items, map[string]interface{}{"index": i, "embedding": [][]float32{textVector(text)}})
+			}
+			json.NewEncoder(w).Encode(items)
+		}},
+		{"flat list", func(w http.ResponseWriter, texts []string) {
+			var items []map[string]interface{}
+			// Out of order
+			for i := len(texts) - 1; i >= 0; i-- {
+				items = append(items, map[string]interface{}{"index": i, "embedding": textVector(texts[i])})
+			}
+			json.NewEncoder(w).Encode(items)
+		}},
+		{"legacy object", func(w http.ResponseWriter, texts []string) {
+			json.NewEncoder(w).Encode(map[string]interface{}{"embedding": textVector(texts[0])})
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(healthy(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/embedding" {
+					http.NotFound(w, r)
+					return
+				}
+				var req struct {
+					Content []string `json:"content"`
+				}
+				json.NewDecoder(r.Body).Decode(&req)
+				tt.write(w, req.Content)
+			}))
+			defer server.Close()
+
+			llama, err := NewLlamaCppEmbedder(server.URL)
+			if err != nil {
+				t.Fatalf("NewLlamaCppEmbedder() error = %v", err)
+			}
+
+			texts := testTexts(3)
+			if tt.name == "legacy object" {
+				texts = texts[:1]
+			}
+			vectors, err := llama.EmbedBatch(context.Background(), texts)
+			if err != nil {
+				t.Fatalf("EmbedBatch() error = %v", err)
+			}
+			checkVectors(t, texts, vectors)
+		})
+	}
+}
+
+func TestLlamaCppEmbedderWithoutPooling(t *testing.T) {
+	server := httptest.NewServer(healthy(func(w http.ResponseWriter, r *http.Request) {
+		// One vector per token
+		fmt.Fprint(w, `[{"index": 0, "embedding": [[0.1, 0.2], [0.3, 0.4]]}]`)
+	}))
+	defer server.Close()
+
+	llama, err := NewLlamaCppEmbedder(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = llama.Embed(context.Background(), "hello world")
+	if err == nil || !strings.Contains(err.Error(), "--pooling") {
+		t.Errorf("Embed() error = %v, want a hint about --pooling", err)
+	}
+}
+
+func TestCohereEmbedder(t *testing.T) {
+	var requests []string // input_type of each request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embed" {
+			http.NotFound(w, r)
+			return
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer co-test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"message": "invalid api token"}`)
+			return
+		}
+
+		var req struct {
+			Model     string   `json:"model"`
+			Texts     []string `json:"texts"`
+			InputType string   `json:"input_type"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if len(req.Texts) > cohereMaxBatch {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		requests = append(requests, req.InputType)
+
+		var vectors [][]float32
+		for _, text := range req.Texts {
+			vectors = append(vectors, textVector(text))
+		}
+		// Queries get the older bare-list shape, documents the typed one
+		if req.InputType == "search_query" {
+			json.NewEncoder(w).Encode(map[string]interface{}{"embeddings": vectors})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"embeddings": map[string]interface{}{"float": vectors}})
+	}))
+	defer server.Close()
+
+	cohere, err := NewCohereEmbedder("co-test", "", server.URL)
+	if err != nil {
+		t.Fatalf("NewCohereEmbedder() error = %v", err)
+	}
+
+	texts := testTexts(100)
+	vectors, err := cohere.EmbedBatch(context.Background(), texts)
+	if err != nil {
+		t.Fatalf("EmbedBatch() error = %v", err)
+	}
+	checkVectors(t, texts, vectors)
+
+	query, err := cohere.Embed(context.Background(), "query")
+	if err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+	checkVectors(t, []string{"query"}, [][]float32{query})
+
+	want := []string{"search_document", "search_document", "search_query"}
+	if fmt.Sprint(requests) != fmt.Sprint(want) {
+		t.Errorf("input types = %v, want %v", requests, want)
+	}
+
+	bad, err := NewCohereEmbedder("wrong", "", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bad.Embed(context.Background(), "query"); err == nil || !strings.Contains(err.Error(), "invalid api token") {
+		t.Errorf("Embed() with a bad key error = %v, want the API's message", err)
+	}
+}
@@ -0,0 +1,296 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fastRetry keeps backoff short so tests don't wait on it
+var fastRetry = RetryPolicy{MaxAttempts: 4, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Second}
+
+func newTestOpenAI(t *testing.T, handler http.HandlerFunc) *OpenAIEmbedder {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	o, err := NewOpenAIEmbedder("test-key", "", server.URL, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.retry = fastRetry
+	return o
+}
+
+// writeOpenAIEmbeddings answers an embeddings request with one vector per input
+func writeOpenAIEmbeddings(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Input []string `json:"input"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+
+	type item struct {
+		Embedding []float32 `json:"embedding"`
+		Index     int       `json:"index"`
+	}
+	var resp struct {
+		Data []item `json:"data"`
+	}
+	// Out of order, as the API doesn't promise ordering
+	for i := len(req.Input) - 1; i >= 0; i-- {
+		resp.Data = append(resp.Data, item{Embedding: []float32{float32(len(req.Input[i]))}, Index: i})
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func TestOpenAIRetriesRateLimitsAndServerErrors(t *testing.T) {
+	var attempts int32
+	o := newTestOpenAI(t, func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&attempts, 1) {
+		case 1:
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			writeOpenAIEmbeddings(w, r)
+		}
+	})
+
+	start := time.Now()
+	vectors, err := o.EmbedBatch(context.Background(), []string{"a", "bb", "ccc"})
+	if err != nil {
+		t.Fatalf("EmbedBatch() error = %v", err)
+	}
+
+	if got := atomic.LoadInt32(&attempts); got != 3 {
+		t.Errorf("attempts = %d, want 3", got)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("Retry-After ignored: retried after %v", elapsed)
+	}
+	for i, v := range vectors {
+		if want := float32(i + 1); v[0] != want {
+			t.Errorf("vector %d = %v, want [%v]", i, v, want)
+		}
+	}
+}
+
+func TestOpenAIDoesNotRetryClientErrors(t *testing.T) {
+	var attempts int32
+	o := newTestOpenAI(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"error": {"message": "Incorrect API key provided"}}`)
+	})
+
+	_, err := o.Embed(context.Background(), "text")
+	if err == nil {
+		t.Fatal("Embed() succeeded, want error")
+	}
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("error = %v, want a 401 StatusError", err)
+	}
+	if !strings.Contains(err.Error(), "Incorrect API key provided") {
+		t.Errorf("error = %v, want the API's message", err)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 1 {
+		t.Errorf("attempts = %d, want 1", got)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	var attempts int32
+	o := newTestOpenAI(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	if _, err := o.Embed(context.Background(), "text"); err == nil {
+		t.Fatal("Embed() succeeded, want error")
+	}
+	if got := atomic.LoadInt32(&attempts); got != int32(fastRetry.MaxAttempts) {
+		t.Errorf("attempts = %d, want %d", got, fastRetry.MaxAttempts)
+	}
+}
+
+func TestRetryStopsOnCancel(t *testing.T) {
+	o := newTestOpenAI(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	o.retry.MaxDelay = time.Minute
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := o.Embed(ctx, "text")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("waited %v after the context was done", elapsed)
+	}
+}
+
+func TestOllamaEmbedBatchConcurrency(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		inFlight int
+		maxSeen  int
+		failed   = make(map[string]bool)
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/tags" {
+			fmt.Fprint(w, `{"models": []}`)
+			return
+		}
+
+		var req struct {
+			Prompt string `json:"prompt"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		mu.Lock()
+		inFlight++
+		if inFlight > maxSeen {
+			maxSeen = inFlight
+		}
+		// Fail each text once so every request goes through a retry
+		first := !failed[req.Prompt]
+		failed[req.Prompt] = true
+		mu.Unlock()
+
+		defer func() {
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+		}()
+
+		time.Sleep(10 * time.Millisecond)
+		if first {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string][]float32{"embedding": {float32(len(req.Prompt))}})
+	}))
+	defer server.Close()
+
+	o, err := NewOllamaEmbedder(server.URL, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.retry = fastRetry
+
+	texts := make([]string, 12)
+	for i := range texts {
+		texts[i] = strings.Repeat("x", i+1)
+	}
+
+	vectors, err := o.EmbedBatch(context.Background(), texts)
+	if err != nil {
+		t.Fatalf("EmbedBatch() error = %v", err)
+	}
+	for i, v := range vectors {
+		if want := float32(i + 1); len(v) != 1 || v[0] != want {
+			t.Errorf("vector %d = %v, want [%v]", i, v, want)
+		}
+	}
+
+	if maxSeen > ollamaConcurrency {
+		t.Errorf("%d requests in flight, want at most %d", maxSeen, ollamaConcurrency)
+	}
+	if maxSeen < 2 {
+		t.Errorf("requests weren't concurrent")
+	}
+}
+
+func TestOllamaEmbedderSharesConcurrency(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/tags" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		var req struct {
+			Prompt string `json:"prompt"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		json.NewEncoder(w).Encode(map[string]interface{}{"embedding": textVector(req.Prompt)})
+	}))
+	defer server.Close()
+
+	e, err := NewOllamaEmbedder(server.URL, "nomic-embed-text")
+	if err != nil {
+		t.Fatalf("NewOllamaEmbedder() error = %v", err)
+	}
+
+	// Parallel batches, as the indexer sends them, share one request limit
+	texts := testTexts(8)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			vectors, err := e.EmbedBatch(context.Background(), texts)
+			if err != nil {
+				t.Errorf("EmbedBatch() error = %v", err)
+				return
+			}
+			checkVectors(t, texts, vectors)
+		}()
+	}
+	wg.Wait()
+
+	if got := peak.Load(); got > ollamaConcurrency {
+		t.Errorf("%d requests in flight, want at most %d", got, ollamaConcurrency)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"", 0, false},
+		{"3", 3 * time.Second, true},
+		{"soon", 0, false},
+		{"Wed, 21 Oct 2015 07:28:00 GMT", 0, true}, // In the past
+	}
+
+	for _, tt := range tests {
+		got, ok := retryAfter(tt.value)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("retryAfter(%q) = %v, %v; want %v, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	future := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	if got, ok := retryAfter(future); !ok || got < 59*time.Minute {
+		t.Errorf("retryAfter(%q) = %v, %v; want about an hour", future, got, ok)
+	}
+}
@@ -0,0 +1,106 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// teiMaxBatch matches TEI's default --max-client-batch-size
+const teiMaxBatch = 32
+
+// TEIEmbedder implements Embedder using a Hugging Face Text Embeddings
+// Inference server. The server hosts a single model, chosen when it starts.
+type TEIEmbedder struct {
+	baseURL string
+	model   string
+	client  *http.Client
+	dims    dimensions
+	retry   RetryPolicy
+}
+
+// NewTEIEmbedder creates a new TEI embedder
+func NewTEIEmbedder(baseURL string) (*TEIEmbedder, error) {
+	if baseURL == "" {
+		baseURL = "http://localhost:8080"
+	}
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	if err := checkHealth(client, "text-embeddings-inference", baseURL+"/health"); err != nil {
+		return nil, err
+	}
+
+	return &TEIEmbedder{
+		baseURL: baseURL,
+		model:   teiModel(client, baseURL),
+		client:  client,
+		retry:   DefaultRetryPolicy,
+	}, nil
+}
+
+// teiModel reads the served model's ID and revision from /info, falling
+// back to the URL
+func teiModel(client *http.Client, baseURL string) string {
+	var info struct {
+		ModelID  string `json:"model_id"`
+		ModelSHA string `json:"model_sha"`
+	}
+	if err := getJSON(client, baseURL+"/info", &info); err != nil || info.ModelID == "" {
+		return baseURL
+	}
+	if info.ModelSHA != "" {
+		return info.ModelID + "@" + info.ModelSHA
+	}
+	return info.ModelID
+}
+
+// Embed generates an embedding for a single text
+func (t *TEIEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := t.EmbedBatch(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// EmbedBatch generates embeddings for multiple texts
+func (t *TEIEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings, err := embedInBatches(ctx, texts, teiMaxBatch, func(ctx context.Context, batch []string) ([][]float32, error) {
+		reqBody := map[string]interface{}{
+			"inputs":   batch,
+			"truncate": true, // Long sections are cut to the model's limit rather than rejected
+		}
+
+		var result [][]float32
+		if err := postJSON(ctx, t.client, t.retry, t.baseURL+"/embed", nil, reqBody, &result); err != nil {
+			return nil, fmt.Errorf("tei: %w", err)
+		}
+		return result, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(embeddings) > 0 {
+		t.dims.observe(embeddings[0])
+	}
+	return embeddings, nil
+}
+
+// Dimensions returns the embedding dimension size, detected from the first response
+func (t *TEIEmbedder) Dimensions() int {
+	return t.dims.get(0)
+}
+
+// Model returns the served model's ID
+func (t *TEIEmbedder) Model() string {
+	return t.model
+}
+
+// Name returns the model this embedder uses
+func (t *TEIEmbedder) Name() string {
+	return fmt.Sprintf("tei/%s", t.model)
+}
@@ -0,0 +1,275 @@
+package customcmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Where GatherHelp reads a tool's documentation from
+const (
+	HelpFromAuto = "auto" // --help, falling back to the man page
+	HelpFromHelp = "help" // --help output of the tool and its subcommands
+	HelpFromMan  = "man"  // The man page
+)
+
+const (
+	// helpTimeout bounds each --help or man invocation
+	helpTimeout = 5 * time.Second
+
+	// maxHelpChars caps the reference text handed to the agent
+	maxHelpChars = 48000
+
+	// maxSubcommands caps how many subcommands are read per command
+	maxSubcommands = 40
+
+	// maxHelpRuns caps how many times a tool is run to read its help
+	maxHelpRuns = 50
+)
+
+var (
+	ansiRe       = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+	overstrikeRe = regexp.MustCompile(`.\x08`)
+
+	// A header introducing a list of subcommands: "Available Commands:",
+	// "Basic Commands (Beginner):", "COMMANDS", "SUBCOMMANDS:"
+	commandsHeaderRe = regexp.MustCompile(`(?i)^[a-z ()-]*commands?\b[^:]*:?$`)
+
+	// A subcommand entry: indented name, optional aliases, then two spaces
+	// or a tab before its description (or nothing)
+	subcommandRe = regexp.MustCompile(`^(?: {1,8}|\t)([a-z][a-z0-9_-]*)(?:,\s*[a-z0-9_-]+)*(?:\s{2,}|\t|\s*$)`)
+)
+
+// HelpSection is the documentation of one command or subcommand
+type HelpSection struct {
+	Command string // e.g. "kubectl get"
+	Text    string
+}
+
+// HelpText is the documentation gathered for a tool
+type HelpText struct {
+	Binary    string // Base name of the tool, e.g. "kubectl"
+	Source    string // HelpFromHelp or HelpFromMan
+	Sections  []HelpSection
+	Truncated bool // Some subcommands were left out to stay under the size cap
+}
+
+// String renders the sections as one reference document
+func (h *HelpText) String() string {
+	var b strings.Builder
+	for _, section := range h.Sections {
+		fmt.Fprintf(&b, "### %s\n\n%s\n\n", section.Command, section.Text)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// GatherHelp collects a tool's documentation to generate a command doc
+// from. With HelpFromHelp it runs `<binary> --help` and then reads the help
+// of the subcommands it lists, up to depth levels deep; with HelpFromMan it
+// reads the man page. HelpFromAuto uses --help and falls back to the man
+// page when the tool has no usable help.
+//
+// Subcommand help is read with `<binary> help <sub>` when the tool lists a
+// help command, since a subcommand that ignores --help would otherwise run.
+// -h is never tried: for tools like df, sort and shutdown it's a real option.
+func GatherHelp(ctx context.Context, binary, from string, depth int) (*HelpText, error) {
+	path, err := exec.LookPath(binary)
+	if err != nil {
+		return nil, fmt.Errorf("%s not found in PATH", binary)
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+	switch from {
+	case HelpFromHelp, HelpFromAuto, "":
+		help, err := gatherCommandHelp(ctx, path, name, depth)
+		if err == nil || from == HelpFromHelp {
+			return help, err
+		}
+		manHelp, manErr := gatherManPage(ctx, name)
+		if manErr != nil {
+			return nil, fmt.Errorf("%v; man page: %v", err, manErr)
+		}
+		return manHelp, nil
+	case HelpFromMan:
+		return gatherManPage(ctx, name)
+	default:
+		return nil, fmt.Errorf("unknown help source %q (use %s, %s or %s)", from, HelpFromAuto, HelpFromHelp, HelpFromMan)
+	}
+}
+
+// gatherCommandHelp walks a tool's help output breadth first, so the
+// top-level commands make it in before the size cap is reached
+func gatherCommandHelp(ctx context.Context, path, name string, depth int) (*HelpText, error) {
+	help := &HelpText{Binary: name, Source: HelpFromHelp}
+	seen := make(map[string]bool) // Help texts already included, for tools that ignore unknown subcommands
+	size, runs := 0, 0
+	helpCommand := false // Whether the tool has a help subcommand to ask instead of passing --help
+
+	queue := [][]string{nil}
+	for len(queue) > 0 {
+		args := queue[0]
+		queue = queue[1:]
+
+		if runs == maxHelpRuns {
+			help.Truncated = true
+			break
+		}
+		runs++
+
+		helpArgs := append(append([]string{}, args...), "--help")
+		if len(args) > 0 && helpCommand {
+			helpArgs = append([]string{"help"}, args...)
+		}
+		text, err := runHelp(ctx, path, helpArgs)
+		if err != nil || seen[text] {
+			if len(args) == 0 {
+				return nil, fmt.Errorf("%s --help printed nothing usable", name)
+			}
+			continue
+		}
+		seen[text] = true
+
+		if size+len(text) > maxHelpChars {
+			help.Truncated = true
+			continue
+		}
+		size += len(text)
+		help.Sections = append(help.Sections, HelpSection{
+			Command: strings.Join(append([]string{name}, args...), " "),
+			Text:    text,
+		})
+
+		if len(args) < depth {
+			subcommands, hasHelp := parseSubcommands(text)
+			if len(args) == 0 {
+				helpCommand = hasHelp
+			}
+			if len(subcommands) > maxSubcommands {
+				subcommands = subcommands[:maxSubcommands]
+				help.Truncated = true
+			}
+			for _, sub := range subcommands {
+				queue = append(queue, append(append([]string{}, args...), sub))
+			}
+		}
+	}
+
+	return help, nil
+}
+
+// runHelp returns the cleaned-up output of a command that asks for help.
+// Many tools print help to stderr and exit non-zero, so failed output counts
+// too if it shows a usage line (rather than, say, git failing to open a man
+// page for --help).
+func runHelp(ctx context.Context, path string, args []string) (string, error) {
+	output, err := runReference(ctx, path, args...)
+	text := cleanHelp(output)
+	if strings.Count(text, "\n") < 1 {
+		return "", fmt.Errorf("no help output")
+	}
+	if err != nil && !strings.Contains(strings.ToLower(text), "usage") {
+		return "", fmt.Errorf("no help output")
+	}
+	return text, nil
+}
+
+// gatherManPage reads the man page for a tool
+func gatherManPage(ctx context.Context, name string) (*HelpText, error) {
+	if _, err := exec.LookPath("man"); err != nil {
+		return nil, fmt.Errorf("man is not installed")
+	}
+
+	output, err := runReference(ctx, "man", name)
+	text := cleanHelp(output)
+	if err != nil || text == "" {
+		return nil, fmt.Errorf("no man page for %s", name)
+	}
+
+	help := &HelpText{Binary: name, Source: HelpFromMan}
+	if len(text) > maxHelpChars {
+		text = text[:maxHelpChars]
+		help.Truncated = true
+	}
+	help.Sections = []HelpSection{{Command: name, Text: text}}
+	return help, nil
+}
+
+// runReference runs a command that prints documentation, with paging and
+// colour turned off, and returns its combined output
+func runReference(ctx context.Context, name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, helpTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Env = append(os.Environ(), "PAGER=cat", "MANPAGER=cat", "MANWIDTH=100", "NO_COLOR=1", "TERM=dumb")
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	err := cmd.Run()
+	return output.String(), err
+}
+
+// cleanHelp strips terminal formatting from help output
+func cleanHelp(text string) string {
+	text = ansiRe.ReplaceAllString(text, "")
+	text = overstrikeRe.ReplaceAllString(text, "")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// parseSubcommands finds the subcommands listed in help output, under
+// headers like "Available Commands:" or "COMMANDS", and whether a help
+// command is among them. A list runs until the next unindented header that
+// isn't about commands (e.g. "Flags:").
+func parseSubcommands(help string) (subcommands []string, helpCommand bool) {
+	seen := make(map[string]bool)
+	inList := false
+
+	for _, line := range strings.Split(help, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+
+		unindented := trimmed == line
+		if unindented {
+			if commandsHeaderRe.MatchString(trimmed) {
+				inList = true
+			} else if strings.HasSuffix(trimmed, ":") || trimmed == strings.ToUpper(trimmed) {
+				inList = false
+			}
+			continue
+		}
+		if !inList {
+			continue
+		}
+
+		m := subcommandRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		name := m[1]
+		if name == "help" {
+			helpCommand = true
+		}
+		if name == "help" || name == "completion" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		subcommands = append(subcommands, name)
+	}
+
+	return subcommands, helpCommand
+}
@@ -0,0 +1,143 @@
+package customcmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParseSubcommands(t *testing.T) {
+	tests := []struct {
+		name     string
+		help     string
+		want     []string
+		wantHelp bool
+	}{
+		{"cobra", `kubectl controls the Kubernetes cluster manager.
+
+Basic Commands (Beginner):
+  create          Create a resource from a file or from stdin
+  expose          Take a replication controller, service, deployment or pod and expose it as a new
+                  Kubernetes service
+
+Basic Commands (Intermediate):
+  get             Display one or many resources
+  completion      Output shell completion code
+
+Flags:
+  -h, --help   help for kubectl
+  version      not a subcommand
+`, []string{"create", "expose", "get"}, false},
+		{"git", `usage: git [-v | --version] [-h | --help] <command> [<args>]
+
+These are common Git commands used in various situations:
+
+start a working area (see also: git help tutorial)
+   clone     Clone a repository into a new directory
+   init      Create an empty Git repository or reinitialize an existing one
+
+grow, mark and tweak your common history
+   commit    Record changes to the repository
+`, []string{"clone", "init", "commit"}, false},
+		{"man style", `NAME
+       tool - does things
+
+COMMANDS
+   sync, s   Sync everything
+   help      Show help
+
+OPTIONS
+   --force   Don't ask
+`, []string{"sync"}, true},
+		{"no commands", "Usage: cat [OPTION]... [FILE]...\n  -A, --show-all   equivalent to -vET\n", nil, false},
+	}
+
+	for _, tt := range tests {
+		got, gotHelp := parseSubcommands(tt.help)
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) || gotHelp != tt.wantHelp {
+			t.Errorf("%s: parseSubcommands() = %v, %v, want %v, %v", tt.name, got, gotHelp, tt.want, tt.wantHelp)
+		}
+	}
+}
+
+func TestGatherHelp(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as the tool")
+	}
+
+	// A tool with two subcommands, one of which has its own, and help on
+	// stderr. -h would run it for real.
+	dir := t.TempDir()
+	ran := filepath.Join(dir, "ran")
+	script := `#!/bin/sh
+case "$*" in
+  *-h) touch "` + ran + `" ;;
+  "--help") printf 'Usage: fake\n\nCommands:\n  db     Manage databases\n  user   Manage users\n' >&2; exit 1 ;;
+  "db --help") printf 'Usage: fake db\n\nCommands:\n  backup   Back up a database\n' ;;
+  "db backup --help") printf 'Usage: fake db backup <name>\n\nFlags:\n  --gzip   Compress\n' ;;
+  "user --help") printf '\033[1mUsage:\033[0m fake user\n\nManage users\n' ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(dir, "fake"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	help, err := GatherHelp(context.Background(), "fake", HelpFromHelp, 2)
+	if err != nil {
+		t.Fatalf("GatherHelp() error = %v", err)
+	}
+	var commands []string
+	for _, section := range help.Sections {
+		commands = append(commands, section.Command)
+	}
+	want := "fake,fake db,fake user,fake db backup"
+	if strings.Join(commands, ",") != want {
+		t.Errorf("sections = %v, want %s", commands, want)
+	}
+	if strings.Contains(help.String(), "\033") {
+		t.Error("help still contains terminal escapes")
+	}
+	if !strings.Contains(help.String(), "--gzip") {
+		t.Error("help is missing the nested subcommand's flags")
+	}
+
+	help, err = GatherHelp(context.Background(), "fake", HelpFromHelp, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(help.Sections) != 3 {
+		t.Errorf("depth 1 read %d sections, want 3", len(help.Sections))
+	}
+
+	// A tool with a help command is asked for subcommand help through it,
+	// rather than running a subcommand that ignores --help
+	helper := `#!/bin/sh
+case "$*" in
+  "--help") printf 'Usage: helper\n\nCommands:\n  reset   Reset everything\n  help    Help about any command\n' ;;
+  "help reset") printf 'Usage: helper reset [--hard]\n\nResets everything\n' ;;
+  *) touch "` + ran + `" ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(dir, "helper"), []byte(helper), 0755); err != nil {
+		t.Fatal(err)
+	}
+	help, err = GatherHelp(context.Background(), "helper", HelpFromHelp, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(help.Sections) != 2 || help.Sections[1].Command != "helper reset" || !strings.Contains(help.Sections[1].Text, "--hard") {
+		t.Errorf("sections = %+v, want helper and helper reset", help.Sections)
+	}
+	if _, err := os.Stat(ran); err == nil {
+		t.Error("a tool was run with -h or a subcommand without asking for help")
+	}
+
+	if _, err := GatherHelp(context.Background(), "no-such-tool-xyz", HelpFromAuto, 2); err == nil {
+		t.Error("GatherHelp() of a missing tool succeeded")
+	}
+}
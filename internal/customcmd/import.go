@@ -0,0 +1,90 @@
+package customcmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/iishyfishyy/please/internal/placeholder"
+	"gopkg.in/yaml.v3"
+)
+
+// Formats 'please commands import' converts from
+const (
+	ImportFormatTldr    = "tldr"
+	ImportFormatOpenAPI = "openapi"
+)
+
+// ImportedDoc is a command doc converted from another format
+type ImportedDoc struct {
+	Path string // Where to write it, relative to the output directory
+	Doc  CommandDoc
+}
+
+// docFrontmatter is Frontmatter with empty fields left out when written
+type docFrontmatter struct {
+	Command      string                      `yaml:"command"`
+	Aliases      []string                    `yaml:"aliases,omitempty,flow"`
+	Keywords     []string                    `yaml:"keywords,omitempty,flow"`
+	Categories   []string                    `yaml:"categories,omitempty,flow"`
+	Priority     string                      `yaml:"priority,omitempty"`
+	Version      string                      `yaml:"version,omitempty"`
+	OS           []string                    `yaml:"os,omitempty,flow"`
+	Placeholders map[string]placeholder.Spec `yaml:"placeholders,omitempty"`
+}
+
+// FormatDoc renders a doc as markdown with frontmatter, the format Parser
+// reads. The doc's Content is written as is, so it should already contain
+// its examples as User:/Command: lines.
+func FormatDoc(doc CommandDoc) (string, error) {
+	fm, err := yaml.Marshal(docFrontmatter{
+		Command:      doc.Command,
+		Aliases:      doc.Aliases,
+		Keywords:     doc.Keywords,
+		Categories:   doc.Categories,
+		Priority:     doc.Priority,
+		Version:      doc.Version,
+		OS:           doc.OS,
+		Placeholders: doc.Placeholders,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal frontmatter: %w", err)
+	}
+
+	return "---\n" + string(fm) + "---\n\n" + strings.TrimSpace(doc.Content) + "\n", nil
+}
+
+// formatExample renders an example the way the parser reads it. Quotes in
+// the request are replaced, since the parser ends the request at the first
+// quote of either kind.
+func formatExample(ex Example) string {
+	request := strings.NewReplacer(`"`, "", "'", "’").Replace(ex.UserRequest)
+	return fmt.Sprintf("User: \"%s\"\nCommand: `%s`\n", request, ex.Command)
+}
+
+// WriteImported writes imported docs under dir. Existing files are skipped
+// unless overwrite is set. It returns the paths written and skipped.
+func WriteImported(dir string, docs []ImportedDoc, overwrite bool) (written, skipped []string, err error) {
+	for _, imported := range docs {
+		path := filepath.Join(dir, filepath.FromSlash(imported.Path))
+		if _, err := os.Stat(path); err == nil && !overwrite {
+			skipped = append(skipped, path)
+			continue
+		}
+
+		content, err := FormatDoc(imported.Doc)
+		if err != nil {
+			return written, skipped, fmt.Errorf("%s: %w", imported.Path, err)
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return written, skipped, fmt.Errorf("failed to create directory: %w", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return written, skipped, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		written = append(written, path)
+	}
+
+	return written, skipped, nil
+}
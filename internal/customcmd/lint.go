@@ -0,0 +1,408 @@
+package customcmd
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/iishyfishyy/please/internal/placeholder"
+	"gopkg.in/yaml.v3"
+)
+
+// Lint issue severities. Errors mean a doc won't load or load wrongly;
+// warnings mean part of it is ignored or likely to match badly.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// LintIssue is a problem found in a command doc
+type LintIssue struct {
+	File     string
+	Line     int // 1-based, or 0 for the whole file
+	Severity string
+	Message  string
+}
+
+// String formats the issue as file:line: severity: message
+func (i LintIssue) String() string {
+	if i.Line == 0 {
+		return fmt.Sprintf("%s: %s: %s", i.File, i.Severity, i.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s: %s", i.File, i.Line, i.Severity, i.Message)
+}
+
+// LintResult holds the issues found in a set of docs
+type LintResult struct {
+	Files  int // Docs checked
+	Issues []LintIssue
+}
+
+// Count returns the number of issues with the given severity
+func (r *LintResult) Count(severity string) int {
+	count := 0
+	for _, issue := range r.Issues {
+		if issue.Severity == severity {
+			count++
+		}
+	}
+	return count
+}
+
+// Regexps for example lines the parser would skip. They're looser than the
+// parser's so they catch near misses.
+var (
+	lintUserRe    = regexp.MustCompile(`(?i)^[*_>\s-]*(?:User|Request)[*_]*:`)
+	lintCommandRe = regexp.MustCompile(`(?i)^[*_>\s-]*Command[*_]*:[*_]*\s*(.*)$`)
+	yamlLineRe    = regexp.MustCompile(`line (\d+): (.*)`)
+)
+
+// validPriorities are the priority values the matcher understands
+var validPriorities = map[string]bool{"high": true, "medium": true, "low": true}
+
+// knownOS are the GOOS values a doc's os: field may name
+var knownOS = map[string]bool{
+	"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true,
+	"illumos": true, "ios": true, "linux": true, "netbsd": true, "openbsd": true,
+	"plan9": true, "solaris": true, "windows": true,
+}
+
+// frontmatterKeys are the keys Frontmatter reads, from its yaml tags
+var frontmatterKeys = func() map[string]bool {
+	keys := make(map[string]bool)
+	t := reflect.TypeOf(Frontmatter{})
+	for i := 0; i < t.NumField(); i++ {
+		if key, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ","); key != "" {
+			keys[key] = true
+		}
+	}
+	return keys
+}()
+
+// Lint checks command docs for problems. Each path is a commands directory,
+// walked the same way the loader walks it, or a single doc. Command names
+// and aliases must be unique within each directory (and among the single
+// files given); the same name in different directories is an override.
+func Lint(paths []string) (*LintResult, error) {
+	result := &LintResult{}
+	var singles []commandFile
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			singles = append(singles, commandFile{Path: path})
+			continue
+		}
+
+		files, err := walkCommandDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find command files in %s: %w", path, err)
+		}
+		result.lintSet(files)
+	}
+	result.lintSet(singles)
+
+	sort.SliceStable(result.Issues, func(i, j int) bool {
+		a, b := result.Issues[i], result.Issues[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+	return result, nil
+}
+
+// lintedDoc is a doc that parsed well enough to check against the others
+type lintedDoc struct {
+	doc         CommandDoc
+	commandLine int
+	aliasLine   int
+}
+
+// lintSet lints files and checks their names against each other
+func (r *LintResult) lintSet(files []commandFile) {
+	var docs []lintedDoc
+	for _, file := range files {
+		issues, doc := lintFile(file)
+		r.Files++
+		r.Issues = append(r.Issues, issues...)
+		if doc != nil {
+			docs = append(docs, *doc)
+		}
+	}
+	r.Issues = append(r.Issues, lintDuplicates(docs)...)
+}
+
+// lintDuplicates reports docs that share a name, which makes one of them
+// unreachable, and aliases claimed by more than one doc
+func lintDuplicates(docs []lintedDoc) []LintIssue {
+	var issues []LintIssue
+	names := make(map[string]string)   // Name → file
+	aliases := make(map[string]string) // Lowercased command or alias → Name
+
+	for _, d := range docs {
+		if first, ok := names[d.doc.Name()]; ok {
+			issues = append(issues, LintIssue{d.doc.Filename, d.commandLine, SeverityError,
+				fmt.Sprintf("duplicate command %q, also defined in %s; only one will load", d.doc.Name(), first)})
+			continue
+		}
+		names[d.doc.Name()] = d.doc.Filename
+		aliases[strings.ToLower(d.doc.Command)] = d.doc.Name()
+	}
+
+	for _, d := range docs {
+		for _, alias := range d.doc.Aliases {
+			key := strings.ToLower(alias)
+			if owner, ok := aliases[key]; ok && owner != d.doc.Name() {
+				issues = append(issues, LintIssue{d.doc.Filename, d.aliasLine, SeverityWarning,
+					fmt.Sprintf("alias %q is also used by %s", alias, owner)})
+				continue
+			}
+			aliases[key] = d.doc.Name()
+		}
+	}
+
+	return issues
+}
+
+// lintFile checks a single doc, returning its issues and, if it parsed, the
+// doc for the duplicate checks
+func lintFile(file commandFile) ([]LintIssue, *lintedDoc) {
+	var issues []LintIssue
+	report := func(line int, severity, format string, args ...interface{}) {
+		issues = append(issues, LintIssue{file.Path, line, severity, fmt.Sprintf(format, args...)})
+	}
+
+	data, err := os.ReadFile(file.Path)
+	if err != nil {
+		report(0, SeverityError, "%v", err)
+		return issues, nil
+	}
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+
+	if strings.TrimSpace(string(data)) == "" {
+		report(0, SeverityError, "empty file")
+		return issues, nil
+	}
+	if strings.TrimSpace(lines[0]) != "---" {
+		report(1, SeverityError, "missing frontmatter: docs must start with a --- block declaring command:")
+		return issues, nil
+	}
+	end := -1
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "---" {
+			end = i
+			break
+		}
+	}
+	if end == -1 {
+		report(1, SeverityError, "unclosed frontmatter: no closing ---")
+		return issues, nil
+	}
+
+	// Frontmatter line n is file line n+1
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(strings.Join(lines[1:end], "\n")), &root); err != nil {
+		for _, issue := range yamlIssues(err, 1) {
+			report(issue.Line, SeverityError, "%s", issue.Message)
+		}
+		return issues, nil
+	}
+
+	var fm Frontmatter
+	keyLines := make(map[string]int)
+	var placeholderNodes *yaml.Node
+	if len(root.Content) > 0 {
+		mapping := root.Content[0]
+		if mapping.Kind != yaml.MappingNode {
+			report(2, SeverityError, "frontmatter must be a mapping of keys to values")
+			return issues, nil
+		}
+		for i := 0; i+1 < len(mapping.Content); i += 2 {
+			key := mapping.Content[i]
+			keyLines[key.Value] = key.Line + 1
+			if !frontmatterKeys[key.Value] {
+				report(key.Line+1, SeverityWarning, "unknown frontmatter key %q is ignored", key.Value)
+			}
+			if key.Value == "placeholders" {
+				placeholderNodes = mapping.Content[i+1]
+			}
+		}
+		if err := mapping.Decode(&fm); err != nil {
+			for _, issue := range yamlIssues(err, 1) {
+				report(issue.Line, SeverityError, "%s", issue.Message)
+			}
+			return issues, nil
+		}
+	}
+
+	commandLine := keyLines["command"]
+	if commandLine == 0 {
+		commandLine = 1
+	}
+	if strings.TrimSpace(fm.Command) == "" {
+		report(commandLine, SeverityError, "missing required field: command")
+	}
+	if fm.Priority != "" && !validPriorities[strings.ToLower(fm.Priority)] {
+		report(keyLines["priority"], SeverityWarning, "priority %q isn't high, medium or low", fm.Priority)
+	}
+	for _, name := range normalizeOS(fm.OS) {
+		if !knownOS[name] {
+			report(keyLines["os"], SeverityWarning, "unknown os %q", name)
+		}
+	}
+
+	contentStart := end + 2 // File line of the first content line
+	content := lines[end+1:]
+	examples := lintExamples(content, contentStart, report)
+	if len(examples) == 0 {
+		report(0, SeverityWarning, "no User:/Command: examples; matching relies on keywords alone")
+	}
+	lintPlaceholders(fm.Placeholders, placeholderNodes, examples, strings.Join(content, "\n"), report)
+
+	if strings.TrimSpace(fm.Command) == "" {
+		return issues, nil
+	}
+	doc := CommandDoc{Filename: file.Path, Command: fm.Command, Aliases: fm.Aliases, Namespace: file.Namespace}
+	return issues, &lintedDoc{doc: doc, commandLine: commandLine, aliasLine: keyLines["aliases"]}
+}
+
+// yamlIssues splits a YAML error into one issue per line it mentions, with
+// line numbers shifted by offset
+func yamlIssues(err error, offset int) []LintIssue {
+	var messages []string
+	if typeErr, ok := err.(*yaml.TypeError); ok {
+		messages = typeErr.Errors
+	} else {
+		messages = []string{strings.TrimPrefix(err.Error(), "yaml: ")}
+	}
+
+	var issues []LintIssue
+	for _, msg := range messages {
+		issue := LintIssue{Message: "invalid frontmatter: " + msg}
+		if m := yamlLineRe.FindStringSubmatch(msg); m != nil {
+			line, _ := strconv.Atoi(m[1])
+			issue.Line = line + offset
+			issue.Message = "invalid frontmatter: " + m[2]
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// lintExamples finds the examples the parser would extract, reporting
+// User:/Command: lines it would skip. start is the file line of content[0].
+func lintExamples(content []string, start int, report func(int, string, string, ...interface{})) []Example {
+	// Match the parser exactly for what counts as an example
+	parsed := NewParser().parseExamples(strings.Join(content, "\n"))
+
+	pendingLine := 0 // Line of a User: still waiting for its Command:
+	for i, raw := range content {
+		line := strings.TrimSpace(raw)
+		lineNo := start + i
+
+		if exampleUserRe.MatchString(line) {
+			if pendingLine != 0 {
+				report(pendingLine, SeverityWarning, "User: line has no Command: before the next User:, so it's skipped")
+			}
+			pendingLine = lineNo
+			continue
+		}
+		if lintUserRe.MatchString(line) {
+			report(lineNo, SeverityWarning, "User: request isn't quoted, so this example is skipped")
+			continue
+		}
+		if m := lintCommandRe.FindStringSubmatch(line); m != nil {
+			switch {
+			case pendingLine == 0:
+				report(lineNo, SeverityWarning, "Command: line has no User: line before it, so it's skipped")
+			case strings.Trim(strings.TrimSpace(m[1]), "`") == "":
+				report(lineNo, SeverityWarning, "Command: line is empty")
+			}
+			pendingLine = 0
+		}
+	}
+	if pendingLine != 0 {
+		report(pendingLine, SeverityWarning, "User: line has no Command: after it, so it's skipped")
+	}
+
+	return parsed
+}
+
+// lintPlaceholders checks declared placeholders against the ones the doc
+// uses: unused declarations, names declared twice, sources that depend on
+// undeclared placeholders, and the same placeholder spelled different ways
+func lintPlaceholders(specs map[string]placeholder.Spec, node *yaml.Node, examples []Example, content string, report func(int, string, string, ...interface{})) {
+	declared := make(map[string]int) // Normalised name → file line
+	if node != nil && node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			name := placeholder.Normalize(key.Value)
+			if line, ok := declared[name]; ok {
+				report(key.Line+1, SeverityWarning, "placeholder %q is declared twice (see line %d)", key.Value, line)
+				continue
+			}
+			declared[name] = key.Line + 1
+		}
+	}
+
+	// Spellings of each placeholder across the examples
+	spellings := make(map[string]map[string]bool)
+	used := make(map[string]bool)
+	for _, ex := range examples {
+		for _, p := range placeholder.Find(ex.Command) {
+			used[p.Name] = true
+			for _, token := range p.Tokens {
+				if strings.HasPrefix(token, "<") || strings.HasPrefix(token, "{{") {
+					if spellings[p.Name] == nil {
+						spellings[p.Name] = make(map[string]bool)
+					}
+					spellings[p.Name][token] = true
+				}
+			}
+		}
+	}
+	for _, p := range placeholder.Find(content) {
+		used[p.Name] = true
+	}
+
+	var spelled []string
+	for name := range spellings {
+		spelled = append(spelled, name)
+	}
+	sort.Strings(spelled)
+	for _, name := range spelled {
+		if tokens := spellings[name]; len(tokens) > 1 {
+			var list []string
+			for token := range tokens {
+				list = append(list, token)
+			}
+			sort.Strings(list)
+			report(declared[name], SeverityWarning, "placeholder %s is spelled %s; pick one", name, strings.Join(list, " and "))
+		}
+	}
+
+	var names []string
+	for name := range specs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		key := placeholder.Normalize(name)
+		if !used[key] {
+			report(declared[key], SeverityWarning, "placeholder %q is declared but never used", name)
+		}
+		for _, dep := range placeholder.Find(specs[name].Source) {
+			if _, ok := declared[dep.Name]; !ok && !strings.HasPrefix(dep.Tokens[0], "$") {
+				report(declared[key], SeverityWarning, "source of placeholder %q uses %s, which isn't declared", name, dep.Tokens[0])
+			}
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package customcmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLint(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "deploy.md"), `---
+command: deploy
+aliases: [ship]
+keyword: [release]
+priority: urgent
+placeholders:
+  env:
+    description: Target environment
+  service:
+    source: list-services --cluster {{cluster}}
+---
+
+# Deploy
+
+User: "deploy to staging"
+Command: deploy <env> --wait <ENV>
+
+User: deploy everything
+User: "roll back"
+
+Command: deploy --rollback
+Command: deploy --force
+`)
+	writeFile(t, filepath.Join(dir, "ship.md"), "---\ncommand: ship\n---\nUser: \"ship it\"\nCommand: ship\n")
+	writeFile(t, filepath.Join(dir, "again.md"), "---\ncommand: ship\n---\nUser: \"ship it\"\nCommand: ship\n")
+	writeFile(t, filepath.Join(dir, "aws", "deploy.md"), "---\ncommand: deploy\n---\nUser: \"deploy to ecs\"\nCommand: deploy --ecs\n")
+	writeFile(t, filepath.Join(dir, "nocommand.md"), "---\npriority: high\n---\nUser: \"x\"\nCommand: x\n")
+	writeFile(t, filepath.Join(dir, "badyaml.md"), "---\ncommand: x\naliases: 5\n---\n")
+	writeFile(t, filepath.Join(dir, "plain.md"), "# Just notes\n")
+	writeFile(t, filepath.Join(dir, "README.md"), "# Not linted\n")
+
+	result, err := Lint([]string{dir})
+	if err != nil {
+		t.Fatalf("Lint() error = %v", err)
+	}
+	if result.Files != 7 {
+		t.Errorf("Files = %d, want 7", result.Files)
+	}
+
+	want := []string{
+		"ship.md:2: error: duplicate command \"ship\"",
+		"badyaml.md:3: error: invalid frontmatter: cannot unmarshal",
+		"deploy.md:3: warning: alias \"ship\" is also used by ship",
+		"deploy.md:4: warning: unknown frontmatter key \"keyword\"",
+		"deploy.md:5: warning: priority \"urgent\"",
+		"deploy.md:7: warning: placeholder env is spelled <ENV> and <env>",
+		"deploy.md:9: warning: placeholder \"service\" is declared but never used",
+		"deploy.md:9: warning: source of placeholder \"service\" uses {{cluster}}",
+		"deploy.md:18: warning: User: request isn't quoted",
+		"deploy.md:22: warning: Command: line has no User: line before it",
+		"nocommand.md:1: error: missing required field: command",
+		"plain.md:1: error: missing frontmatter",
+	}
+	var got []string
+	for _, issue := range result.Issues {
+		rel, _ := filepath.Rel(dir, issue.File)
+		got = append(got, strings.TrimPrefix(issue.String(), issue.File[:len(issue.File)-len(rel)]))
+	}
+	for _, prefix := range want {
+		found := false
+		for _, line := range got {
+			if strings.HasPrefix(line, prefix) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("missing issue %q", prefix)
+		}
+	}
+	if len(got) != len(want) || t.Failed() {
+		t.Errorf("got %d issues, want %d:\n%s", len(got), len(want), strings.Join(got, "\n"))
+	}
+	if result.Count(SeverityError) != 4 {
+		t.Errorf("Count(error) = %d, want 4", result.Count(SeverityError))
+	}
+}
+
+func TestLintTemplate(t *testing.T) {
+	result, err := Lint([]string{filepath.Join("..", "..", "templates", "kubectl_example.md")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, issue := range result.Issues {
+		t.Errorf("unexpected issue: %s", issue)
+	}
+}
+
+func TestParseRejectsDocsWithoutCommand(t *testing.T) {
+	dir := t.TempDir()
+	tests := map[string]string{
+		"no frontmatter": "# Custom Commands\n\nUser: \"x\"\nCommand: x\n",
+		"no command":     "---\npriority: high\n---\n",
+	}
+	for name, content := range tests {
+		path := filepath.Join(dir, strings.ReplaceAll(name, " ", "-")+".md")
+		writeFile(t, path, content)
+		if doc, err := NewParser().Parse(path); err == nil {
+			t.Errorf("%s: Parse() = %+v, want an error", name, doc)
+		}
+	}
+}
+
+func TestParseEmphasizedExamples(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deploy.md")
+	writeFile(t, path, "---\ncommand: deploy\n---\n**User**: \"deploy to staging\"\n**Command**: `deploy staging`\n")
+	doc, err := NewParser().Parse(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Examples) != 1 || doc.Examples[0].Command != "deploy staging" {
+		t.Errorf("Examples = %+v, want deploy staging", doc.Examples)
+	}
+}
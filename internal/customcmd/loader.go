@@ -3,8 +3,6 @@ package customcmd
 import (
 	"fmt"
 	"os"
-	"path/filepath"
-	"strings"
 )
 
 // Loader handles loading command documentation files
@@ -26,47 +24,38 @@ func NewLoaderWithDebug(debug bool) *Loader {
 	}
 }
 
-// LoadAll loads all .md files from the specified directory
+// LoadAll loads the command docs in dir and its subdirectories. Docs in a
+// subdirectory get its path as their namespace, and each component of it as
+// a category.
 func (l *Loader) LoadAll(dir string) ([]CommandDoc, error) {
-	// Check if directory exists
-	if _, err := os.Stat(dir); os.IsNotExist(err) {
-		return []CommandDoc{}, nil // Not an error, just no commands yet
-	}
-
-	// Find all .md files
-	pattern := filepath.Join(dir, "*.md")
-	files, err := filepath.Glob(pattern)
+	files, err := walkCommandDir(dir)
 	if err != nil {
-		return nil, fmt.Errorf("failed to glob files: %w", err)
+		return nil, fmt.Errorf("failed to find command files: %w", err)
 	}
 
 	if l.debug {
-		fmt.Fprintf(os.Stderr, "[DEBUG] Loader: found %d .md files in %s\n", len(files), dir)
+		fmt.Fprintf(os.Stderr, "[DEBUG] Loader: found %d command files in %s\n", len(files), dir)
 	}
 
 	if len(files) == 0 {
 		return []CommandDoc{}, nil
 	}
 
-	// Filter out README.md and other meta files
-	var commandFiles []string
-	for _, file := range files {
-		basename := filepath.Base(file)
-		// Skip README and files starting with _ (convention for meta files)
-		if strings.EqualFold(basename, "README.md") || strings.HasPrefix(basename, "_") {
-			if l.debug {
-				fmt.Fprintf(os.Stderr, "[DEBUG] Loader: skipping meta file: %s\n", basename)
-			}
-			continue
-		}
-		commandFiles = append(commandFiles, file)
+	paths := make([]string, len(files))
+	namespaces := make(map[string]string, len(files))
+	for i, file := range files {
+		paths[i] = file.Path
+		namespaces[file.Path] = file.Namespace
 		if l.debug {
-			fmt.Fprintf(os.Stderr, "[DEBUG] Loader: loading command file: %s\n", basename)
+			fmt.Fprintf(os.Stderr, "[DEBUG] Loader: loading command file: %s\n", file.Path)
 		}
 	}
 
 	// Parse all command files
-	docs, err := l.parser.ParseAll(commandFiles)
+	docs, err := l.parser.ParseAll(paths)
+	for i := range docs {
+		docs[i].setNamespace(namespaces[docs[i].Filename])
+	}
 	if err != nil {
 		// Don't fail completely if some files have errors
 		// The parser already collected docs that did parse
@@ -88,27 +77,19 @@ func (l *Loader) LoadSingle(filepath string) (*CommandDoc, error) {
 	return l.parser.Parse(filepath)
 }
 
-// HasCommands checks if the commands directory exists and has any .md files
+// HasCommands checks if any of the commands directories has command docs
 func HasCommands() (bool, error) {
-	dir, err := GetCommandsDir()
+	dirs, err := GetCommandDirs()
 	if err != nil {
 		return false, err
 	}
 
-	if _, err := os.Stat(dir); os.IsNotExist(err) {
-		return false, nil
-	}
-
-	pattern := filepath.Join(dir, "*.md")
-	files, err := filepath.Glob(pattern)
-	if err != nil {
-		return false, err
-	}
-
-	// Filter out README.md
-	for _, file := range files {
-		basename := filepath.Base(file)
-		if !strings.EqualFold(basename, "README.md") && !strings.HasPrefix(basename, "_") {
+	for _, dir := range dirs {
+		files, err := walkCommandDir(dir.Path)
+		if err != nil {
+			return false, err
+		}
+		if len(files) > 0 {
 			return true, nil
 		}
 	}
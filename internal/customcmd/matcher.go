@@ -9,8 +9,10 @@ import (
 )
 
 // Matcher performs keyword-based matching of user requests to command docs
+// using a BM25 index over each doc's name, aliases, keywords, examples and body
 type Matcher struct {
 	docs  []CommandDoc
+	index *LexicalIndex
 	debug bool
 }
 
@@ -23,164 +25,88 @@ func NewMatcher() *Matcher {
 func NewMatcherWithDebug(debug bool) *Matcher {
 	return &Matcher{
 		docs:  []CommandDoc{},
+		index: BuildLexicalIndex(nil),
 		debug: debug,
 	}
 }
 
-// SetDocs sets the documents to match against
+// SetDocs sets the documents to match against, building a fresh index
 func (m *Matcher) SetDocs(docs []CommandDoc) {
+	m.SetIndex(docs, BuildLexicalIndex(docs))
+}
+
+// SetIndex sets the documents along with a prebuilt index for them
+func (m *Matcher) SetIndex(docs []CommandDoc, index *LexicalIndex) {
 	m.docs = docs
+	m.index = index
 }
 
 // ScoredDoc represents a document with a match score
 type ScoredDoc struct {
 	Doc   CommandDoc
-	Score int
+	Score float64 // BM25 score as a percentage of the best possible for the request
 }
 
 // FindRelevantDocs finds the most relevant documents for a request
 func (m *Matcher) FindRelevantDocs(request string, maxDocs int) []CommandDoc {
-	if len(m.docs) == 0 {
-		return []CommandDoc{}
-	}
-
-	requestWords := tokenize(strings.ToLower(request))
-	if len(requestWords) == 0 {
-		return []CommandDoc{}
-	}
-
-	if m.debug {
-		fmt.Fprintf(os.Stderr, "[DEBUG] Matcher: searching %d docs for request words: %v\n", len(m.docs), requestWords)
-	}
-
-	var scored []ScoredDoc
-
-	for _, doc := range m.docs {
-		score := m.scoreDoc(doc, requestWords)
-		if score > 0 {
-			scored = append(scored, ScoredDoc{
-				Doc:   doc,
-				Score: score,
-			})
-			if m.debug && score > 50 {
-				fmt.Fprintf(os.Stderr, "[DEBUG] Matcher:   %s scored %d\n", doc.Command, score)
-			}
-		}
-	}
-
-	// Sort by score (descending)
-	sort.Slice(scored, func(i, j int) bool {
-		return scored[i].Score > scored[j].Score
-	})
+	scored := m.Score(request)
 
 	// Return top N
-	n := min(len(scored), maxDocs)
+	n := minInt(len(scored), maxDocs)
 	result := make([]CommandDoc, n)
 	for i := 0; i < n; i++ {
 		result[i] = scored[i].Doc
 	}
 
 	if m.debug && n > 0 {
-		fmt.Fprintf(os.Stderr, "[DEBUG] Matcher: returning top %d docs (best score: %d)\n", n, scored[0].Score)
+		fmt.Fprintf(os.Stderr, "[DEBUG] Matcher: returning top %d docs (best score: %.1f)\n", n, scored[0].Score)
 	}
 
 	return result
 }
 
-// scoreDoc calculates a relevance score for a document
-func (m *Matcher) scoreDoc(doc CommandDoc, requestWords []string) int {
-	score := 0
-
-	// Direct command name match (highest priority)
-	commandName := strings.ToLower(doc.Command)
-	if containsWord(requestWords, commandName) {
-		score += 100
+// Score returns every document that matches the request with its keyword
+// score, best first
+func (m *Matcher) Score(request string) []ScoredDoc {
+	if len(m.docs) == 0 {
+		return []ScoredDoc{}
 	}
 
-	// Partial command name match (e.g., "kube" matches "kubectl")
-	for _, word := range requestWords {
-		if strings.Contains(commandName, word) || strings.Contains(word, commandName) {
-			score += 50
-			break
-		}
+	if m.debug {
+		fmt.Fprintf(os.Stderr, "[DEBUG] Matcher: searching %d docs for request terms: %v\n", len(m.docs), analyze(request))
 	}
 
-	// Alias match
-	for _, alias := range doc.Aliases {
-		aliasLower := strings.ToLower(alias)
-		if containsWord(requestWords, aliasLower) {
-			score += 80
-		}
-		// Partial alias match
-		for _, word := range requestWords {
-			if strings.Contains(aliasLower, word) || strings.Contains(word, aliasLower) {
-				score += 40
-				break
-			}
-		}
-	}
+	var scored []ScoredDoc
 
-	// Keyword match
-	keywordMatches := 0
-	for _, keyword := range doc.Keywords {
-		keywordLower := strings.ToLower(keyword)
-		if containsWord(requestWords, keywordLower) {
-			keywordMatches++
-			score += 10
+	for i, score := range m.index.Search(request) {
+		if score <= 0 || i >= len(m.docs) {
+			continue
 		}
-		// Partial keyword match
-		for _, word := range requestWords {
-			if strings.Contains(keywordLower, word) || strings.Contains(word, keywordLower) {
-				score += 3
-				break
-			}
-		}
-	}
-
-	// Bonus for multiple keyword matches
-	if keywordMatches > 2 {
-		score += keywordMatches * 5
+		scored = append(scored, ScoredDoc{
+			Doc:   m.docs[i],
+			Score: score,
+		})
 	}
 
-	// Category match
-	for _, category := range doc.Categories {
-		categoryLower := strings.ToLower(category)
-		if containsWord(requestWords, categoryLower) {
-			score += 5
+	// Sort by score (descending), then name for a stable order
+	sort.Slice(scored, func(i, j int) bool {
+		if scored[i].Score != scored[j].Score {
+			return scored[i].Score > scored[j].Score
 		}
-	}
+		return scored[i].Doc.Command < scored[j].Doc.Command
+	})
 
-	// Example match (check if request is similar to known examples)
-	for _, example := range doc.Examples {
-		exampleWords := tokenize(strings.ToLower(example.UserRequest))
-		overlap := wordOverlap(requestWords, exampleWords)
-		if overlap > 0 {
-			score += overlap * 15 // High value for example matches
+	if m.debug {
+		for _, s := range scored {
+			fmt.Fprintf(os.Stderr, "[DEBUG] Matcher:   %s scored %.1f\n", s.Doc.Command, s.Score)
 		}
 	}
 
-	// Priority boost
-	switch strings.ToLower(doc.Priority) {
-	case "high":
-		score = int(float64(score) * 1.3)
-	case "medium":
-		score = int(float64(score) * 1.1)
-	}
-
-	return score
+	return scored
 }
 
 // tokenize splits text into words, filtering out common stop words
 func tokenize(text string) []string {
-	// Stop words to ignore
-	stopWords := map[string]bool{
-		"a": true, "an": true, "and": true, "the": true, "in": true,
-		"on": true, "at": true, "to": true, "for": true, "of": true,
-		"with": true, "by": true, "from": true, "as": true, "is": true,
-		"was": true, "are": true, "were": true, "be": true, "been": true,
-		"my": true, "me": true, "i": true, "you": true, "it": true,
-	}
-
 	var words []string
 	var currentWord strings.Builder
 
@@ -209,32 +135,8 @@ func tokenize(text string) []string {
 	return words
 }
 
-// containsWord checks if a word is in the list
-func containsWord(words []string, word string) bool {
-	for _, w := range words {
-		if w == word {
-			return true
-		}
-	}
-	return false
-}
-
-// wordOverlap counts how many words are in both lists
-func wordOverlap(words1, words2 []string) int {
-	count := 0
-	for _, w1 := range words1 {
-		for _, w2 := range words2 {
-			if w1 == w2 {
-				count++
-				break
-			}
-		}
-	}
-	return count
-}
-
-// min returns the minimum of two integers
-func min(a, b int) int {
+// minInt returns the minimum of two integers
+func minInt(a, b int) int {
 	if a < b {
 		return a
 	}
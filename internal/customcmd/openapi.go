@@ -0,0 +1,687 @@
+package customcmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/iishyfishyy/please/internal/placeholder"
+	"gopkg.in/yaml.v3"
+)
+
+// How ImportOpenAPI splits an API into docs
+const (
+	OpenAPIByTag       = "tag"       // One doc per tag, with a section per operation
+	OpenAPIByOperation = "operation" // One doc per operation
+)
+
+// openAPIMethods are the operations of a path item, in the order they're listed
+var openAPIMethods = []string{"get", "post", "put", "patch", "delete", "head", "options"}
+
+// Only what the importer uses of an OpenAPI 3 document. YAML decoding reads
+// JSON documents as well.
+type openAPISpec struct {
+	OpenAPI string `yaml:"openapi"`
+	Info    struct {
+		Title       string `yaml:"title"`
+		Description string `yaml:"description"`
+		Version     string `yaml:"version"`
+	} `yaml:"info"`
+	Servers []struct {
+		URL       string `yaml:"url"`
+		Variables map[string]struct {
+			Default string `yaml:"default"`
+		} `yaml:"variables"`
+	} `yaml:"servers"`
+	Paths    map[string]map[string]yaml.Node `yaml:"paths"`
+	Security []map[string][]string           `yaml:"security"`
+	Tags     []struct {
+		Name        string `yaml:"name"`
+		Description string `yaml:"description"`
+	} `yaml:"tags"`
+	Components struct {
+		Parameters      map[string]openAPIParameter      `yaml:"parameters"`
+		RequestBodies   map[string]openAPIRequestBody    `yaml:"requestBodies"`
+		Schemas         map[string]*openAPISchema        `yaml:"schemas"`
+		SecuritySchemes map[string]openAPISecurityScheme `yaml:"securitySchemes"`
+	} `yaml:"components"`
+}
+
+type openAPIOperation struct {
+	OperationID string                 `yaml:"operationId"`
+	Summary     string                 `yaml:"summary"`
+	Description string                 `yaml:"description"`
+	Tags        []string               `yaml:"tags"`
+	Parameters  []openAPIParameter     `yaml:"parameters"`
+	RequestBody *openAPIRequestBody    `yaml:"requestBody"`
+	Security    *[]map[string][]string `yaml:"security"`
+	Deprecated  bool                   `yaml:"deprecated"`
+
+	method, path string
+}
+
+type openAPIParameter struct {
+	Ref         string         `yaml:"$ref"`
+	Name        string         `yaml:"name"`
+	In          string         `yaml:"in"`
+	Description string         `yaml:"description"`
+	Required    bool           `yaml:"required"`
+	Schema      *openAPISchema `yaml:"schema"`
+	Example     interface{}    `yaml:"example"`
+}
+
+type openAPIRequestBody struct {
+	Ref     string `yaml:"$ref"`
+	Content map[string]struct {
+		Schema  *openAPISchema `yaml:"schema"`
+		Example interface{}    `yaml:"example"`
+	} `yaml:"content"`
+}
+
+type openAPISchema struct {
+	Ref        string                    `yaml:"$ref"`
+	Type       string                    `yaml:"type"`
+	Enum       []interface{}             `yaml:"enum"`
+	Default    interface{}               `yaml:"default"`
+	Example    interface{}               `yaml:"example"`
+	Properties map[string]*openAPISchema `yaml:"properties"`
+	Required   []string                  `yaml:"required"`
+}
+
+type openAPISecurityScheme struct {
+	Type         string `yaml:"type"`   // apiKey, http, oauth2 or openIdConnect
+	Scheme       string `yaml:"scheme"` // basic or bearer, for http
+	BearerFormat string `yaml:"bearerFormat"`
+	Name         string `yaml:"name"` // Header, query or cookie name, for apiKey
+	In           string `yaml:"in"`
+	Description  string `yaml:"description"`
+}
+
+var camelRe = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+
+// ImportOpenAPI converts an OpenAPI 3 document (YAML or JSON) into command
+// docs, one per tag or per operation (see OpenAPIByTag). Each operation gets
+// curl and httpie examples with its parameters as placeholders, and the
+// auth the API declares as a header (or query parameter) read from an
+// environment variable. Docs are written under a directory named after the
+// API.
+func ImportOpenAPI(filename, groupBy string) ([]ImportedDoc, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var spec openAPISpec
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		return nil, fmt.Errorf("%s isn't an OpenAPI 3 document", filename)
+	}
+	if spec.Info.Title == "" {
+		spec.Info.Title = "api"
+	}
+
+	operations, err := spec.operations()
+	if err != nil {
+		return nil, err
+	}
+	if len(operations) == 0 {
+		return nil, fmt.Errorf("%s has no operations", filename)
+	}
+
+	api := slug(spec.Info.Title)
+	var docs []ImportedDoc
+	switch groupBy {
+	case OpenAPIByTag, "":
+		var tags []string
+		byTag := make(map[string][]openAPIOperation)
+		for _, op := range operations {
+			tag := "default"
+			if len(op.Tags) > 0 {
+				tag = op.Tags[0]
+			}
+			if _, ok := byTag[tag]; !ok {
+				tags = append(tags, tag)
+			}
+			byTag[tag] = append(byTag[tag], op)
+		}
+		for _, tag := range tags {
+			doc := spec.buildDoc(slug(tag), tag, spec.tagDescription(tag), byTag[tag])
+			docs = append(docs, ImportedDoc{Path: api + "/" + slug(tag) + ".md", Doc: doc})
+		}
+	case OpenAPIByOperation:
+		for _, op := range operations {
+			name := slug(op.OperationID)
+			if name == "" {
+				name = slug(op.method + " " + op.path)
+			}
+			doc := spec.buildDoc(name, op.summary(), op.Description, []openAPIOperation{op})
+			docs = append(docs, ImportedDoc{Path: api + "/" + name + ".md", Doc: doc})
+		}
+	default:
+		return nil, fmt.Errorf("unknown grouping %q (use %s or %s)", groupBy, OpenAPIByTag, OpenAPIByOperation)
+	}
+
+	return docs, nil
+}
+
+// operations returns every operation in the spec, sorted by path and then
+// method, with path-level parameters merged in and $refs resolved
+func (s *openAPISpec) operations() ([]openAPIOperation, error) {
+	var paths []string
+	for path := range s.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var operations []openAPIOperation
+	for _, path := range paths {
+		item := s.Paths[path]
+
+		var shared []openAPIParameter
+		if node, ok := item["parameters"]; ok {
+			if err := node.Decode(&shared); err != nil {
+				return nil, fmt.Errorf("%s parameters: %w", path, err)
+			}
+		}
+
+		for _, method := range openAPIMethods {
+			node, ok := item[method]
+			if !ok {
+				continue
+			}
+			var op openAPIOperation
+			if err := node.Decode(&op); err != nil {
+				return nil, fmt.Errorf("%s %s: %w", strings.ToUpper(method), path, err)
+			}
+			if op.Deprecated {
+				continue
+			}
+			op.method, op.path = method, path
+			op.Parameters = s.mergeParameters(shared, op.Parameters)
+			if op.RequestBody != nil && op.RequestBody.Ref != "" {
+				if body, ok := s.Components.RequestBodies[refName(op.RequestBody.Ref)]; ok {
+					op.RequestBody = &body
+				}
+			}
+			operations = append(operations, op)
+		}
+	}
+
+	return operations, nil
+}
+
+// mergeParameters resolves parameter $refs, with an operation's parameters
+// replacing path-level ones of the same name and location
+func (s *openAPISpec) mergeParameters(shared, own []openAPIParameter) []openAPIParameter {
+	var merged []openAPIParameter
+	index := make(map[string]int)
+	for _, p := range append(append([]openAPIParameter{}, shared...), own...) {
+		if p.Ref != "" {
+			resolved, ok := s.Components.Parameters[refName(p.Ref)]
+			if !ok {
+				continue
+			}
+			p = resolved
+		}
+		key := p.In + ":" + p.Name
+		if i, ok := index[key]; ok {
+			merged[i] = p
+			continue
+		}
+		index[key] = len(merged)
+		merged = append(merged, p)
+	}
+	return merged
+}
+
+// refName returns the last part of a local $ref: "#/components/schemas/Order" → "Order"
+func refName(ref string) string {
+	return ref[strings.LastIndex(ref, "/")+1:]
+}
+
+// tagDescription returns the description the spec gives a tag
+func (s *openAPISpec) tagDescription(tag string) string {
+	for _, t := range s.Tags {
+		if t.Name == tag {
+			return t.Description
+		}
+	}
+	return ""
+}
+
+// baseURL returns the first server's URL with its variables filled in, or
+// a <base-url> placeholder if the spec has no absolute server URL
+func (s *openAPISpec) baseURL() string {
+	if len(s.Servers) == 0 || !strings.Contains(s.Servers[0].URL, "://") {
+		return "<base-url>"
+	}
+	url := s.Servers[0].URL
+	for name, variable := range s.Servers[0].Variables {
+		url = strings.ReplaceAll(url, "{"+name+"}", variable.Default)
+	}
+	return strings.TrimRight(url, "/")
+}
+
+// openAPIAuth is how a request authenticates, as curl and httpie arguments
+type openAPIAuth struct {
+	hint        string // Sentence for the doc
+	curl, httpi string // Arguments, e.g. -H "Authorization: Bearer $TOKEN"
+	query       string // name=$KEY for API keys sent in the query string
+}
+
+// auth returns how an operation authenticates, from its own security
+// requirements or the spec's. The first scheme the importer understands wins.
+func (s *openAPISpec) auth(op openAPIOperation) *openAPIAuth {
+	requirements := s.Security
+	if op.Security != nil {
+		requirements = *op.Security
+	}
+
+	prefix := strings.ToUpper(strings.ReplaceAll(slug(s.Info.Title), "-", "_"))
+	for _, requirement := range requirements {
+		var names []string
+		for name := range requirement {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			scheme, ok := s.Components.SecuritySchemes[name]
+			if !ok {
+				continue
+			}
+			switch {
+			case scheme.Type == "http" && strings.EqualFold(scheme.Scheme, "basic"):
+				creds := fmt.Sprintf("$%s_USER:$%s_PASSWORD", prefix, prefix)
+				return &openAPIAuth{
+					hint: fmt.Sprintf("HTTP basic auth: set $%s_USER and $%s_PASSWORD.", prefix, prefix),
+					curl: fmt.Sprintf(`-u "%s"`, creds), httpi: fmt.Sprintf(`-a "%s"`, creds),
+				}
+			case scheme.Type == "http" || scheme.Type == "oauth2" || scheme.Type == "openIdConnect":
+				token := "$" + prefix + "_TOKEN"
+				hint := fmt.Sprintf("Bearer token in the Authorization header: set %s.", token)
+				if scheme.Type != "http" {
+					hint = fmt.Sprintf("OAuth access token sent as a bearer token: set %s.", token)
+				}
+				return &openAPIAuth{
+					hint: hint,
+					curl: fmt.Sprintf(`-H "Authorization: Bearer %s"`, token), httpi: fmt.Sprintf(`Authorization:"Bearer %s"`, token),
+				}
+			case scheme.Type == "apiKey":
+				key := "$" + prefix + "_API_KEY"
+				auth := &openAPIAuth{hint: fmt.Sprintf("API key in the %s %s: set %s.", scheme.Name, scheme.In, key)}
+				switch scheme.In {
+				case "query":
+					auth.query = scheme.Name + "=" + key
+				case "cookie":
+					auth.curl = fmt.Sprintf(`-b "%s=%s"`, scheme.Name, key)
+					auth.httpi = fmt.Sprintf(`Cookie:"%s=%s"`, scheme.Name, key)
+				default:
+					auth.curl = fmt.Sprintf(`-H "%s: %s"`, scheme.Name, key)
+					auth.httpi = fmt.Sprintf(`%s:"%s"`, scheme.Name, key)
+				}
+				return auth
+			}
+		}
+	}
+	return nil
+}
+
+// summary describes an operation in a few words
+func (op openAPIOperation) summary() string {
+	switch {
+	case op.Summary != "":
+		return strings.TrimSuffix(op.Summary, ".")
+	case op.OperationID != "":
+		return strings.ReplaceAll(slug(op.OperationID), "-", " ")
+	default:
+		return strings.ToUpper(op.method) + " " + op.path
+	}
+}
+
+// buildDoc writes the doc for a group of operations
+func (s *openAPISpec) buildDoc(command, title, description string, operations []openAPIOperation) CommandDoc {
+	doc := CommandDoc{
+		Command:    command,
+		Categories: []string{"api"},
+		Version:    s.Info.Version,
+	}
+
+	keywords := []string{"api", "http", "curl", "rest"}
+	for _, word := range strings.Fields(strings.ToLower(s.Info.Title + " " + title)) {
+		keywords = append(keywords, strings.Trim(word, ".,:;()"))
+	}
+
+	var content strings.Builder
+	fmt.Fprintf(&content, "# %s: %s\n\n", s.Info.Title, title)
+	if description = strings.TrimSpace(description); description != "" {
+		content.WriteString(description + "\n\n")
+	} else if s.Info.Description != "" {
+		content.WriteString(strings.TrimSpace(s.Info.Description) + "\n\n")
+	}
+	base := s.baseURL()
+	fmt.Fprintf(&content, "Base URL: %s\n", base)
+	if len(s.Servers) > 1 {
+		var others []string
+		for _, server := range s.Servers[1:] {
+			others = append(others, server.URL)
+		}
+		fmt.Fprintf(&content, "Other servers: %s\n", strings.Join(others, ", "))
+	}
+
+	specs := make(map[string]placeholder.Spec)
+	if base == "<base-url>" {
+		specs["base-url"] = placeholder.Spec{Description: s.Info.Title + " base URL"}
+	}
+
+	var examples []Example
+	hints := make(map[string]bool)
+	for _, op := range operations {
+		auth := s.auth(op)
+		if auth != nil && !hints[auth.hint] {
+			hints[auth.hint] = true
+			fmt.Fprintf(&content, "Authentication: %s\n", auth.hint)
+		}
+
+		curl, httpie := s.requests(op, base, auth, specs)
+		summary := op.summary()
+		fmt.Fprintf(&content, "\n## %s\n\n`%s %s`\n\n", summary, strings.ToUpper(op.method), op.path)
+		if op.Description != "" && op.Description != op.Summary {
+			content.WriteString(strings.TrimSpace(op.Description) + "\n\n")
+		}
+		if len(op.Parameters) > 0 {
+			content.WriteString("Parameters:\n")
+			for _, p := range op.Parameters {
+				required := ""
+				if p.Required || p.In == "path" {
+					required = ", required"
+				}
+				fmt.Fprintf(&content, "- `%s` (%s%s)", p.Name, p.In, required)
+				if p.Description != "" {
+					content.WriteString(": " + strings.TrimSpace(p.Description))
+				}
+				content.WriteString("\n")
+			}
+			content.WriteString("\n")
+		}
+		fmt.Fprintf(&content, "```bash\n%s\n%s\n```\n", curl, httpie)
+
+		examples = append(examples,
+			Example{UserRequest: summary, Command: curl},
+			Example{UserRequest: summary + " with httpie", Command: httpie},
+		)
+		if op.OperationID != "" {
+			keywords = append(keywords, strings.Fields(strings.ReplaceAll(slug(op.OperationID), "-", " "))...)
+		}
+	}
+
+	content.WriteString("\n## Examples\n")
+	for _, ex := range examples {
+		content.WriteString("\n" + formatExample(ex))
+	}
+
+	doc.Keywords = uniqueWords(keywords)
+	doc.Content = content.String()
+	doc.Examples = examples
+	if len(specs) > 0 {
+		doc.Placeholders = specs
+	}
+	return doc
+}
+
+// requests returns curl and httpie commands for an operation, adding a
+// placeholder spec for each parameter they use
+func (s *openAPISpec) requests(op openAPIOperation, base string, auth *openAPIAuth, specs map[string]placeholder.Spec) (string, string) {
+	path := op.path
+	var query, curlHeaders, httpieHeaders []string
+
+	for _, p := range op.Parameters {
+		if p.In != "path" && !p.Required {
+			continue
+		}
+		name := placeholderName(p.Name)
+		token := "<" + name + ">"
+		specs[name] = parameterSpec(p)
+
+		switch p.In {
+		case "path":
+			path = strings.ReplaceAll(path, "{"+p.Name+"}", token)
+		case "query":
+			query = append(query, p.Name+"="+token)
+		case "header":
+			curlHeaders = append(curlHeaders, fmt.Sprintf(`-H "%s: %s"`, p.Name, token))
+			httpieHeaders = append(httpieHeaders, fmt.Sprintf(`%s:"%s"`, p.Name, token))
+		case "cookie":
+			curlHeaders = append(curlHeaders, fmt.Sprintf(`-b "%s=%s"`, p.Name, token))
+			httpieHeaders = append(httpieHeaders, fmt.Sprintf(`Cookie:"%s=%s"`, p.Name, token))
+		}
+	}
+	if auth != nil {
+		if auth.query != "" {
+			query = append(query, auth.query)
+		}
+		if auth.curl != "" {
+			curlHeaders = append(curlHeaders, auth.curl)
+			httpieHeaders = append(httpieHeaders, auth.httpi)
+		}
+	}
+
+	url := base + path
+	if len(query) > 0 {
+		url += "?" + strings.Join(query, "&")
+	}
+	method := strings.ToUpper(op.method)
+
+	curl := []string{"curl", "-sS"}
+	if method != "GET" {
+		curl = append(curl, "-X", method)
+	}
+	curl = append(curl, fmt.Sprintf(`"%s"`, url))
+	curl = append(curl, curlHeaders...)
+
+	httpie := []string{"http", method, fmt.Sprintf(`"%s"`, url)}
+	httpie = append(httpie, httpieHeaders...)
+
+	if body := s.requestBody(op, specs); body != nil {
+		contentType := fmt.Sprintf(`-H "Content-Type: %s"`, body.contentType)
+		if body.literal != "" {
+			// Single quotes wrap the body on the command line
+			literal := "'" + strings.ReplaceAll(body.literal, "'", `'\''`) + "'"
+			curl = append(curl, contentType, "-d", literal)
+			httpie = append(httpie, "--raw", literal)
+		} else {
+			// jq and httpie build the JSON, so filled-in values are escaped
+			// properly; the placeholders stay outside quotes to be filled
+			jq := []string{"jq", "-n"}
+			for _, field := range body.fields {
+				if field.raw {
+					jq = append(jq, "--argjson", field.name, field.token)
+					httpie = append(httpie, field.name+":="+field.token)
+				} else {
+					jq = append(jq, "--arg", field.name, field.token)
+					httpie = append(httpie, field.name+"="+field.token)
+				}
+			}
+			jq = append(jq, "'$ARGS.named'", "|")
+			curl = append(append(jq, curl...), contentType, "--data-binary", "@-")
+		}
+	}
+
+	return strings.Join(curl, " "), strings.Join(httpie, " ")
+}
+
+// openAPIBody is an operation's JSON request body: the spec's example, or
+// fields whose values are placeholders
+type openAPIBody struct {
+	contentType string
+	literal     string
+	fields      []openAPIField
+}
+
+// openAPIField is a request body field filled from a placeholder
+type openAPIField struct {
+	name  string
+	token string // e.g. "<sku>"
+	raw   bool   // The value is JSON (a number, boolean, array or object), not a string
+}
+
+// requestBody returns an example JSON body for an operation: the spec's
+// own example if it has one, or else the schema's properties (the required
+// ones, if it lists any) with placeholders as values
+func (s *openAPISpec) requestBody(op openAPIOperation, specs map[string]placeholder.Spec) *openAPIBody {
+	if op.RequestBody == nil {
+		return nil
+	}
+
+	var types []string
+	for contentType := range op.RequestBody.Content {
+		types = append(types, contentType)
+	}
+	sort.Strings(types)
+	body := &openAPIBody{}
+	for _, t := range types {
+		if strings.Contains(t, "json") {
+			body.contentType = t
+			break
+		}
+	}
+	if body.contentType == "" {
+		return nil
+	}
+	media := op.RequestBody.Content[body.contentType]
+
+	example := media.Example
+	if example == nil && media.Schema != nil {
+		schema := s.resolveSchema(media.Schema)
+		if schema.Example != nil {
+			example = schema.Example
+		} else if len(schema.Properties) > 0 {
+			fields := schema.Required
+			if len(fields) == 0 {
+				for name := range schema.Properties {
+					fields = append(fields, name)
+				}
+				sort.Strings(fields)
+			}
+			for _, field := range fields {
+				name := placeholderName(field)
+				raw := false
+				if property := schema.Properties[field]; property != nil {
+					switch s.resolveSchema(property).Type {
+					case "integer", "number", "boolean", "array", "object":
+						raw = true
+					}
+				}
+				body.fields = append(body.fields, openAPIField{name: field, token: "<" + name + ">", raw: raw})
+				if _, ok := specs[name]; !ok {
+					specs[name] = placeholder.Spec{Description: "Request body field " + field}
+				}
+			}
+			return body
+		}
+	}
+	if example == nil {
+		return nil
+	}
+
+	// Without HTML escaping, so the example stays readable
+	var data strings.Builder
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(jsonCompatible(example)); err != nil {
+		return nil
+	}
+	body.literal = strings.TrimSpace(data.String())
+	return body
+}
+
+// resolveSchema follows a schema's $ref into components
+func (s *openAPISpec) resolveSchema(schema *openAPISchema) *openAPISchema {
+	for i := 0; schema.Ref != "" && i < 10; i++ {
+		resolved, ok := s.Components.Schemas[refName(schema.Ref)]
+		if !ok || resolved == nil {
+			break
+		}
+		schema = resolved
+	}
+	return schema
+}
+
+// jsonCompatible converts YAML-decoded values (which may have
+// map[string]interface{} or map[interface{}]interface{} maps) for encoding/json
+func jsonCompatible(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[fmt.Sprint(key)] = jsonCompatible(item)
+		}
+		return m
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = jsonCompatible(item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = jsonCompatible(item)
+		}
+		return v
+	}
+	return value
+}
+
+// parameterSpec describes a parameter for placeholder prompts
+func parameterSpec(p openAPIParameter) placeholder.Spec {
+	spec := placeholder.Spec{Description: strings.TrimSpace(p.Description)}
+	if spec.Description == "" {
+		spec.Description = fmt.Sprintf("%s %s parameter", p.Name, p.In)
+	}
+	if p.Schema != nil {
+		if len(p.Schema.Enum) > 0 {
+			var values []string
+			for _, v := range p.Schema.Enum {
+				values = append(values, fmt.Sprint(v))
+			}
+			spec.Description += " (one of: " + strings.Join(values, ", ") + ")"
+		}
+		if p.Schema.Default != nil {
+			spec.Default = fmt.Sprint(p.Schema.Default)
+		}
+	}
+	if spec.Default == "" && p.Example != nil {
+		spec.Default = fmt.Sprint(p.Example)
+	}
+	return spec
+}
+
+// placeholderName turns a parameter name into a placeholder name:
+// "orderId" and "order_id" → "order-id"
+func placeholderName(name string) string {
+	return placeholder.Normalize(camelRe.ReplaceAllString(name, "$1-$2"))
+}
+
+// slug turns a title into a lowercase file and command name: "Orders API" → "orders-api"
+func slug(text string) string {
+	text = camelRe.ReplaceAllString(text, "$1-$2")
+	return strings.Trim(nonNameRe.ReplaceAllString(strings.ToLower(text), "-"), "-")
+}
+
+// uniqueWords drops empty and repeated words, keeping the first of each
+func uniqueWords(words []string) []string {
+	var unique []string
+	seen := make(map[string]bool)
+	for _, word := range words {
+		if word == "" || seen[word] {
+			continue
+		}
+		seen[word] = true
+		unique = append(unique, word)
+	}
+	return unique
+}
@@ -0,0 +1,194 @@
+package customcmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/iishyfishyy/please/internal/placeholder"
+)
+
+const ordersSpec = `openapi: 3.0.3
+info:
+  title: Orders API
+  version: "2.1"
+servers:
+  - url: https://{region}.orders.internal/v2/
+    variables:
+      region:
+        default: eu
+security:
+  - bearerAuth: []
+tags:
+  - name: orders
+    description: Customer orders.
+paths:
+  /orders/{orderId}:
+    parameters:
+      - $ref: '#/components/parameters/OrderId'
+    get:
+      operationId: getOrder
+      summary: Get the status of an order
+      tags: [orders]
+      parameters:
+        - name: expand
+          in: query
+          schema: {type: string}
+    delete:
+      operationId: cancelOrder
+      summary: Cancel an order
+      tags: [orders]
+      parameters:
+        - name: reason
+          in: query
+          required: true
+          schema:
+            type: string
+            enum: [duplicate, fraud]
+  /orders:
+    post:
+      operationId: createOrder
+      summary: Create an order
+      tags: [orders]
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/NewOrder'
+  /health:
+    get:
+      summary: Health check
+      security:
+        - apiKey: []
+components:
+  parameters:
+    OrderId:
+      name: orderId
+      in: path
+      required: true
+      description: The order's ID
+  schemas:
+    NewOrder:
+      type: object
+      required: [sku, quantity]
+      properties:
+        sku: {type: string}
+        quantity: {type: integer}
+        note: {type: string}
+  securitySchemes:
+    bearerAuth:
+      type: http
+      scheme: bearer
+    apiKey:
+      type: apiKey
+      in: header
+      name: X-API-Key
+`
+
+func TestImportOpenAPI(t *testing.T) {
+	dir := t.TempDir()
+	spec := filepath.Join(dir, "orders.yaml")
+	writeFile(t, spec, ordersSpec)
+
+	docs, err := ImportOpenAPI(spec, OpenAPIByTag)
+	if err != nil {
+		t.Fatalf("ImportOpenAPI() error = %v", err)
+	}
+	paths := make(map[string]CommandDoc)
+	for _, d := range docs {
+		paths[d.Path] = d.Doc
+	}
+	orders, ok := paths["orders-api/orders.md"]
+	if !ok || len(paths) != 2 {
+		t.Fatalf("imported %v, want orders-api/orders.md and orders-api/default.md", paths)
+	}
+
+	want := map[string]string{
+		"Get the status of an order":  `curl -sS "https://eu.orders.internal/v2/orders/<order-id>" -H "Authorization: Bearer $ORDERS_API_TOKEN"`,
+		"Cancel an order":             `curl -sS -X DELETE "https://eu.orders.internal/v2/orders/<order-id>?reason=<reason>" -H "Authorization: Bearer $ORDERS_API_TOKEN"`,
+		"Create an order":             `jq -n --arg sku <sku> --argjson quantity <quantity> '$ARGS.named' | curl -sS -X POST "https://eu.orders.internal/v2/orders" -H "Authorization: Bearer $ORDERS_API_TOKEN" -H "Content-Type: application/json" --data-binary @-`,
+		"Create an order with httpie": `http POST "https://eu.orders.internal/v2/orders" Authorization:"Bearer $ORDERS_API_TOKEN" sku=<sku> quantity:=<quantity>`,
+	}
+	got := make(map[string]string)
+	for _, ex := range orders.Examples {
+		got[ex.UserRequest] = ex.Command
+	}
+	for request, command := range want {
+		if got[request] != command {
+			t.Errorf("example %q:\n got %s\nwant %s", request, got[request], command)
+		}
+	}
+
+	// Body values are filled outside quotes and escaped by jq, so a value
+	// with quotes in it still makes valid JSON
+	create := got["Create an order"]
+	filled := placeholder.Fill(create, placeholder.Find(create), map[string]string{"sku": `say "hi"`, "quantity": "2"})
+	if !strings.HasPrefix(filled, `jq -n --arg sku 'say "hi"' --argjson quantity 2 '$ARGS.named' |`) {
+		t.Errorf("filled body = %s", filled)
+	}
+
+	if spec := orders.Placeholders["order-id"]; spec.Description != "The order's ID" {
+		t.Errorf("order-id placeholder = %+v", spec)
+	}
+	if spec := orders.Placeholders["reason"]; !strings.Contains(spec.Description, "duplicate, fraud") {
+		t.Errorf("reason placeholder = %+v, want the enum values", spec)
+	}
+	if _, ok := orders.Placeholders["expand"]; ok {
+		t.Error("optional query parameters shouldn't be placeholders")
+	}
+
+	health := paths["orders-api/default.md"].Examples
+	if len(health) == 0 || !strings.Contains(health[0].Command, `-H "X-API-Key: $ORDERS_API_API_KEY"`) {
+		t.Errorf("health examples = %+v, want the operation's API key", health)
+	}
+
+	byOperation, err := ImportOpenAPI(spec, OpenAPIByOperation)
+	if err != nil || len(byOperation) != 4 || byOperation[0].Path != "orders-api/get-health.md" {
+		t.Errorf("ImportOpenAPI(operation) = %d docs (first %v), %v", len(byOperation), byOperation, err)
+	}
+
+	writeFile(t, filepath.Join(dir, "swagger.json"), `{"swagger": "2.0", "paths": {}}`)
+	if _, err := ImportOpenAPI(filepath.Join(dir, "swagger.json"), OpenAPIByTag); err == nil {
+		t.Error("expected an error for a Swagger 2 document")
+	}
+}
+
+func TestImportOpenAPIRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	spec := filepath.Join(dir, "orders.yaml")
+	writeFile(t, spec, ordersSpec)
+
+	docs, err := ImportOpenAPI(spec, OpenAPIByTag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := t.TempDir()
+	if _, _, err := WriteImported(out, docs, false); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := NewLoader().LoadAll(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var orders *CommandDoc
+	for i := range loaded {
+		if loaded[i].Name() == "orders-api/orders" {
+			orders = &loaded[i]
+		}
+	}
+	if orders == nil {
+		t.Fatalf("orders-api/orders didn't load: %+v", loaded)
+	}
+	if len(orders.Examples) != 6 || orders.Examples[0].Command != docs[1].Doc.Examples[0].Command {
+		t.Errorf("loaded examples = %+v", orders.Examples)
+	}
+
+	result, err := Lint([]string{out})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, issue := range result.Issues {
+		t.Errorf("lint: %s", issue)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/iishyfishyy/please/internal/placeholder"
 	"gopkg.in/yaml.v3"
 )
 
@@ -26,6 +27,9 @@ type Frontmatter struct {
 	Categories []string `yaml:"categories"`
 	Priority   string   `yaml:"priority"`
 	Version    string   `yaml:"version"`
+	OS         []string `yaml:"os"`
+
+	Placeholders map[string]placeholder.Spec `yaml:"placeholders"`
 }
 
 // Parse parses a markdown file with frontmatter
@@ -58,26 +62,57 @@ func (p *Parser) Parse(filepath string) (*CommandDoc, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse frontmatter: %w", err)
 	}
+	if frontmatter == nil {
+		return nil, fmt.Errorf("missing frontmatter: docs must start with a --- block declaring command:")
+	}
+	if strings.TrimSpace(frontmatter.Command) == "" {
+		return nil, fmt.Errorf("missing required field: command")
+	}
 
 	// Parse examples from content
 	examples := p.parseExamples(content)
 
 	doc := &CommandDoc{
-		Filename:   filepath,
-		Command:    frontmatter.Command,
-		Aliases:    frontmatter.Aliases,
-		Keywords:   frontmatter.Keywords,
-		Categories: frontmatter.Categories,
-		Priority:   frontmatter.Priority,
-		Version:    frontmatter.Version,
-		Content:    content,
-		Examples:   examples,
-		UpdatedAt:  info.ModTime(),
+		Filename:     filepath,
+		Command:      frontmatter.Command,
+		Aliases:      frontmatter.Aliases,
+		Keywords:     frontmatter.Keywords,
+		Categories:   frontmatter.Categories,
+		Priority:     frontmatter.Priority,
+		Version:      frontmatter.Version,
+		OS:           normalizeOS(frontmatter.OS),
+		Content:      content,
+		Placeholders: normalizePlaceholders(frontmatter.Placeholders),
+		Examples:     examples,
+		UpdatedAt:    info.ModTime(),
 	}
 
 	return doc, nil
 }
 
+// osAliases maps common OS names to Go's runtime.GOOS values
+var osAliases = map[string]string{
+	"macos": "darwin",
+	"mac":   "darwin",
+	"osx":   "darwin",
+	"win":   "windows",
+}
+
+// normalizeOS lowercases OS names and maps aliases like "macos" to GOOS values
+func normalizeOS(systems []string) []string {
+	var normalized []string
+	for _, name := range systems {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if alias, ok := osAliases[name]; ok {
+			name = alias
+		}
+		if name != "" {
+			normalized = append(normalized, name)
+		}
+	}
+	return normalized
+}
+
 // parseFrontmatter extracts YAML frontmatter and returns it with the remaining content
 func (p *Parser) parseFrontmatter(lines []string) (*Frontmatter, string, error) {
 	if len(lines) == 0 {
@@ -118,20 +153,35 @@ func (p *Parser) parseFrontmatter(lines []string) (*Frontmatter, string, error)
 	return &fm, content, nil
 }
 
+// normalizePlaceholders keys placeholder specs by their normalised name
+func normalizePlaceholders(specs map[string]placeholder.Spec) map[string]placeholder.Spec {
+	if len(specs) == 0 {
+		return nil
+	}
+	normalized := make(map[string]placeholder.Spec, len(specs))
+	for name, spec := range specs {
+		normalized[placeholder.Normalize(name)] = spec
+	}
+	return normalized
+}
+
+// Example lines, with or without markdown emphasis on the label:
+//
+//	User: "request text"
+//	**Command**: actual command
+var (
+	exampleUserRe    = regexp.MustCompile(`(?i)(?:User|Request)\*{0,2}:\*{0,2}\s*["'](.+?)["']`)
+	exampleCommandRe = regexp.MustCompile(`(?i)Command\*{0,2}:\*{0,2}\s*(.+)`)
+)
+
 // parseExamples extracts examples from the markdown content
 // Looks for patterns like:
-//   User: "show me all pods"
-//   Command: kubectl get pods -A
+//
+//	User: "show me all pods"
+//	Command: kubectl get pods -A
 func (p *Parser) parseExamples(content string) []Example {
 	var examples []Example
 
-	// Pattern to match example blocks
-	// Looking for lines like:
-	// User: "request text"
-	// Command: actual command
-	userPattern := regexp.MustCompile(`(?i)(?:User|Request):\s*["'](.+?)["']`)
-	commandPattern := regexp.MustCompile(`(?i)Command:\s*(.+)`)
-
 	lines := strings.Split(content, "\n")
 	var currentUserRequest string
 
@@ -139,14 +189,14 @@ func (p *Parser) parseExamples(content string) []Example {
 		line = strings.TrimSpace(line)
 
 		// Check for user request
-		if matches := userPattern.FindStringSubmatch(line); len(matches) > 1 {
+		if matches := exampleUserRe.FindStringSubmatch(line); len(matches) > 1 {
 			currentUserRequest = matches[1]
 			continue
 		}
 
 		// Check for command (must follow a user request)
 		if currentUserRequest != "" {
-			if matches := commandPattern.FindStringSubmatch(line); len(matches) > 1 {
+			if matches := exampleCommandRe.FindStringSubmatch(line); len(matches) > 1 {
 				command := strings.TrimSpace(matches[1])
 				// Remove backticks if present
 				command = strings.Trim(command, "`")
@@ -0,0 +1,134 @@
+package customcmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/iishyfishyy/please/internal/ui"
+)
+
+// systemCommandsDir holds command docs shared by every user on the machine
+var systemCommandsDir = defaultSystemCommandsDir()
+
+func defaultSystemCommandsDir() string {
+	if runtime.GOOS == "windows" {
+		if dir := os.Getenv("ProgramData"); dir != "" {
+			return filepath.Join(dir, "please", "commands")
+		}
+	}
+	return "/etc/please/commands"
+}
+
+// CommandDir is a directory of command docs and the source its docs get
+type CommandDir struct {
+	Source string // SourceProject, SourceUser, GitSourceName(name) or SourceSystem
+	Path   string
+}
+
+// GitSourceName returns the doc source for a git source's docs
+func GitSourceName(name string) string {
+	return SourceGit + ":" + name
+}
+
+// GetCommandDirs returns the directories command docs are loaded from,
+// highest precedence first: the nearest .please/commands above the working
+// directory (if any), the user's ~/.please/commands, the git sources in the
+// order they were added, and the system-wide directory. Directories that
+// don't exist are included; they load no docs. An unreadable sources file is
+// skipped with a warning, so it doesn't hide every other doc.
+func GetCommandDirs() ([]CommandDir, error) {
+	userDir, err := GetCommandsDir()
+	if err != nil {
+		return nil, err
+	}
+
+	var dirs []CommandDir
+	if cwd, err := os.Getwd(); err == nil {
+		if projectDir, ok := findProjectCommandsDir(cwd, userDir); ok {
+			dirs = append(dirs, CommandDir{Source: SourceProject, Path: projectDir})
+		}
+	}
+	dirs = append(dirs, CommandDir{Source: SourceUser, Path: userDir})
+
+	sources, err := LoadSources()
+	if err != nil {
+		ui.ShowWarning(fmt.Sprintf("Ignoring git sources: %v", err))
+		sources = &Sources{}
+	}
+	for i := range sources.Sources {
+		path, err := sources.Sources[i].CommandsPath()
+		if err != nil {
+			return nil, err
+		}
+		dirs = append(dirs, CommandDir{Source: GitSourceName(sources.Sources[i].Name), Path: path})
+	}
+
+	dirs = append(dirs, CommandDir{Source: SourceSystem, Path: systemCommandsDir})
+	return dirs, nil
+}
+
+// findProjectCommandsDir walks up from start looking for a .please/commands
+// directory, skipping the user's own (found when start is under $HOME)
+func findProjectCommandsDir(start, userDir string) (string, bool) {
+	userDir = resolvePath(userDir)
+
+	dir := start
+	for {
+		candidate := filepath.Join(dir, ".please", "commands")
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() && resolvePath(candidate) != userDir {
+			return candidate, true
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// resolvePath returns path with symlinks resolved, so the same directory
+// reached two ways compares equal
+func resolvePath(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return filepath.Clean(path)
+}
+
+// GetScopeCachePath returns the embeddings cache for a commands directory.
+// The user's docs keep ~/.please/embeddings.db; project, git source and
+// system docs get their own caches under ~/.please/cache, so switching
+// projects doesn't re-embed anything and nothing is written into the
+// project, source or system dirs.
+func GetScopeCachePath(dir CommandDir) (string, error) {
+	userCache, err := GetEmbeddingsCachePath()
+	if err != nil {
+		return "", err
+	}
+
+	cacheDir := filepath.Join(filepath.Dir(userCache), "cache")
+	if name, ok := strings.CutPrefix(dir.Source, SourceGit+":"); ok {
+		return filepath.Join(cacheDir, "sources", name, "embeddings.db"), nil
+	}
+
+	switch dir.Source {
+	case SourceUser:
+		return userCache, nil
+	case SourceSystem:
+		return filepath.Join(cacheDir, "system", "embeddings.db"), nil
+	case SourceProject:
+		// .../myrepo/.please/commands → myrepo-<hash of the full path>
+		root := filepath.Dir(filepath.Dir(dir.Path))
+		sum := sha256.Sum256([]byte(resolvePath(dir.Path)))
+		name := filepath.Base(root) + "-" + hex.EncodeToString(sum[:6])
+		return filepath.Join(cacheDir, "projects", name, "embeddings.db"), nil
+	default:
+		return "", fmt.Errorf("unknown command source: %s", dir.Source)
+	}
+}
@@ -0,0 +1,156 @@
+package customcmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/iishyfishyy/please/internal/config"
+)
+
+func writeDoc(t *testing.T, dir, command, body string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := "---\ncommand: " + command + "\n---\n\n" + body + "\n"
+	if err := os.WriteFile(filepath.Join(dir, command+".md"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFindProjectCommandsDir(t *testing.T) {
+	root := t.TempDir()
+	outer := filepath.Join(root, "repo", ".please", "commands")
+	inner := filepath.Join(root, "repo", "services", "api", ".please", "commands")
+	deep := filepath.Join(root, "repo", "services", "api", "cmd", "server")
+	for _, dir := range []string{outer, inner, deep} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		start, userDir string
+		want           string
+	}{
+		{deep, "", inner}, // Nearest wins
+		{filepath.Join(root, "repo", "services"), "", outer},
+		{deep, inner, outer}, // The user's own dir isn't a project
+		{root, "", ""},
+	}
+	for _, tt := range tests {
+		got, ok := findProjectCommandsDir(tt.start, tt.userDir)
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("findProjectCommandsDir(%s) = %q, %v; want %q", tt.start, got, ok, tt.want)
+		}
+	}
+}
+
+// layeredManager sets up project, user and system command dirs, with the
+// working directory inside the project
+func layeredManager(t *testing.T) *Manager {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	system := t.TempDir()
+	oldSystem := systemCommandsDir
+	systemCommandsDir = system
+	t.Cleanup(func() { systemCommandsDir = oldSystem })
+
+	project := filepath.Join(t.TempDir(), "webapp")
+	writeDoc(t, filepath.Join(project, ".please", "commands"), "deploy", "Deploy the webapp with make deploy")
+	writeDoc(t, filepath.Join(home, ".please", "commands"), "deploy", "Deploy anything with my personal script")
+	writeDoc(t, filepath.Join(home, ".please", "commands"), "psql", "Connect to the postgres database")
+	writeDoc(t, system, "psql", "Company-wide postgres access")
+	writeDoc(t, system, "vpn", "Connect to the corporate vpn")
+
+	sub := filepath.Join(project, "src", "handlers")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(sub)
+
+	m, err := NewManager()
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	return m
+}
+
+func TestManagerLoadsLayeredDirs(t *testing.T) {
+	m := layeredManager(t)
+
+	var sources []string
+	for _, dir := range m.Dirs() {
+		sources = append(sources, dir.Source)
+	}
+	if strings.Join(sources, ",") != "project,user,system" {
+		t.Fatalf("Dirs() sources = %v, want project, user, system", sources)
+	}
+
+	if err := m.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	got := make(map[string]CommandDoc)
+	for _, doc := range m.GetDocs() {
+		got[doc.Command] = doc
+	}
+	want := map[string]string{"deploy": SourceProject, "psql": SourceUser, "vpn": SourceSystem}
+	if len(got) != len(want) {
+		t.Errorf("loaded %d docs, want %d", len(got), len(want))
+	}
+	for command, source := range want {
+		if doc := got[command]; doc.Source != source {
+			t.Errorf("%s loaded from %q, want %q", command, doc.Source, source)
+		}
+	}
+	if !strings.Contains(got["deploy"].Content, "make deploy") {
+		t.Error("project deploy doc didn't override the user's")
+	}
+
+	if ok, err := HasCommands(); err != nil || !ok {
+		t.Errorf("HasCommands() = %v, %v", ok, err)
+	}
+}
+
+func TestManagerIndexesEachDirSeparately(t *testing.T) {
+	m := layeredManager(t)
+	m.SetEmbeddingConfig(*config.NewDefaultCustomCommands(config.ProviderLocal))
+	m.SetMatchingConfig(config.MatchingConfig{Strategy: "semantic", MinScore: 0.1})
+
+	if err := m.Index(context.Background(), false); err != nil {
+		t.Fatalf("Index() error = %v", err)
+	}
+
+	for _, dir := range m.Dirs() {
+		path, err := GetScopeCachePath(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("no %s embeddings cache at %s", dir.Source, path)
+		}
+		if dir.Source != SourceUser && !strings.Contains(path, filepath.Join(".please", "cache")) {
+			t.Errorf("%s cache %s isn't under ~/.please/cache", dir.Source, path)
+		}
+	}
+
+	// The user's deploy doc is cached but overridden by the project's
+	docs := m.GetRelevantDocs("deploy with my personal script", 3)
+	for _, doc := range docs {
+		if doc.Command == "deploy" && doc.Source != SourceProject {
+			t.Errorf("deploy matched from %s, want project", doc.Source)
+		}
+	}
+	if len(docs) == 0 || docs[0].Command != "deploy" {
+		t.Errorf("GetRelevantDocs() = %v, want deploy first", commands(docs))
+	}
+
+	// Reindexing loads from the caches
+	if err := m.Index(context.Background(), false); err != nil {
+		t.Fatalf("second Index() error = %v", err)
+	}
+}
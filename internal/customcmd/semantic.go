@@ -2,17 +2,37 @@ package customcmd
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/iishyfishyy/please/internal/customcmd/embeddings"
 	"github.com/iishyfishyy/please/internal/customcmd/vectorstore"
 )
 
+// Section matching: how many vectors to fetch per requested doc, how close
+// to its doc's best score a section must be to be included, and how many
+// sections to include per doc
+const (
+	chunkFanout      = 10
+	chunkScoreMargin = 0.1
+	maxMatchedChunks = 3
+)
+
 // SemanticMatcher performs semantic search using embeddings
 type SemanticMatcher struct {
 	embedder    embeddings.Embedder
 	vectorStore vectorstore.Store
+	docs        map[string]CommandDoc // Indexed docs by vector ID
+	chunks      map[string]chunkRef   // Indexed sections by vector ID
 	indexed     bool
+	progress    func(done, total int)
+	debug       bool
 }
 
 // NewSemanticMatcher creates a new semantic matcher
@@ -24,10 +44,60 @@ func NewSemanticMatcher(embedder embeddings.Embedder, store vectorstore.Store) *
 	return &SemanticMatcher{
 		embedder:    embedder,
 		vectorStore: store,
+		docs:        make(map[string]CommandDoc),
 		indexed:     false,
 	}
 }
 
+// NewCachedSemanticMatcher creates a semantic matcher over a store that already
+// holds embeddings for docs (e.g. a valid SQLite cache), without re-embedding
+func NewCachedSemanticMatcher(embedder embeddings.Embedder, store vectorstore.Store, docs []CommandDoc) *SemanticMatcher {
+	s := NewSemanticMatcher(embedder, store)
+	s.SetDocs(docs)
+	s.indexed = true
+	return s
+}
+
+// chunkRef locates a chunk within its doc
+type chunkRef struct {
+	chunk Chunk
+	index int // Position within the doc, for ordering matched sections
+}
+
+// SetDocs sets the documents (and their sections) that search results are
+// mapped back to
+func (s *SemanticMatcher) SetDocs(docs []CommandDoc) {
+	s.docs = make(map[string]CommandDoc, len(docs))
+	s.chunks = make(map[string]chunkRef)
+	for _, doc := range docs {
+		s.docs[docID(doc)] = doc
+		for i, chunk := range ChunkDoc(doc) {
+			s.chunks[chunk.ID] = chunkRef{chunk: chunk, index: i}
+		}
+	}
+}
+
+// SetDebug enables or disables debug logging
+func (s *SemanticMatcher) SetDebug(debug bool) {
+	s.debug = debug
+}
+
+// SetProgress sets a callback reporting how many texts have been embedded
+// during indexing. It may be called from several goroutines.
+func (s *SemanticMatcher) SetProgress(progress func(done, total int)) {
+	s.progress = progress
+}
+
+// IsIndexed returns whether embeddings are available for searching
+func (s *SemanticMatcher) IsIndexed() bool {
+	return s.indexed
+}
+
+// docID returns the vector store ID for a document
+func docID(doc CommandDoc) string {
+	return fmt.Sprintf("cmd_%s", doc.Name())
+}
+
 // Index creates embeddings for all command documents
 func (s *SemanticMatcher) Index(ctx context.Context, docs []CommandDoc) error {
 	if s.embedder == nil {
@@ -36,36 +106,231 @@ func (s *SemanticMatcher) Index(ctx context.Context, docs []CommandDoc) error {
 
 	// Clear existing vectors
 	s.vectorStore.Clear(ctx)
+	s.SetDocs(docs)
+
+	// Create embeddings for each document and its sections
+	if err := s.IndexDocs(ctx, docs); err != nil {
+		return err
+	}
+
+	s.markIndexed()
+	return nil
+}
+
+// Update re-embeds only the changed docs, keeping the vectors already stored
+// for the rest. Vectors of changed and removed docs must have been deleted
+// from the store beforehand.
+func (s *SemanticMatcher) Update(ctx context.Context, docs, changed []CommandDoc) error {
+	if s.embedder == nil {
+		return fmt.Errorf("no embedder configured")
+	}
+
+	s.SetDocs(docs)
+	if err := s.IndexDocs(ctx, changed); err != nil {
+		return err
+	}
+
+	s.markIndexed()
+	return nil
+}
+
+// persistentStore is a vector store that records when it was indexed, such
+// as SQLiteStore and HNSWStore
+type persistentStore interface {
+	UpdateIndexTime() error
+	SetDimensions(dims int) error
+}
+
+// markIndexed records that the store holds embeddings for the current docs
+func (s *SemanticMatcher) markIndexed() {
+	s.indexed = true
+
+	// Update indexed_at timestamp (and the vector size, which some embedders
+	// only learn from their first response) if the store is persistent
+	if persistent, ok := s.vectorStore.(persistentStore); ok {
+		if dims := s.embedder.Dimensions(); dims > 0 {
+			persistent.SetDimensions(dims)
+		}
+		persistent.UpdateIndexTime()
+	}
+}
 
-	// Create embeddings for each document
+// embedBatchSize is how many texts are sent to the embedder per request, and
+// embedConcurrency how many batches are embedded at once. Embedders that
+// split a batch into several requests, like Ollama, cap those requests
+// across batches themselves.
+const (
+	embedBatchSize   = 64
+	embedConcurrency = 4
+)
+
+// embedItem is one text to embed and where to store its vector
+type embedItem struct {
+	id       string
+	text     string
+	metadata map[string]interface{}
+}
+
+// embedItems lists what gets embedded for a doc: its summary (name,
+// keywords, example requests) under the doc ID, and each section of its body
+// under the section's chunk ID
+func embedItems(doc CommandDoc) []embedItem {
+	// Store with metadata
+	hash := contentHash(doc)
+	metadata := func(heading string) map[string]interface{} {
+		m := scopeMetadata(doc)
+		m["command"] = doc.Command
+		m["filename"] = doc.Filename
+		m["file_mtime"] = doc.UpdatedAt.Unix()
+		m["content_hash"] = hash // For incremental re-indexing
+		if heading != "" {
+			m["chunk"] = heading
+		}
+		return m
+	}
+
+	// Combine command name, keywords, and examples into searchable text
+	items := []embedItem{{id: docID(doc), text: buildSearchText(doc), metadata: metadata("")}}
+
+	for _, chunk := range ChunkDoc(doc) {
+		heading := chunk.Heading
+		if heading == "" {
+			heading = "(intro)"
+		}
+		items = append(items, embedItem{id: chunk.ID, text: chunkSearchText(doc, chunk), metadata: metadata(heading)})
+	}
+
+	return items
+}
+
+// scopeMetadata returns the fields of a doc that searches can be scoped by,
+// keyed as vectorstore.Filter expects
+func scopeMetadata(doc CommandDoc) map[string]interface{} {
+	return map[string]interface{}{
+		vectorstore.MetaCategories: doc.Categories,
+		vectorstore.MetaPriority:   doc.Priority,
+		vectorstore.MetaSource:     doc.Source,
+		vectorstore.MetaOS:         doc.OS,
+	}
+}
+
+// contentHash fingerprints everything that goes into a doc's embeddings and
+// their metadata, so a doc is only re-embedded when that changes
+func contentHash(doc CommandDoc) string {
+	h := sha256.New()
+	h.Write([]byte(docID(doc)))
+	for _, field := range [][]string{doc.Categories, {doc.Priority}, {doc.Source}, doc.OS} {
+		h.Write([]byte{0})
+		h.Write([]byte(strings.Join(field, ",")))
+	}
+	h.Write([]byte{0})
+	h.Write([]byte(buildSearchText(doc)))
+	for _, chunk := range ChunkDoc(doc) {
+		h.Write([]byte{0})
+		h.Write([]byte(chunk.ID))
+		h.Write([]byte{0})
+		h.Write([]byte(chunkSearchText(doc, chunk)))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// IndexDocs embeds docs in batches and adds them to the store, without
+// clearing what's already there. Up to embedConcurrency batches are in
+// flight at once; the first failure cancels the rest.
+func (s *SemanticMatcher) IndexDocs(ctx context.Context, docs []CommandDoc) error {
+	var items []embedItem
 	for _, doc := range docs {
-		// Combine command name, keywords, and examples into searchable text
-		searchText := s.buildSearchText(doc)
+		items = append(items, embedItems(doc)...)
+	}
 
-		// Generate embedding
-		embedding, err := s.embedder.Embed(ctx, searchText)
-		if err != nil {
-			return fmt.Errorf("failed to embed doc %s: %w", doc.Command, err)
+	var batches [][]embedItem
+	for start := 0; start < len(items); start += embedBatchSize {
+		end := start + embedBatchSize
+		if end > len(items) {
+			end = len(items)
 		}
+		batches = append(batches, items[start:end])
+	}
 
-		// Store with metadata
-		metadata := map[string]interface{}{
-			"command":    doc.Command,
-			"filename":   doc.Filename,
-			"file_mtime": doc.UpdatedAt.Unix(), // For cache validation
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex // Guards done and firstErr
+		done     int
+		firstErr error
+	)
+	fail := func(err error) {
+		mu.Lock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
 		}
+		mu.Unlock()
+	}
+
+	next := make(chan []embedItem)
+	workers := embedConcurrency
+	if workers > len(batches) {
+		workers = len(batches)
+	}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range next {
+				if err := s.embedBatch(ctx, batch); err != nil {
+					fail(err)
+					continue
+				}
+
+				mu.Lock()
+				done += len(batch)
+				if s.progress != nil {
+					s.progress(done, len(items))
+				}
+				mu.Unlock()
+			}
+		}()
+	}
 
-		id := fmt.Sprintf("cmd_%s", doc.Command)
-		if err := s.vectorStore.Add(ctx, id, embedding, metadata); err != nil {
-			return fmt.Errorf("failed to store embedding for %s: %w", doc.Command, err)
+feed:
+	for _, batch := range batches {
+		select {
+		case next <- batch:
+		case <-ctx.Done():
+			break feed
 		}
 	}
+	close(next)
+	wg.Wait()
 
-	s.indexed = true
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
 
-	// Update indexed_at timestamp if using SQLiteStore
-	if sqlStore, ok := s.vectorStore.(*vectorstore.SQLiteStore); ok {
-		sqlStore.UpdateIndexTime()
+// embedBatch embeds one batch of items and stores the vectors
+func (s *SemanticMatcher) embedBatch(ctx context.Context, batch []embedItem) error {
+	texts := make([]string, len(batch))
+	for i, item := range batch {
+		texts[i] = item.text
+	}
+
+	vectors, err := s.embedder.EmbedBatch(ctx, texts)
+	if err != nil {
+		return fmt.Errorf("failed to embed %s: %w", batch[0].metadata["command"], err)
+	}
+	if len(vectors) != len(batch) {
+		return fmt.Errorf("embedder returned %d vectors for %d texts", len(vectors), len(batch))
+	}
+
+	for i, item := range batch {
+		if err := s.vectorStore.Add(ctx, item.id, vectors[i], item.metadata); err != nil {
+			return fmt.Errorf("failed to store embedding for %s: %w", item.id, err)
+		}
 	}
 
 	return nil
@@ -73,6 +338,11 @@ func (s *SemanticMatcher) Index(ctx context.Context, docs []CommandDoc) error {
 
 // Search finds relevant documents using semantic similarity
 func (s *SemanticMatcher) Search(ctx context.Context, query string, topK int) ([]CommandDoc, []float32, error) {
+	return s.SearchFiltered(ctx, query, topK, vectorstore.Filter{})
+}
+
+// SearchFiltered finds relevant documents within a scope using semantic similarity
+func (s *SemanticMatcher) SearchFiltered(ctx context.Context, query string, topK int, filter vectorstore.Filter) ([]CommandDoc, []float32, error) {
 	if !s.indexed {
 		return nil, nil, fmt.Errorf("not indexed")
 	}
@@ -83,26 +353,97 @@ func (s *SemanticMatcher) Search(ctx context.Context, query string, topK int) ([
 		return nil, nil, fmt.Errorf("failed to embed query: %w", err)
 	}
 
-	// Search vector store
-	results, err := s.vectorStore.Search(ctx, queryEmbed, topK)
+	// Search vector store, fetching enough section hits to rank topK docs
+	results, err := s.vectorStore.SearchFiltered(ctx, queryEmbed, topK*chunkFanout, filter)
 	if err != nil {
 		return nil, nil, fmt.Errorf("search failed: %w", err)
 	}
 
-	// For now, return empty since we need to map back to docs
-	// This would require storing doc references in metadata
-	scores := make([]float32, len(results))
-	for i, result := range results {
-		scores[i] = result.Score
+	// Aggregate section hits back to their docs: a doc scores as its best
+	// vector, and keeps the sections that scored close to that
+	type docHit struct {
+		doc    CommandDoc
+		score  float32
+		chunks []chunkRef
 	}
+	hits := make(map[string]*docHit)
+	var order []string
+
+	for _, result := range results {
+		id := chunkDocID(result.ID)
+		doc, ok := s.docs[id]
+		if !ok {
+			// Cached vector for a doc that's no longer loaded
+			if s.debug {
+				fmt.Fprintf(os.Stderr, "[DEBUG] Semantic: ignoring result %s (no matching doc)\n", result.ID)
+			}
+			continue
+		}
+		if source, _ := result.Metadata[vectorstore.MetaSource].(string); source != "" && source != doc.Source {
+			// Cached vector for a doc overridden by one from another directory
+			continue
+		}
+
+		hit, ok := hits[id]
+		if !ok {
+			// Results arrive best first, so the first hit sets the doc's score
+			hit = &docHit{doc: doc, score: result.Score}
+			hits[id] = hit
+			order = append(order, id)
+		}
+
+		if ref, ok := s.chunks[result.ID]; ok && result.Score >= hit.score-chunkScoreMargin && len(hit.chunks) < maxMatchedChunks {
+			hit.chunks = append(hit.chunks, ref)
+		}
+	}
+
+	if len(order) > topK {
+		order = order[:topK]
+	}
+
+	docs := make([]CommandDoc, 0, len(order))
+	scores := make([]float32, 0, len(order))
+	for _, id := range order {
+		hit := hits[id]
+		sort.Slice(hit.chunks, func(i, j int) bool { return hit.chunks[i].index < hit.chunks[j].index })
 
-	return nil, scores, nil
+		doc := hit.doc
+		doc.MatchedChunks = make([]Chunk, len(hit.chunks))
+		for i, ref := range hit.chunks {
+			doc.MatchedChunks[i] = ref.chunk
+		}
+
+		docs = append(docs, doc)
+		scores = append(scores, hit.score)
+		if s.debug {
+			fmt.Fprintf(os.Stderr, "[DEBUG] Semantic:   %s scored %.3f (%d matching sections)\n", doc.Command, hit.score, len(doc.MatchedChunks))
+			for _, chunk := range doc.MatchedChunks {
+				fmt.Fprintf(os.Stderr, "[DEBUG] Semantic:     - %s\n", chunk.ID)
+			}
+		}
+	}
+
+	return docs, scores, nil
+}
+
+// chunkSearchText creates searchable text for a section, prefixed with the
+// command and heading so the section is embedded in context
+func chunkSearchText(doc CommandDoc, chunk Chunk) string {
+	if chunk.Heading == "" {
+		return doc.Command + "\n" + chunk.Content
+	}
+	return doc.Command + ": " + chunk.Heading + "\n" + chunk.Content
 }
 
 // buildSearchText creates searchable text from a document
-func (s *SemanticMatcher) buildSearchText(doc CommandDoc) string {
+func buildSearchText(doc CommandDoc) string {
 	text := doc.Command
 
+	// Add the namespace, e.g. "k8s helm" for k8s/helm
+	for _, part := range namespaceCategories(doc.Namespace) {
+		text += " " + part
+	}
+
 	// Add aliases
 	for _, alias := range doc.Aliases {
 		text += " " + alias
@@ -121,12 +462,19 @@ func (s *SemanticMatcher) buildSearchText(doc CommandDoc) string {
 	return text
 }
 
+// defaultKeywordWeight is the keyword share of the hybrid score when unset
+const defaultKeywordWeight = 0.5
+
 // HybridMatcher combines keyword and semantic matching
 type HybridMatcher struct {
 	keywordMatcher  *Matcher
 	semanticMatcher *SemanticMatcher
-	strategy        string // "keyword", "semantic", "hybrid"
-	threshold       int    // Score threshold for keyword matches
+	strategy        string  // "keyword", "semantic", "hybrid"
+	threshold       int     // Keyword scores below this percentage don't count
+	keywordWeight   float64 // Share of the fused score from keywords (0-1)
+	minScore        float64 // Fused/semantic scores below this are dropped
+	scope           vectorstore.Filter
+	debug           bool
 }
 
 // NewHybridMatcher creates a new hybrid matcher
@@ -136,9 +484,20 @@ func NewHybridMatcher(embedder embeddings.Embedder, strategy string, threshold i
 		semanticMatcher: NewSemanticMatcher(embedder, nil), // Use MemoryStore by default
 		strategy:        strategy,
 		threshold:       threshold,
+		keywordWeight:   defaultKeywordWeight,
 	}
 }
 
+// SetFusion sets how keyword and semantic scores are combined: the keyword
+// share of the fused score, and the minimum score a doc needs to be returned
+func (h *HybridMatcher) SetFusion(keywordWeight, minScore float64) {
+	if keywordWeight <= 0 || keywordWeight > 1 {
+		keywordWeight = defaultKeywordWeight
+	}
+	h.keywordWeight = keywordWeight
+	h.minScore = minScore
+}
+
 // SetDocs sets the documents for both matchers
 func (h *HybridMatcher) SetDocs(docs []CommandDoc) {
 	h.keywordMatcher.SetDocs(docs)
@@ -154,39 +513,164 @@ func (h *HybridMatcher) IndexSemantic(ctx context.Context, docs []CommandDoc) er
 
 // FindRelevantDocs finds relevant docs using hybrid strategy
 func (h *HybridMatcher) FindRelevantDocs(ctx context.Context, request string, maxDocs int) ([]CommandDoc, error) {
+	semanticReady := h.semanticMatcher != nil && h.semanticMatcher.indexed
+
 	switch h.strategy {
 	case "keyword":
 		// Keyword only
-		return h.keywordMatcher.FindRelevantDocs(request, maxDocs), nil
+		return h.keywordDocs(request, maxDocs), nil
 
 	case "semantic":
-		// Semantic only
-		docs, _, err := h.semanticMatcher.Search(ctx, request, maxDocs)
-		return docs, err
+		// Semantic only, falling back to keywords if embeddings aren't available
+		if !semanticReady {
+			return h.keywordDocs(request, maxDocs), nil
+		}
+		docs, scores, err := h.semanticMatcher.SearchFiltered(ctx, request, maxDocs, h.scope)
+		if err != nil {
+			return nil, err
+		}
+		var results []CommandDoc
+		for i, doc := range docs {
+			if float64(scores[i]) < h.minScore {
+				if h.debug {
+					fmt.Fprintf(os.Stderr, "[DEBUG] Hybrid:   %s semantic=%.3f below min score %.2f\n", doc.Command, scores[i], h.minScore)
+				}
+				continue
+			}
+			results = append(results, doc)
+		}
+		return results, nil
 
 	case "hybrid":
-		// Try keyword first
-		keywordDocs := h.keywordMatcher.FindRelevantDocs(request, maxDocs)
+		return h.fuse(ctx, request, maxDocs, semanticReady), nil
+
+	default:
+		return h.keywordDocs(request, maxDocs), nil
+	}
+}
+
+// keywordScores returns the keyword matches within scope, best first
+func (h *HybridMatcher) keywordScores(request string) []ScoredDoc {
+	scored := h.keywordMatcher.Score(request)
+	if h.scope.Empty() {
+		return scored
+	}
+	inScope := scored[:0:0]
+	for _, s := range scored {
+		if h.scope.Matches(scopeMetadata(s.Doc)) {
+			inScope = append(inScope, s)
+		}
+	}
+	return inScope
+}
+
+// keywordDocs returns the top keyword matches within scope
+func (h *HybridMatcher) keywordDocs(request string, maxDocs int) []CommandDoc {
+	if h.scope.Empty() {
+		return h.keywordMatcher.FindRelevantDocs(request, maxDocs)
+	}
+	scored := h.keywordScores(request)
+	docs := make([]CommandDoc, 0, minInt(len(scored), maxDocs))
+	for _, s := range scored[:minInt(len(scored), maxDocs)] {
+		docs = append(docs, s.Doc)
+	}
+	return docs
+}
+
+// fusedDoc holds a candidate's component scores during hybrid ranking
+type fusedDoc struct {
+	doc      CommandDoc
+	keyword  float64 // Raw keyword score
+	keywordN float64 // Keyword score normalised to 0-1
+	semantic float64 // Cosine similarity clamped to 0-1
+	score    float64 // Weighted combination
+}
 
-		// If we got good keyword matches (score > threshold), use them
-		if len(keywordDocs) > 0 {
-			// For now, just use keyword matches
-			// In a full implementation, we'd check the actual scores
-			return keywordDocs, nil
+// fuse ranks docs by a weighted sum of normalised keyword and semantic
+// scores. Keyword scores below the threshold count as no keyword match, the
+// rest are scaled by the best match. If semantic search isn't available the
+// keyword score is used on its own.
+func (h *HybridMatcher) fuse(ctx context.Context, request string, maxDocs int, semanticReady bool) []CommandDoc {
+	candidates := make(map[string]*fusedDoc)
+	var order []string
+	candidate := func(doc CommandDoc) *fusedDoc {
+		id := docID(doc)
+		if c, ok := candidates[id]; ok {
+			return c
 		}
+		c := &fusedDoc{doc: doc}
+		candidates[id] = c
+		order = append(order, id)
+		return c
+	}
+
+	keywordScores := h.keywordScores(request)
+	best := 0.0
+	if len(keywordScores) > 0 {
+		best = keywordScores[0].Score
+	}
+	for _, scored := range keywordScores {
+		c := candidate(scored.Doc)
+		c.keyword = scored.Score
+		if scored.Score >= float64(h.threshold) && best > 0 {
+			c.keywordN = scored.Score / best
+		}
+	}
 
-		// Otherwise fall back to semantic search
-		if h.semanticMatcher.indexed {
-			docs, _, err := h.semanticMatcher.Search(ctx, request, maxDocs)
-			if err == nil && len(docs) > 0 {
-				return docs, nil
+	if semanticReady {
+		// Score every doc so keyword hits also get their semantic component
+		docs, scores, err := h.semanticMatcher.SearchFiltered(ctx, request, len(h.semanticMatcher.docs), h.scope)
+		if err != nil {
+			semanticReady = false
+			if h.debug {
+				fmt.Fprintf(os.Stderr, "[DEBUG] Hybrid: semantic search failed, using keyword scores only: %v\n", err)
 			}
 		}
+		for i, doc := range docs {
+			c := candidate(doc)
+			c.doc.MatchedChunks = doc.MatchedChunks
+			c.semantic = math.Max(0, math.Min(1, float64(scores[i])))
+		}
+	}
+
+	weight := h.keywordWeight
+	if weight <= 0 || weight > 1 {
+		weight = defaultKeywordWeight
+	}
+	if !semanticReady {
+		weight = 1
+	}
 
-		// Fall back to keyword matches even if scores are low
-		return keywordDocs, nil
+	ranked := make([]*fusedDoc, 0, len(candidates))
+	for _, id := range order {
+		c := candidates[id]
+		c.score = weight*c.keywordN + (1-weight)*c.semantic
+		ranked = append(ranked, c)
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].score > ranked[j].score
+	})
+
+	var results []CommandDoc
+	for _, c := range ranked {
+		kept := c.score >= h.minScore && c.score > 0 && len(results) < maxDocs
+		if h.debug {
+			status := "kept"
+			if !kept {
+				status = "dropped"
+			}
+			fmt.Fprintf(os.Stderr, "[DEBUG] Hybrid:   %-20s keyword=%.1f (%.2f) semantic=%.3f fused=%.3f %s\n",
+				c.doc.Command, c.keyword, c.keywordN, c.semantic, c.score, status)
+		}
+		if kept {
+			results = append(results, c.doc)
+		}
+	}
 
-	default:
-		return h.keywordMatcher.FindRelevantDocs(request, maxDocs), nil
+	if h.debug {
+		fmt.Fprintf(os.Stderr, "[DEBUG] Hybrid: %d of %d candidates above min score %.2f (keyword weight %.2f)\n",
+			len(results), len(ranked), h.minScore, weight)
 	}
+
+	return results
 }
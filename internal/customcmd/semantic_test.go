@@ -0,0 +1,289 @@
+package customcmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/iishyfishyy/please/internal/customcmd/embeddings"
+	"github.com/iishyfishyy/please/internal/customcmd/vectorstore"
+)
+
+// conceptEmbedder embeds text as counts of words from fixed concept groups,
+// so related words land close together without a real model
+type conceptEmbedder struct {
+	concepts [][]string
+}
+
+func (e *conceptEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	vector := make([]float32, len(e.concepts)+1)
+	vector[len(e.concepts)] = 0.01 // Keep unrelated text from being a zero vector
+	for _, word := range strings.Fields(strings.ToLower(text)) {
+		for i, group := range e.concepts {
+			for _, concept := range group {
+				if word == concept {
+					vector[i]++
+				}
+			}
+		}
+	}
+	return vector, nil
+}
+
+func (e *conceptEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i], _ = e.Embed(ctx, text)
+	}
+	return vectors, nil
+}
+
+func (e *conceptEmbedder) Dimensions() int { return len(e.concepts) + 1 }
+func (e *conceptEmbedder) Name() string    { return "concepts" }
+
+func newTestHybrid(t *testing.T, strategy string, minScore float64) *HybridMatcher {
+	t.Helper()
+
+	docs := []CommandDoc{
+		{Command: "kubectl", Keywords: []string{"pods", "logs"}, Examples: []Example{{UserRequest: "show pod logs"}}},
+		{Command: "psql", Keywords: []string{"database", "postgres"}, Examples: []Example{{UserRequest: "connect to the database"}}},
+	}
+
+	embedder := &conceptEmbedder{concepts: [][]string{
+		{"logs", "output", "pod", "pods", "kubectl"},
+		{"database", "postgres", "psql", "sql", "tables"},
+	}}
+	semantic := NewSemanticMatcher(embedder, vectorstore.NewMemoryStore())
+	if err := semantic.Index(context.Background(), docs); err != nil {
+		t.Fatalf("Index() error = %v", err)
+	}
+
+	keyword := NewMatcher()
+	keyword.SetDocs(docs)
+
+	h := &HybridMatcher{
+		keywordMatcher:  keyword,
+		semanticMatcher: semantic,
+		strategy:        strategy,
+		threshold:       50,
+	}
+	h.SetFusion(0.5, minScore)
+	return h
+}
+
+func commands(docs []CommandDoc) []string {
+	var names []string
+	for _, doc := range docs {
+		names = append(names, doc.Command)
+	}
+	return names
+}
+
+func TestHybridFusion(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy string
+		minScore float64
+		request  string
+		want     []string
+	}{
+		{
+			name:     "semantic match without keyword overlap",
+			strategy: "hybrid",
+			minScore: 0.3,
+			request:  "print container output",
+			want:     []string{"kubectl"},
+		},
+		{
+			name:     "keyword and semantic agree",
+			strategy: "hybrid",
+			minScore: 0.3,
+			request:  "list postgres tables",
+			want:     []string{"psql"},
+		},
+		{
+			name:     "nothing clears the cutoff",
+			strategy: "hybrid",
+			minScore: 0.3,
+			request:  "compress these images",
+			want:     nil,
+		},
+		{
+			name:     "semantic cutoff",
+			strategy: "semantic",
+			minScore: 0.9,
+			request:  "sql and pod output",
+			want:     nil,
+		},
+		{
+			name:     "keyword only ignores semantics",
+			strategy: "keyword",
+			request:  "print container output",
+			want:     nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHybrid(t, tt.strategy, tt.minScore)
+			docs, err := h.FindRelevantDocs(context.Background(), tt.request, 3)
+			if err != nil {
+				t.Fatalf("FindRelevantDocs() error = %v", err)
+			}
+			if got := commands(docs); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("FindRelevantDocs(%q) = %v, want %v", tt.request, got, tt.want)
+			}
+		})
+	}
+}
+
+// flakyEmbedder fails every batch after the first few
+type flakyEmbedder struct {
+	conceptEmbedder
+	mu      sync.Mutex
+	batches int
+	failAt  int
+}
+
+func (e *flakyEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	e.mu.Lock()
+	e.batches++
+	n := e.batches
+	e.mu.Unlock()
+
+	if e.failAt > 0 && n >= e.failAt {
+		return nil, errors.New("embedding service unavailable")
+	}
+	return e.conceptEmbedder.EmbedBatch(ctx, texts)
+}
+
+func TestIndexDocsBatches(t *testing.T) {
+	// Enough docs for several concurrent batches
+	var docs []CommandDoc
+	for i := 0; i < embedBatchSize*embedConcurrency; i++ {
+		docs = append(docs, CommandDoc{Command: fmt.Sprintf("tool%d", i), Filename: fmt.Sprintf("tool%d.md", i)})
+	}
+
+	embedder := &flakyEmbedder{}
+	store := vectorstore.NewMemoryStore()
+	s := NewSemanticMatcher(embedder, store)
+
+	var mu sync.Mutex
+	var last, total int
+	s.SetProgress(func(done, n int) {
+		mu.Lock()
+		defer mu.Unlock()
+		if done < last {
+			t.Errorf("progress went backwards: %d after %d", done, last)
+		}
+		last, total = done, n
+	})
+
+	if err := s.Index(context.Background(), docs); err != nil {
+		t.Fatalf("Index() error = %v", err)
+	}
+	if last != total || total != store.Count() {
+		t.Errorf("progress = %d/%d, want %d/%d", last, total, store.Count(), store.Count())
+	}
+	if want := (total + embedBatchSize - 1) / embedBatchSize; embedder.batches != want {
+		t.Errorf("%d batches, want %d", embedder.batches, want)
+	}
+
+	// A failing batch fails the whole index
+	failing := NewSemanticMatcher(&flakyEmbedder{failAt: 2}, vectorstore.NewMemoryStore())
+	if err := failing.Index(context.Background(), docs); err == nil {
+		t.Error("Index() succeeded with a failing embedder")
+	}
+	if failing.IsIndexed() {
+		t.Error("matcher marked indexed after a failure")
+	}
+}
+
+func TestHybridWithLocalEmbedder(t *testing.T) {
+	docs := []CommandDoc{
+		{Command: "deployctl", Keywords: []string{"deploy", "rollout"}, Content: "# Restarting\nRestart a deployment with deployctl restart."},
+		{Command: "psql", Keywords: []string{"database", "postgres"}, Content: "# Backups\nBack up a database with pg_dump."},
+	}
+
+	semantic := NewSemanticMatcher(embeddings.NewLocalEmbedder(), vectorstore.NewMemoryStore())
+	if err := semantic.Index(context.Background(), docs); err != nil {
+		t.Fatalf("Index() error = %v", err)
+	}
+
+	keyword := NewMatcher()
+	keyword.SetDocs(docs)
+
+	// The default cutoff for the local provider, whose similarities run lower than a model's
+	h := &HybridMatcher{
+		keywordMatcher:  keyword,
+		semanticMatcher: semantic,
+		strategy:        "semantic",
+		threshold:       50,
+	}
+	h.SetFusion(0.5, 0.15)
+
+	// Misspelt, so keywords find nothing and only the n-gram overlap matches
+	got, err := h.FindRelevantDocs(context.Background(), "restrat my deploymnet", 3)
+	if err != nil {
+		t.Fatalf("FindRelevantDocs() error = %v", err)
+	}
+	if names := commands(got); len(names) != 1 || names[0] != "deployctl" {
+		t.Errorf("FindRelevantDocs() = %v, want [deployctl]", names)
+	}
+}
+
+func TestHybridScope(t *testing.T) {
+	docs := []CommandDoc{
+		{Command: "brew-services", Keywords: []string{"restart", "service"}, Categories: []string{"system"}, OS: []string{"darwin"}, Source: SourceUser},
+		{Command: "systemctl", Keywords: []string{"restart", "service"}, Categories: []string{"system"}, OS: []string{"linux"}, Source: SourceUser},
+		{Command: "supervisorctl", Keywords: []string{"restart", "service"}, Categories: []string{"process"}, Source: SourceUser},
+	}
+
+	embedder := &conceptEmbedder{concepts: [][]string{{"restart", "service", "daemon"}}}
+	semantic := NewSemanticMatcher(embedder, vectorstore.NewMemoryStore())
+	if err := semantic.Index(context.Background(), docs); err != nil {
+		t.Fatalf("Index() error = %v", err)
+	}
+	keyword := NewMatcher()
+	keyword.SetDocs(docs)
+
+	tests := []struct {
+		name  string
+		scope vectorstore.Filter
+		want  []string
+	}{
+		{"unscoped", vectorstore.Filter{}, []string{"brew-services", "supervisorctl", "systemctl"}},
+		{"current OS keeps docs without one", vectorstore.Filter{OS: []string{"linux"}}, []string{"supervisorctl", "systemctl"}},
+		{"category", vectorstore.Filter{Categories: []string{"System"}}, []string{"brew-services", "systemctl"}},
+		{"OS and category", vectorstore.Filter{OS: []string{"darwin"}, Categories: []string{"system"}}, []string{"brew-services"}},
+		{"other source", vectorstore.Filter{Sources: []string{"project"}}, nil},
+	}
+
+	for _, tt := range tests {
+		for _, strategy := range []string{"keyword", "semantic", "hybrid"} {
+			t.Run(tt.name+"/"+strategy, func(t *testing.T) {
+				h := &HybridMatcher{
+					keywordMatcher:  keyword,
+					semanticMatcher: semantic,
+					strategy:        strategy,
+					scope:           tt.scope,
+				}
+				h.SetFusion(0.5, 0.1)
+
+				docs, err := h.FindRelevantDocs(context.Background(), "restart the service daemon", 5)
+				if err != nil {
+					t.Fatalf("FindRelevantDocs() error = %v", err)
+				}
+				got := commands(docs)
+				sort.Strings(got)
+				if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+					t.Errorf("FindRelevantDocs() = %v, want %v", got, tt.want)
+				}
+			})
+		}
+	}
+}
@@ -2,6 +2,7 @@ package customcmd
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -11,6 +12,7 @@ import (
 	"runtime"
 	"time"
 
+	"github.com/iishyfishyy/please/internal/customcmd/embeddings"
 	"github.com/iishyfishyy/please/internal/ui"
 )
 
@@ -77,7 +79,7 @@ func SetupOpenAI() (string, bool, error) {
 	ui.ShowSection("OpenAI Setup")
 
 	// Ask how to provide API key
-	useEnv, err := ui.PromptAPIKeyStorage()
+	useEnv, err := ui.PromptAPIKeyStorage("OpenAI")
 	if err != nil {
 		return "", false, err
 	}
@@ -119,6 +121,108 @@ func SetupOpenAI() (string, bool, error) {
 	return apiKey, useEnv, nil
 }
 
+// SetupTEI handles setup for a Hugging Face Text Embeddings Inference server,
+// returning its URL
+func SetupTEI() (string, error) {
+	ui.ShowSection("Text Embeddings Inference Setup")
+	ui.ShowInfo("Start a server with, for example:")
+	ui.ShowInfo("  docker run -p 8080:80 ghcr.io/huggingface/text-embeddings-inference:cpu-latest --model-id BAAI/bge-small-en-v1.5")
+	fmt.Println()
+
+	return setupEmbeddingServer("TEI server URL:", func(url string) (embeddings.Embedder, error) {
+		return embeddings.NewTEIEmbedder(url)
+	})
+}
+
+// SetupLlamaCpp handles setup for a llama.cpp embedding server, returning its URL
+func SetupLlamaCpp() (string, error) {
+	ui.ShowSection("llama.cpp Server Setup")
+	ui.ShowInfo("Start a server with, for example:")
+	ui.ShowInfo("  llama-server -m nomic-embed-text-v1.5.Q8_0.gguf --embedding --pooling mean --port 8080")
+	fmt.Println()
+
+	return setupEmbeddingServer("llama.cpp server URL:", func(url string) (embeddings.Embedder, error) {
+		return embeddings.NewLlamaCppEmbedder(url)
+	})
+}
+
+// setupEmbeddingServer asks for a self-hosted server's URL and checks it
+// returns embeddings
+func setupEmbeddingServer(message string, create func(url string) (embeddings.Embedder, error)) (string, error) {
+	url, err := ui.PromptInput(message, "http://localhost:8080")
+	if err != nil {
+		return "", err
+	}
+
+	ui.ShowInfo("Testing server...")
+	embedder, err := create(url)
+	if err != nil {
+		return "", err
+	}
+	if _, err := embedder.Embed(context.Background(), "test"); err != nil {
+		return "", fmt.Errorf("server test failed: %w", err)
+	}
+
+	ui.ShowSuccess(fmt.Sprintf("Server configured successfully! (%d dimensions)", embedder.Dimensions()))
+	return url, nil
+}
+
+// SetupCohere handles Cohere setup flow
+func SetupCohere() (string, bool, error) {
+	ui.ShowSection("Cohere Setup")
+
+	useEnv, err := ui.PromptAPIKeyStorage("Cohere")
+	if err != nil {
+		return "", false, err
+	}
+
+	var apiKey string
+
+	if useEnv {
+		apiKey = os.Getenv("COHERE_API_KEY")
+		if apiKey == "" {
+			ui.ShowWarning("COHERE_API_KEY environment variable not set")
+			ui.ShowInfo("")
+			ui.ShowInfo("Please set it in your shell:")
+			ui.ShowInfo("  export COHERE_API_KEY=...")
+			ui.ShowInfo("")
+
+			return "", true, fmt.Errorf("COHERE_API_KEY not set")
+		}
+	} else {
+		apiKey, err = ui.PromptPassword("Enter Cohere API key:")
+		if err != nil {
+			return "", false, err
+		}
+
+		ui.ShowWarning("API key will be saved to ~/.please/config.json (0600 perms)")
+	}
+
+	ui.ShowInfo("Testing Cohere connection...")
+	embedder, err := embeddings.NewCohereEmbedder(apiKey, "", "")
+	if err != nil {
+		return "", useEnv, err
+	}
+	if _, err := embedder.Embed(context.Background(), "test"); err != nil {
+		return "", useEnv, fmt.Errorf("cohere test failed: %w", err)
+	}
+
+	ui.ShowSuccess("Cohere configured successfully!")
+	ui.ShowInfo("Model: embed-english-v3.0")
+
+	return apiKey, useEnv, nil
+}
+
+// SetupLocal explains the built-in embedder, which needs no setup
+func SetupLocal() {
+	ui.ShowSection("Built-in Embeddings")
+	ui.ShowInfo("Using built-in embeddings")
+	ui.ShowInfo("  - Runs in-process, nothing to install or start")
+	ui.ShowInfo("  - Docs never leave your machine")
+	ui.ShowInfo("  - Matches similar wording and word variants, but not synonyms")
+	ui.ShowInfo("  - Can upgrade to a model-based provider later")
+}
+
 // SetupKeywordOnly handles keyword-only setup
 func SetupKeywordOnly() error {
 	ui.ShowSection("Keyword Matching")
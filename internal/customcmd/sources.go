@@ -0,0 +1,264 @@
+package customcmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// SourcesFileName records the git repositories docs are synced from
+const SourcesFileName = "sources.json"
+
+// GitSource is a git repository of shared command docs, cloned into
+// ~/.please/sources/<name> and checked out at a pinned commit
+type GitSource struct {
+	Name     string    `json:"name"`
+	URL      string    `json:"url"`
+	Ref      string    `json:"ref,omitempty"` // Branch, tag or commit to follow; empty for the remote's default branch
+	Dir      string    `json:"dir,omitempty"` // Subdirectory of the repo holding the docs; empty for the root
+	Commit   string    `json:"commit"`        // Commit currently checked out
+	SyncedAt time.Time `json:"synced_at"`
+}
+
+// Sources is the list of git sources, in order of precedence
+type Sources struct {
+	Sources []GitSource `json:"sources"`
+}
+
+// sourceNamePattern keeps source names usable as directory names
+var sourceNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// GetSourcesDir returns the directory git sources are cloned into
+func GetSourcesDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".please", "sources"), nil
+}
+
+// GetSourcesPath returns the path to the sources file
+func GetSourcesPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".please", SourcesFileName), nil
+}
+
+// LoadSources reads the git sources from disk
+func LoadSources() (*Sources, error) {
+	path, err := GetSourcesPath()
+	if err != nil {
+		return nil, err
+	}
+
+	// No sources file means no sources yet
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Sources{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sources file: %w", err)
+	}
+
+	var sources Sources
+	if err := json.Unmarshal(data, &sources); err != nil {
+		return nil, fmt.Errorf("failed to parse sources file: %w", err)
+	}
+
+	return &sources, nil
+}
+
+// Save writes the git sources to disk
+func (s *Sources) Save() error {
+	path, err := GetSourcesPath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal sources: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write sources file: %w", err)
+	}
+
+	return nil
+}
+
+// Get returns the source with the given name
+func (s *Sources) Get(name string) (*GitSource, bool) {
+	for i := range s.Sources {
+		if s.Sources[i].Name == name {
+			return &s.Sources[i], true
+		}
+	}
+	return nil, false
+}
+
+// Add clones a git repository of docs and records it as a source. name
+// defaults to the repository's name, and a local path is recorded as an
+// absolute path so syncing works from any directory. The caller saves.
+func (s *Sources) Add(ctx context.Context, url, name, ref, dir string) (*GitSource, error) {
+	if info, err := os.Stat(url); err == nil && info.IsDir() {
+		if abs, err := filepath.Abs(url); err == nil {
+			url = abs
+		}
+	}
+	if name == "" {
+		name = sourceNameFromURL(url)
+	}
+	if !sourceNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid source name %q (use letters, digits, '.', '_' and '-')", name)
+	}
+	if _, ok := s.Get(name); ok {
+		return nil, fmt.Errorf("source %q already exists", name)
+	}
+
+	src := GitSource{
+		Name: name,
+		URL:  url,
+		Ref:  ref,
+		Dir:  filepath.ToSlash(filepath.Clean(dir)),
+	}
+	if src.Dir == "." {
+		src.Dir = ""
+	}
+
+	clonePath, err := src.ClonePath()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(clonePath); err == nil {
+		return nil, fmt.Errorf("%s already exists; remove it or choose another name", clonePath)
+	}
+
+	if err := src.Sync(ctx); err != nil {
+		os.RemoveAll(clonePath)
+		return nil, err
+	}
+
+	s.Sources = append(s.Sources, src)
+	return &s.Sources[len(s.Sources)-1], nil
+}
+
+// sourceNameFromURL derives a source name from a repository URL or path,
+// e.g. git@github.com:acme/team-docs.git → team-docs
+func sourceNameFromURL(url string) string {
+	name := strings.TrimRight(url, "/\\")
+	if i := strings.LastIndexAny(name, "/\\:"); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.TrimSuffix(name, ".git")
+}
+
+// ClonePath returns where the source's repository is cloned
+func (src *GitSource) ClonePath() (string, error) {
+	dir, err := GetSourcesDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, src.Name), nil
+}
+
+// CommandsPath returns the directory in the clone that docs are loaded from
+func (src *GitSource) CommandsPath() (string, error) {
+	clonePath, err := src.ClonePath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(clonePath, filepath.FromSlash(src.Dir)), nil
+}
+
+// Sync fetches the source's repository, cloning it if needed, and checks out
+// the commit its ref points to. A branch moves to its latest commit; a tag
+// or commit stays put. Commit and SyncedAt are updated; the caller saves.
+func (src *GitSource) Sync(ctx context.Context) error {
+	// sources.json can be edited by hand, so check here rather than in Add
+	if strings.HasPrefix(src.URL, "-") {
+		return fmt.Errorf("invalid source URL %q", src.URL)
+	}
+	if strings.HasPrefix(src.Ref, "-") {
+		return fmt.Errorf("invalid ref %q", src.Ref)
+	}
+
+	clonePath, err := src.ClonePath()
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(filepath.Join(clonePath, ".git")); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(clonePath), 0755); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+		if _, err := runGit(ctx, "", "clone", "--quiet", "--no-checkout", "--", src.URL, clonePath); err != nil {
+			return err
+		}
+	} else if _, err := runGit(ctx, clonePath, "fetch", "--quiet", "--tags", "--force", "origin"); err != nil {
+		return err
+	}
+
+	commit, err := src.resolveRef(ctx, clonePath)
+	if err != nil {
+		return err
+	}
+	if _, err := runGit(ctx, clonePath, "checkout", "--quiet", "--force", "--detach", commit); err != nil {
+		return err
+	}
+
+	src.Commit = commit
+	src.SyncedAt = time.Now()
+	return nil
+}
+
+// resolveRef returns the commit the source's ref points to after a fetch,
+// preferring the remote's branch over a stale local name
+func (src *GitSource) resolveRef(ctx context.Context, clonePath string) (string, error) {
+	candidates := []string{"origin/HEAD"}
+	if src.Ref != "" {
+		candidates = []string{"origin/" + src.Ref, src.Ref}
+	}
+
+	for _, candidate := range candidates {
+		commit, err := runGit(ctx, clonePath, "rev-parse", "--verify", "--quiet", candidate+"^{commit}", "--")
+		if err == nil {
+			return commit, nil
+		}
+	}
+
+	if src.Ref == "" {
+		return "", fmt.Errorf("%s has no default branch; add it with a ref", src.URL)
+	}
+	return "", fmt.Errorf("ref %q not found in %s", src.Ref, src.URL)
+}
+
+// runGit runs a git command in dir and returns its trimmed output
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	// Never stop to ask for credentials
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(output)); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,172 @@
+package customcmd
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// docsRepo is a bare git repository with a working clone to commit docs from
+type docsRepo struct {
+	t    *testing.T
+	bare string
+	work string
+}
+
+func newDocsRepo(t *testing.T) *docsRepo {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	root := t.TempDir()
+	r := &docsRepo{t: t, bare: filepath.Join(root, "team-docs.git"), work: filepath.Join(root, "work")}
+	r.git(root, "init", "--quiet", "--bare", "--initial-branch=main", r.bare)
+	r.git(root, "clone", "--quiet", r.bare, r.work)
+	r.git(r.work, "checkout", "--quiet", "-B", "main")
+	return r
+}
+
+func (r *docsRepo) git(dir string, args ...string) string {
+	r.t.Helper()
+	out, err := runGit(context.Background(), dir, args...)
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	return out
+}
+
+// commit writes a doc into the working clone, commits and pushes it, and
+// returns the new commit
+func (r *docsRepo) commit(dir, command, body string) string {
+	r.t.Helper()
+	writeDoc(r.t, filepath.Join(r.work, dir), command, body)
+	r.git(r.work, "add", "-A")
+	r.git(r.work, "commit", "--quiet", "-m", "Add "+command)
+	r.git(r.work, "push", "--quiet", "origin", "main")
+	return r.git(r.work, "rev-parse", "HEAD")
+}
+
+func TestSourcesAddAndSync(t *testing.T) {
+	repo := newDocsRepo(t)
+	first := repo.commit("docs", "deploy", "Deploy a service with the team's deploy tool")
+	repo.git(repo.work, "tag", "v1")
+	repo.git(repo.work, "push", "--quiet", "origin", "v1")
+
+	t.Setenv("HOME", t.TempDir())
+	t.Chdir(t.TempDir())
+	ctx := context.Background()
+
+	sources, err := LoadSources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest, err := sources.Add(ctx, repo.bare, "", "", "docs")
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if latest.Name != "team-docs" || latest.Commit != first {
+		t.Errorf("Add() = %s at %s, want team-docs at %s", latest.Name, latest.Commit, first)
+	}
+	if _, err := sources.Add(ctx, repo.bare, "pinned", "v1", "docs"); err != nil {
+		t.Fatalf("Add(v1) error = %v", err)
+	}
+	if _, err := sources.Add(ctx, repo.bare, "", "", ""); err == nil {
+		t.Error("adding team-docs twice succeeded")
+	}
+	if _, err := sources.Add(ctx, repo.bare, "missing", "no-such-ref", ""); err == nil {
+		t.Error("Add() with an unknown ref succeeded")
+	}
+	// Neither a URL nor a ref can pass options to git
+	marker := filepath.Join(t.TempDir(), "ran")
+	if _, err := sources.Add(ctx, "--upload-pack=touch "+marker, "injected", "", ""); err == nil {
+		t.Error("Add() with an option as the URL succeeded")
+	}
+	if _, err := sources.Add(ctx, repo.bare, "bad-ref", "--output="+marker, ""); err == nil {
+		t.Error("Add() with an option as the ref succeeded")
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("an option passed as a URL or ref reached git")
+	}
+	if err := sources.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := NewManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	docs := m.GetDocs()
+	if len(docs) != 1 || docs[0].Command != "deploy" || docs[0].Source != GitSourceName("team-docs") {
+		t.Fatalf("loaded %v, want deploy from team-docs", docs)
+	}
+
+	// A branch follows new commits; a tag stays pinned
+	second := repo.commit("docs", "rollback", "Roll back the last deploy")
+	sources, err = LoadSources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range sources.Sources {
+		if err := sources.Sources[i].Sync(ctx); err != nil {
+			t.Fatalf("Sync(%s) error = %v", sources.Sources[i].Name, err)
+		}
+	}
+	if src, _ := sources.Get("team-docs"); src.Commit != second {
+		t.Errorf("team-docs synced to %s, want %s", src.Commit, second)
+	}
+	if src, _ := sources.Get("pinned"); src.Commit != first {
+		t.Errorf("pinned synced to %s, want %s", src.Commit, first)
+	}
+
+	path, err := sources.Sources[0].CommandsPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(path, "rollback.md")); err != nil {
+		t.Errorf("rollback.md wasn't checked out: %v", err)
+	}
+}
+
+func TestSourceNameFromURL(t *testing.T) {
+	tests := map[string]string{
+		"https://github.com/acme/team-docs.git": "team-docs",
+		"git@github.com:acme/team-docs.git":     "team-docs",
+		"git@host:docs":                         "docs",
+		"/srv/git/ops-docs/":                    "ops-docs",
+	}
+	for url, want := range tests {
+		if got := sourceNameFromURL(url); got != want {
+			t.Errorf("sourceNameFromURL(%q) = %q, want %q", url, got, want)
+		}
+	}
+}
+
+func TestGetCommandDirsSkipsBadSources(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Chdir(t.TempDir())
+	if err := os.MkdirAll(filepath.Join(home, ".please"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, ".please", SourcesFileName), []byte(`{"sources": [`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dirs, err := GetCommandDirs()
+	if err != nil {
+		t.Fatalf("GetCommandDirs() error = %v", err)
+	}
+	if len(dirs) != 2 || dirs[0].Source != SourceUser || dirs[1].Source != SourceSystem {
+		t.Errorf("GetCommandDirs() = %v, want the user and system dirs", dirs)
+	}
+}
@@ -0,0 +1,324 @@
+package customcmd
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// tldrPlatforms maps tldr's page directories to GOOS values; pages under
+// "common" apply everywhere
+var tldrPlatforms = map[string]string{
+	"linux":   "linux",
+	"osx":     "darwin",
+	"windows": "windows",
+	"android": "android",
+	"freebsd": "freebsd",
+	"netbsd":  "netbsd",
+	"openbsd": "openbsd",
+	"sunos":   "solaris",
+}
+
+var (
+	// {{path/to/file}} or {{[-f|--force]}}
+	tldrPlaceholderRe = regexp.MustCompile(`\{\{(.*?)\}\}`)
+	// [c]reate → create, in descriptions that mark short options
+	tldrMnemonicRe = regexp.MustCompile(`\[([A-Za-z0-9])\]`)
+	// Optional parts of a value, like the [.gz|.bz2] in {{path/to/file.tar[.gz|.bz2]}}
+	tldrOptionalRe = regexp.MustCompile(`\[[^\]]*\]`)
+	// A numbered item in a list of values, like the 1 in path/to/file1
+	tldrItemNumberRe = regexp.MustCompile(`-?[0-9]+$`)
+	tldrWordRe       = regexp.MustCompile(`[a-z][a-z0-9]+`)
+	nonNameRe        = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// maxTldrKeywords caps the keywords taken from a page's text
+const maxTldrKeywords = 12
+
+// ImportTldr converts tldr pages into command docs. root is a single page
+// or a directory of them, such as tldr's pages/ tree. Pages are written
+// flat, named after their file; a platform directory (linux/, osx/, ...)
+// only restricts its pages to that OS. A page for a command that another
+// platform's page (or a common one) already documents stays in its
+// platform directory, e.g. osx/date.md, since two docs for the same command
+// can't both load from the top level.
+func ImportTldr(root string) ([]ImportedDoc, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		doc, err := parseTldrFile(root, "")
+		if err != nil {
+			return nil, err
+		}
+		return []ImportedDoc{{Path: filepath.Base(root), Doc: *doc}}, nil
+	}
+
+	var docs []ImportedDoc
+	var platforms []string // Directory name of each page, for names that clash
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isCommandFile(d.Name()) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		platform := path.Base(path.Dir(filepath.ToSlash(rel)))
+		doc, err := parseTldrFile(p, tldrPlatforms[platform])
+		if err != nil {
+			return err
+		}
+		docs = append(docs, ImportedDoc{Path: d.Name(), Doc: *doc})
+		platforms = append(platforms, platform)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Common pages keep the plain name; others only when it's free
+	taken := make(map[string]bool)
+	for i := range docs {
+		if tldrPlatforms[platforms[i]] == "" {
+			taken[docs[i].Path] = true
+		}
+	}
+	for i := range docs {
+		if tldrPlatforms[platforms[i]] == "" {
+			continue
+		}
+		if taken[docs[i].Path] {
+			docs[i].Path = platforms[i] + "/" + docs[i].Path
+		}
+		taken[docs[i].Path] = true
+	}
+
+	return docs, nil
+}
+
+// parseTldrFile reads a tldr page, restricting it to goos if set
+func parseTldrFile(filename, goos string) (*CommandDoc, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := ParseTldr(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	if goos != "" {
+		doc.OS = []string{goos}
+	}
+	return doc, nil
+}
+
+// ParseTldr converts a tldr page into a command doc:
+//
+//	# tar
+//
+//	> Archiving utility.
+//	> More information: <https://www.gnu.org/software/tar>.
+//
+//	- [c]reate an archive from files:
+//
+//	`tar cf {{path/to/target.tar}} {{path/to/file1}}`
+//
+// Each "- description:" and the command after it become an example, with
+// {{...}} placeholders written as <name>. The doc's Content is the page
+// rewritten as a command doc, examples included.
+func ParseTldr(page string) (*CommandDoc, error) {
+	var command string
+	var description []string
+	var examples []Example
+	var pending string // Description waiting for its command
+
+	for _, line := range strings.Split(strings.ReplaceAll(page, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "# ") && command == "":
+			command = strings.TrimSpace(line[2:])
+		case strings.HasPrefix(line, ">"):
+			description = append(description, strings.TrimSpace(strings.TrimPrefix(line, ">")))
+		case strings.HasPrefix(line, "- "):
+			pending = tldrDescription(line[2:])
+		case strings.HasPrefix(line, "`") && strings.HasSuffix(line, "`") && len(line) > 1 && pending != "":
+			examples = append(examples, Example{
+				UserRequest: pending,
+				Command:     tldrCommand(strings.Trim(line, "`")),
+			})
+			pending = ""
+		}
+	}
+
+	if command == "" {
+		return nil, fmt.Errorf("not a tldr page: no # title")
+	}
+	if len(examples) == 0 {
+		return nil, fmt.Errorf("tldr page for %s has no examples", command)
+	}
+
+	var content strings.Builder
+	fmt.Fprintf(&content, "# %s\n\n", command)
+	for _, line := range description {
+		// <https://...> would read as a placeholder
+		line = strings.NewReplacer("<http", "http", ">.", ".").Replace(line)
+		content.WriteString(line + "\n")
+	}
+	content.WriteString("\n## Examples\n")
+	for _, ex := range examples {
+		content.WriteString("\n" + formatExample(ex))
+	}
+
+	return &CommandDoc{
+		Command:    command,
+		Keywords:   tldrKeywords(command, description, examples),
+		Categories: []string{"tldr"},
+		Content:    content.String(),
+		Examples:   examples,
+	}, nil
+}
+
+// tldrKeywords picks the distinct words of a page's description and example
+// descriptions, skipping filler words, the command's own name and the
+// "More information" and "See also" lines
+func tldrKeywords(command string, description []string, examples []Example) []string {
+	texts := make([]string, 0, len(description)+len(examples))
+	for _, line := range description {
+		lower := strings.ToLower(line)
+		if !strings.HasPrefix(lower, "more information") && !strings.HasPrefix(lower, "see also") {
+			texts = append(texts, lower)
+		}
+	}
+	for _, ex := range examples {
+		texts = append(texts, strings.ToLower(ex.UserRequest))
+	}
+
+	var words []string
+	for _, text := range texts {
+		for _, word := range tldrWordRe.FindAllString(text, -1) {
+			if !stopWords[word] && word != strings.ToLower(command) {
+				words = append(words, word)
+			}
+		}
+	}
+	words = uniqueWords(words)
+	if len(words) > maxTldrKeywords {
+		words = words[:maxTldrKeywords]
+	}
+	return words
+}
+
+// tldrDescription turns "[c]reate an archive:" into "create an archive"
+func tldrDescription(text string) string {
+	text = tldrMnemonicRe.ReplaceAllString(text, "$1")
+	return strings.TrimSuffix(strings.TrimSpace(text), ":")
+}
+
+// tldrCommand rewrites tldr's {{...}} placeholders: option alternatives
+// like {{[-f|--force]}} become the long option, and values become <name>.
+// Placeholders inside single quotes aren't filled, so those are moved out
+// of the quotes: sed 's/{{a}}/{{b}}/' becomes sed 's/'<a>'/'<b>'/'.
+func tldrCommand(command string) string {
+	var out strings.Builder
+	last := 0
+	for _, m := range tldrPlaceholderRe.FindAllStringIndex(command, -1) {
+		out.WriteString(command[last:m[0]])
+		last = m[1]
+
+		inner := strings.TrimSpace(command[m[0]+2 : m[1]-2])
+		if strings.HasPrefix(inner, "[") && strings.HasSuffix(inner, "]") && strings.Contains(inner, "|") {
+			options := strings.Split(strings.Trim(inner, "[]"), "|")
+			out.WriteString(options[len(options)-1])
+			continue
+		}
+		token := "<" + tldrPlaceholderName(inner) + ">"
+		if singleQuotedAt(command, m[0]) {
+			token = "'" + token + "'"
+		}
+		out.WriteString(token)
+	}
+	out.WriteString(command[last:])
+	return out.String()
+}
+
+// singleQuotedAt reports whether the byte offset is inside single quotes
+func singleQuotedAt(command string, pos int) bool {
+	var quote byte
+	for i := 0; i < pos && i < len(command); i++ {
+		c := command[i]
+		switch {
+		case c == '\\' && quote != '\'':
+			i++
+		case quote == 0 && (c == '\'' || c == '"'):
+			quote = c
+		case c == quote:
+			quote = 0
+		}
+	}
+	return quote == '\''
+}
+
+// tldrPlaceholderName makes a placeholder name from tldr's description of a
+// value: "path/to/target.tar" → "target-tar", and a list of values like
+// "path/to/file1 path/to/file2 ..." → "files"
+func tldrPlaceholderName(text string) string {
+	text = tldrOptionalRe.ReplaceAllString(text, "")
+	text = strings.ReplaceAll(text, "path/to/", "")
+	if item, ok := tldrListItem(text); ok {
+		return plural(item)
+	}
+
+	name := strings.Trim(nonNameRe.ReplaceAllString(strings.ToLower(text), "-"), "-")
+	if name == "" || name[0] < 'a' || name[0] > 'z' {
+		name = "value-" + name
+	}
+	return strings.TrimSuffix(name, "-")
+}
+
+// tldrListItem returns the name of one item of a list of values, such as
+// "file1 file2 ..." or "package1 package2", and whether text is one. Other
+// text with spaces, like {{Hello world}}, isn't a list.
+func tldrListItem(text string) (string, bool) {
+	items := strings.Fields(strings.ReplaceAll(text, "...", " "))
+	if len(items) == 0 || (len(items) == 1 && !strings.Contains(text, "...")) {
+		return "", false
+	}
+
+	var name string
+	for i, item := range items {
+		itemName := tldrItemNumberRe.ReplaceAllString(tldrPlaceholderName(item), "")
+		if itemName == "" || (i > 0 && itemName != name) {
+			return "", false
+		}
+		name = itemName
+	}
+	return name, true
+}
+
+// plural makes a placeholder name plural: file → files, directory → directories
+func plural(name string) string {
+	switch {
+	case strings.HasSuffix(name, "s"):
+		return name
+	case strings.HasSuffix(name, "y") && !strings.HasSuffix(name, "ey"):
+		return strings.TrimSuffix(name, "y") + "ies"
+	default:
+		return name + "s"
+	}
+}
@@ -0,0 +1,143 @@
+package customcmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const tarPage = `# tar
+
+> Archiving utility.
+> More information: <https://www.gnu.org/software/tar>.
+
+- [c]reate an archive and write it to a [f]ile:
+
+` + "`tar cf {{path/to/target.tar}} {{path/to/file1 path/to/file2 ...}}`" + `
+
+- E[x]tract a (compressed) archive [f]ile into the current directory [v]erbosely:
+
+` + "`tar {{[-x|--extract]}} -vf {{path/to/source.tar[.gz|.bz2|.xz]}}`" + `
+
+- List the contents of a "tar" file:
+
+` + "`tar tvf {{path/to/source.tar}}`" + `
+`
+
+func TestParseTldr(t *testing.T) {
+	doc, err := ParseTldr(tarPage)
+	if err != nil {
+		t.Fatalf("ParseTldr() error = %v", err)
+	}
+	if doc.Command != "tar" {
+		t.Errorf("Command = %q, want tar", doc.Command)
+	}
+
+	want := []Example{
+		{"create an archive and write it to a file", "tar cf <target-tar> <files>"},
+		{"Extract a (compressed) archive file into the current directory verbosely", "tar --extract -vf <source-tar>"},
+		{`List the contents of a "tar" file`, "tar tvf <source-tar>"},
+	}
+	if len(doc.Examples) != len(want) {
+		t.Fatalf("got %d examples, want %d", len(doc.Examples), len(want))
+	}
+	for i, ex := range want {
+		if doc.Examples[i] != ex {
+			t.Errorf("example %d = %+v, want %+v", i, doc.Examples[i], ex)
+		}
+	}
+
+	if got := strings.Join(doc.Keywords, ","); got != "archiving,utility,create,archive,write,file,extract,compressed,current,directory,verbosely,list" {
+		t.Errorf("Keywords = %s", got)
+	}
+	if strings.Join(doc.Categories, ",") != "tldr" {
+		t.Errorf("Categories = %v, want tldr", doc.Categories)
+	}
+
+	names := map[string]string{
+		"path/to/directory1 path/to/directory2 ...": "directories",
+		"package1 package2":                         "packages",
+		"path/to/file":                              "file",
+		"Hello world":                               "hello-world",
+		"1":                                         "value-1",
+	}
+	for text, want := range names {
+		if got := tldrPlaceholderName(text); got != want {
+			t.Errorf("tldrPlaceholderName(%q) = %s, want %s", text, got, want)
+		}
+	}
+
+	// Placeholders in single quotes are moved out of them so they're filled
+	sed, err := ParseTldr("# sed\n\n- Replace:\n\n`sed 's/{{apple}}/{{mango}}/g' \"{{path/to/file}}\"`\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := sed.Examples[0].Command, `sed 's/'<apple>'/'<mango>'/g' "<file>"`; got != want {
+		t.Errorf("sed command = %s, want %s", got, want)
+	}
+
+	if _, err := ParseTldr("just some notes\n"); err == nil {
+		t.Error("ParseTldr() of a non-page succeeded")
+	}
+}
+
+func TestImportTldrRoundTrip(t *testing.T) {
+	pages := t.TempDir()
+	writeFile(t, filepath.Join(pages, "common", "tar.md"), tarPage)
+	writeFile(t, filepath.Join(pages, "linux", "ip.md"), "# ip\n\n> Show routing.\n\n- List interfaces:\n\n`ip link`\n")
+	writeFile(t, filepath.Join(pages, "osx", "tar.md"), "# tar\n\n> BSD tar.\n\n- List an archive:\n\n`tar tf {{path/to/source.tar}}`\n")
+
+	docs, err := ImportTldr(pages)
+	if err != nil {
+		t.Fatalf("ImportTldr() error = %v", err)
+	}
+	if len(docs) != 3 {
+		t.Fatalf("imported %d docs, want 3", len(docs))
+	}
+
+	out := t.TempDir()
+	written, _, err := WriteImported(out, docs, false)
+	if err != nil || len(written) != 3 {
+		t.Fatalf("WriteImported() = %v, %v", written, err)
+	}
+	if _, skipped, _ := WriteImported(out, docs, false); len(skipped) != 3 {
+		t.Errorf("rewriting skipped %d docs, want 3", len(skipped))
+	}
+
+	// The written docs load like hand-written ones and lint clean
+	loaded, err := NewLoader().LoadAll(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := make(map[string]CommandDoc)
+	for _, doc := range loaded {
+		byName[doc.Name()] = doc
+	}
+	// Platform directories only set the OS, unless the command is taken
+	tar, ip, bsdTar := byName["tar"], byName["ip"], byName["osx/tar"]
+	if len(tar.Examples) != 3 || tar.Examples[2].UserRequest != "List the contents of a tar file" {
+		t.Errorf("tar examples = %+v", tar.Examples)
+	}
+	if len(tar.OS) != 0 || strings.Join(ip.OS, ",") != "linux" || strings.Join(bsdTar.OS, ",") != "darwin" {
+		t.Errorf("OS = %v for tar, %v for ip, %v for osx/tar; want none, linux and darwin", tar.OS, ip.OS, bsdTar.OS)
+	}
+	if len(tar.Keywords) == 0 || strings.Join(tar.Categories, ",") != "tldr" {
+		t.Errorf("tar keywords = %v, categories = %v", tar.Keywords, tar.Categories)
+	}
+	if strings.Contains(tar.Content, "<https") {
+		t.Error("links were left as <...>, which reads as a placeholder")
+	}
+
+	result, err := Lint([]string{out})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, issue := range result.Issues {
+		t.Errorf("lint: %s", issue)
+	}
+
+	if _, err := os.Stat(filepath.Join(out, "ip.md")); err != nil {
+		t.Errorf("ip.md wasn't written at the top level: %v", err)
+	}
+}
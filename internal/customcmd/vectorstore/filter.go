@@ -0,0 +1,149 @@
+package vectorstore
+
+import "strings"
+
+// Metadata keys that searches can be filtered on
+const (
+	MetaCategories = "categories" // []string
+	MetaPriority   = "priority"   // string
+	MetaSource     = "source"     // string
+	MetaOS         = "os"         // []string, empty when a doc applies to every OS
+)
+
+// Filter restricts a search to vectors whose metadata matches. Every
+// non-empty field must match, and within a field any of the listed values
+// will do. Comparisons ignore case. The zero Filter matches everything.
+type Filter struct {
+	Categories []string // Has any of these categories
+	Priorities []string // Has one of these priorities
+	Sources    []string // Came from one of these sources
+	OS         []string // Applies to one of these; vectors naming no OS apply to all
+}
+
+// Empty reports whether the filter matches everything
+func (f Filter) Empty() bool {
+	return len(f.Categories) == 0 && len(f.Priorities) == 0 && len(f.Sources) == 0 && len(f.OS) == 0
+}
+
+// key identifies the filter for caching, ignoring case
+func (f Filter) key() string {
+	return strings.Join([]string{
+		joinColumn(f.Categories), joinColumn(f.Priorities), joinColumn(f.Sources), joinColumn(f.OS),
+	}, "\x00")
+}
+
+// Matches reports whether a vector's metadata passes the filter
+func (f Filter) Matches(metadata map[string]interface{}) bool {
+	return f.matchesFields(fieldsOf(metadata))
+}
+
+// filterFields are the metadata values a Filter looks at, so stores can
+// keep them without the rest of the metadata
+type filterFields struct {
+	Categories []string
+	Priority   []string
+	Source     []string
+	OS         []string
+}
+
+// fieldsOf pulls the filterable values out of metadata
+func fieldsOf(metadata map[string]interface{}) filterFields {
+	return filterFields{
+		Categories: metadataStrings(metadata[MetaCategories]),
+		Priority:   metadataStrings(metadata[MetaPriority]),
+		Source:     metadataStrings(metadata[MetaSource]),
+		OS:         metadataStrings(metadata[MetaOS]),
+	}
+}
+
+// matchesFields is Matches for values already pulled out of metadata
+func (f Filter) matchesFields(fields filterFields) bool {
+	if len(f.Categories) > 0 && !anyOf(fields.Categories, f.Categories) {
+		return false
+	}
+	if len(f.Priorities) > 0 && !anyOf(fields.Priority, f.Priorities) {
+		return false
+	}
+	if len(f.Sources) > 0 && !anyOf(fields.Source, f.Sources) {
+		return false
+	}
+	if len(f.OS) > 0 && len(fields.OS) > 0 && !anyOf(fields.OS, f.OS) {
+		return false
+	}
+	return true
+}
+
+// metadataStrings reads a metadata value as a list of strings, whether it was
+// stored as a string, a []string, or decoded from JSON as []interface{}
+func metadataStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		if v == "" {
+			return nil
+		}
+		return []string{v}
+	case []string:
+		return v
+	case []interface{}:
+		var values []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// anyOf reports whether values and wanted share an element, ignoring case
+func anyOf(values, wanted []string) bool {
+	for _, v := range values {
+		for _, w := range wanted {
+			if strings.EqualFold(v, w) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// joinColumn encodes a list for a filter column as "|a|b|", lowercased, so
+// SQL can test membership with instr(column, '|a|')
+func joinColumn(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return "|" + strings.ToLower(strings.Join(values, "|")) + "|"
+}
+
+// where returns the SQL condition (without WHERE) and arguments that apply
+// the filter to the embeddings table, or "" if it matches everything
+func (f Filter) where() (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	member := func(column string, values []string) string {
+		var clauses []string
+		for _, v := range values {
+			clauses = append(clauses, "instr("+column+", ?) > 0")
+			args = append(args, joinColumn([]string{v}))
+		}
+		return "(" + strings.Join(clauses, " OR ") + ")"
+	}
+
+	if len(f.Categories) > 0 {
+		conditions = append(conditions, member("categories", f.Categories))
+	}
+	if len(f.Priorities) > 0 {
+		conditions = append(conditions, member("priority", f.Priorities))
+	}
+	if len(f.Sources) > 0 {
+		conditions = append(conditions, member("source", f.Sources))
+	}
+	if len(f.OS) > 0 {
+		conditions = append(conditions, "(os = '' OR "+member("os", f.OS)+")")
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
@@ -0,0 +1,157 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// filterDocs are stored in every store under test. The vectors all point
+// roughly the same way, so filtering decides what's returned.
+var filterDocs = []struct {
+	id       string
+	metadata map[string]interface{}
+}{
+	{"brew", map[string]interface{}{MetaCategories: []string{"system"}, MetaPriority: "high", MetaSource: "user", MetaOS: []string{"darwin"}}},
+	{"systemctl", map[string]interface{}{MetaCategories: []string{"system", "ops"}, MetaPriority: "high", MetaSource: "user", MetaOS: []string{"linux"}}},
+	{"kubectl", map[string]interface{}{MetaCategories: []string{"k8s_ops"}, MetaPriority: "medium", MetaSource: "project"}},
+	{"psql", map[string]interface{}{MetaCategories: []string{"database"}, MetaPriority: "low", MetaSource: "user", MetaOS: []string{"linux", "darwin"}}},
+}
+
+func filterStores(t *testing.T) map[string]Store {
+	t.Helper()
+	ctx := context.Background()
+
+	sqlStore, err := NewSQLiteStore(filepath.Join(t.TempDir(), "embeddings.db"), "test", "model", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlStore.Close() })
+
+	// Enough extra vectors that HNSWStore searches its graph
+	hnswSQL := bulkStore(t, clusteredVectors(hnswMinVectors, 3, 8))
+	hnswStore, err := NewHNSWStore(ctx, hnswSQL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stores := map[string]Store{
+		"memory": NewMemoryStore(),
+		"sqlite": sqlStore,
+		"hnsw":   hnswStore,
+	}
+	for _, s := range stores {
+		for i, doc := range filterDocs {
+			if err := s.Add(ctx, doc.id, []float32{1, float32(i) * 0.01, 0}, doc.metadata); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if hnswStore.graph == nil {
+		t.Fatal("HNSW store has no graph")
+	}
+	return stores
+}
+
+func TestSearchFiltered(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{"category", Filter{Categories: []string{"System"}}, []string{"brew", "systemctl"}},
+		{"category with an underscore", Filter{Categories: []string{"k8s_ops"}}, []string{"kubectl"}},
+		{"category is matched whole", Filter{Categories: []string{"ops"}}, []string{"systemctl"}},
+		{"priority", Filter{Priorities: []string{"high", "low"}}, []string{"brew", "psql", "systemctl"}},
+		{"source", Filter{Sources: []string{"project"}}, []string{"kubectl"}},
+		{"OS keeps docs without one", Filter{OS: []string{"linux"}}, []string{"kubectl", "psql", "systemctl"}},
+		{"fields combine", Filter{OS: []string{"darwin"}, Priorities: []string{"high"}}, []string{"brew"}},
+		{"no matches", Filter{Sources: []string{"system"}}, nil},
+	}
+
+	stores := filterStores(t)
+	for name, s := range stores {
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				results, err := s.SearchFiltered(context.Background(), []float32{1, 0, 0}, 10, tt.filter)
+				if err != nil {
+					t.Fatalf("SearchFiltered() error = %v", err)
+				}
+
+				var got []string
+				for _, r := range results {
+					if !tt.filter.Matches(r.Metadata) {
+						t.Errorf("result %s doesn't match the filter", r.ID)
+					}
+					if !strings.HasPrefix(r.ID, "cmd_") {
+						got = append(got, r.ID)
+					}
+				}
+				sort.Strings(got)
+				if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+					t.Errorf("SearchFiltered() = %v, want %v", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestSearchFilteredAfterChanges(t *testing.T) {
+	ctx := context.Background()
+	filter := Filter{Sources: []string{"system"}}
+
+	search := func(t *testing.T, s Store) string {
+		t.Helper()
+		results, err := s.SearchFiltered(ctx, []float32{1, 0, 0}, 10, filter)
+		if err != nil {
+			t.Fatalf("SearchFiltered() error = %v", err)
+		}
+		var got []string
+		for _, r := range results {
+			got = append(got, r.ID)
+		}
+		sort.Strings(got)
+		return fmt.Sprint(got)
+	}
+
+	for name, s := range filterStores(t) {
+		t.Run(name, func(t *testing.T) {
+			// Search first so any per-filter state is in place before the changes
+			if got := search(t, s); got != "[]" {
+				t.Fatalf("SearchFiltered() = %s, want []", got)
+			}
+
+			apt := map[string]interface{}{MetaCategories: []string{"system"}, MetaSource: "system"}
+			if err := s.Add(ctx, "apt", []float32{1, 0.05, 0}, apt); err != nil {
+				t.Fatal(err)
+			}
+			if got := search(t, s); got != "[apt]" {
+				t.Errorf("SearchFiltered() after Add = %s, want [apt]", got)
+			}
+
+			if err := s.Delete(ctx, "apt"); err != nil {
+				t.Fatal(err)
+			}
+			if got := search(t, s); got != "[]" {
+				t.Errorf("SearchFiltered() after Delete = %s, want []", got)
+			}
+		})
+	}
+}
+
+func TestFilterWhere(t *testing.T) {
+	where, args := Filter{Categories: []string{"a", "b"}, OS: []string{"linux"}}.where()
+	want := "(instr(categories, ?) > 0 OR instr(categories, ?) > 0) AND (os = '' OR (instr(os, ?) > 0))"
+	if where != want {
+		t.Errorf("where = %q, want %q", where, want)
+	}
+	if fmt.Sprint(args) != "[|a| |b| |linux|]" {
+		t.Errorf("args = %v", args)
+	}
+	if where, _ := (Filter{}).where(); where != "" {
+		t.Errorf("empty filter where = %q, want none", where)
+	}
+}
@@ -0,0 +1,451 @@
+package vectorstore
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// HNSW parameters (Malkov & Yashunin): each node links to up to hnswM
+// neighbours per layer (twice that on the bottom layer), inserts explore
+// hnswEfConstruction candidates, and searches at least hnswEfSearch
+const (
+	hnswM              = 16
+	hnswEfConstruction = 100
+	hnswEfSearch       = 64
+)
+
+// hnswNode is one vector in the graph
+type hnswNode struct {
+	ID        string
+	Vector    []byte    // Normalised and encoded with the graph's quantization
+	Neighbors [][]int32 // Neighbour node indexes per layer, bottom layer first
+	Deleted   bool      // Kept for navigation until the graph is compacted
+}
+
+// hnswGraph is a hierarchical navigable small world graph for approximate
+// nearest-neighbour search by cosine similarity. It isn't safe for
+// concurrent use; HNSWStore serialises access.
+type hnswGraph struct {
+	Nodes    []hnswNode
+	Entry    int32 // Entry point on the top layer, -1 when empty
+	MaxLevel int
+	Quant    Quantization
+
+	byID    map[string]int32
+	deleted int
+	rng     *rand.Rand
+}
+
+func newHNSWGraph(quant Quantization) *hnswGraph {
+	g := &hnswGraph{Entry: -1, Quant: quant}
+	g.init()
+	return g
+}
+
+// init sets up the unexported state, after creation or decoding
+func (g *hnswGraph) init() {
+	g.byID = make(map[string]int32, len(g.Nodes))
+	g.deleted = 0
+	for i, node := range g.Nodes {
+		if node.Deleted {
+			g.deleted++
+			continue
+		}
+		g.byID[node.ID] = int32(i)
+	}
+	g.rng = rand.New(rand.NewSource(int64(len(g.Nodes)) + 1))
+}
+
+// hnswGraphFile is a graph's structure as it's persisted, flattened into a
+// few slices: gob decodes slices of structs an element at a time through
+// reflection, which for thousands of nodes costs as much as scanning the
+// database. The encoded vectors are written after it as one raw block.
+type hnswGraphFile struct {
+	IDs        []string
+	Deleted    []bool
+	Levels     []int32 // Layers per node
+	LinkCounts []int32 // Links per node and layer, in node order
+	Links      []int32
+	VectorSize int // Bytes per encoded vector
+	Quant      Quantization
+	Entry      int32
+	MaxLevel   int
+}
+
+// flatten returns the graph's structure and its encoded vectors, in node order
+func (g *hnswGraph) flatten() (hnswGraphFile, []byte, error) {
+	f := hnswGraphFile{Quant: g.Quant, Entry: g.Entry, MaxLevel: g.MaxLevel}
+	if len(g.Nodes) > 0 {
+		f.VectorSize = len(g.Nodes[0].Vector)
+	}
+	vectors := make([]byte, 0, len(g.Nodes)*f.VectorSize)
+	for _, node := range g.Nodes {
+		if len(node.Vector) != f.VectorSize {
+			return f, nil, fmt.Errorf("vector %s is %d bytes, want %d", node.ID, len(node.Vector), f.VectorSize)
+		}
+		f.IDs = append(f.IDs, node.ID)
+		f.Deleted = append(f.Deleted, node.Deleted)
+		f.Levels = append(f.Levels, int32(len(node.Neighbors)))
+		for _, links := range node.Neighbors {
+			f.LinkCounts = append(f.LinkCounts, int32(len(links)))
+			f.Links = append(f.Links, links...)
+		}
+		vectors = append(vectors, node.Vector...)
+	}
+	return f, vectors, nil
+}
+
+// unflattenGraph restores a graph from flatten's output. The nodes' vectors
+// point into vectors rather than copying it.
+func unflattenGraph(f hnswGraphFile, vectors []byte) (*hnswGraph, error) {
+	n := len(f.IDs)
+	if len(f.Deleted) != n || len(f.Levels) != n || len(vectors) != n*f.VectorSize || f.Entry >= int32(n) {
+		return nil, fmt.Errorf("corrupt graph")
+	}
+
+	g := &hnswGraph{Nodes: make([]hnswNode, n), Entry: f.Entry, MaxLevel: f.MaxLevel, Quant: f.Quant}
+	size := f.VectorSize
+
+	layer, link := 0, 0
+	for i := range g.Nodes {
+		node := &g.Nodes[i]
+		node.ID, node.Deleted = f.IDs[i], f.Deleted[i]
+		node.Vector = vectors[i*size : (i+1)*size : (i+1)*size]
+
+		node.Neighbors = make([][]int32, f.Levels[i])
+		for l := range node.Neighbors {
+			if layer >= len(f.LinkCounts) || link+int(f.LinkCounts[layer]) > len(f.Links) {
+				return nil, fmt.Errorf("corrupt graph")
+			}
+			count := int(f.LinkCounts[layer])
+			for _, nb := range f.Links[link : link+count] {
+				if nb < 0 || int(nb) >= n {
+					return nil, fmt.Errorf("corrupt graph")
+				}
+			}
+			// Capped, so appending a link copies rather than overwriting the next node's
+			node.Neighbors[l] = f.Links[link : link+count : link+count]
+			layer++
+			link += count
+		}
+	}
+
+	g.init()
+	return g, nil
+}
+
+// live returns the number of searchable vectors
+func (g *hnswGraph) live() int {
+	return len(g.Nodes) - g.deleted
+}
+
+// randomLevel draws a node's top layer from an exponentially decaying distribution
+func (g *hnswGraph) randomLevel() int {
+	return int(-math.Log(1-g.rng.Float64()) / math.Log(hnswM))
+}
+
+// maxNeighbors is how many links a node keeps on a layer
+func maxNeighbors(level int) int {
+	if level == 0 {
+		return 2 * hnswM
+	}
+	return hnswM
+}
+
+// Insert adds a vector encoded with the graph's quantization, replacing any
+// existing vector with the same ID
+func (g *hnswGraph) Insert(id string, encoded []byte) {
+	g.Delete(id)
+
+	level := g.randomLevel()
+	n := int32(len(g.Nodes))
+	g.Nodes = append(g.Nodes, hnswNode{
+		ID:        id,
+		Vector:    encoded,
+		Neighbors: make([][]int32, level+1),
+	})
+	g.byID[id] = n
+
+	if g.Entry < 0 {
+		g.Entry = n
+		g.MaxLevel = level
+		return
+	}
+
+	// Searching with the decoded vector scores it as a query would be
+	vector := g.Quant.decode(encoded)
+
+	// Greedy descent through the layers above the new node's top layer
+	ep := g.Entry
+	for l := g.MaxLevel; l > level; l-- {
+		ep = g.greedy(vector, ep, l)
+	}
+
+	for l := min(level, g.MaxLevel); l >= 0; l-- {
+		candidates := g.searchLayer(vector, ep, hnswEfConstruction, l)
+		neighbors := g.selectNeighbors(candidates, maxNeighbors(l))
+		g.Nodes[n].Neighbors[l] = neighbors
+
+		for _, nb := range neighbors {
+			links := append(g.Nodes[nb].Neighbors[l], n)
+			if len(links) > maxNeighbors(l) {
+				links = g.prune(nb, links, l)
+			}
+			g.Nodes[nb].Neighbors[l] = links
+		}
+
+		ep = candidates[0].node
+	}
+
+	if level > g.MaxLevel {
+		g.Entry = n
+		g.MaxLevel = level
+	}
+}
+
+// Delete removes a vector from search results. Its node stays in the graph
+// to keep it connected until the graph is compacted.
+func (g *hnswGraph) Delete(id string) {
+	n, ok := g.byID[id]
+	if !ok {
+		return
+	}
+	g.Nodes[n].Deleted = true
+	delete(g.byID, id)
+	g.deleted++
+}
+
+// NeedsCompaction reports whether deleted nodes make up enough of the graph
+// to be worth rebuilding it
+func (g *hnswGraph) NeedsCompaction() bool {
+	return g.deleted > 0 && g.deleted*4 > len(g.Nodes)
+}
+
+// Compact rebuilds the graph from its live vectors
+func (g *hnswGraph) Compact() *hnswGraph {
+	fresh := newHNSWGraph(g.Quant)
+	for _, node := range g.Nodes {
+		if !node.Deleted {
+			fresh.Insert(node.ID, node.Vector)
+		}
+	}
+	return fresh
+}
+
+// Search returns the IDs and similarities of up to k live vectors closest to query
+func (g *hnswGraph) Search(query []float32, k int) []SearchResult {
+	if g.Entry < 0 || k <= 0 {
+		return nil
+	}
+	query = normalize(query)
+
+	ep := g.Entry
+	for l := g.MaxLevel; l > 0; l-- {
+		ep = g.greedy(query, ep, l)
+	}
+
+	// Deleted nodes are skipped in the results, so look a little wider
+	ef := max(hnswEfSearch, k+g.deleted*k/max(len(g.Nodes), 1))
+	candidates := g.searchLayer(query, ep, ef, 0)
+
+	var results []SearchResult
+	for _, c := range candidates {
+		if g.Nodes[c.node].Deleted {
+			continue
+		}
+		results = append(results, SearchResult{ID: g.Nodes[c.node].ID, Score: c.sim})
+		if len(results) == k {
+			break
+		}
+	}
+	return results
+}
+
+// greedy walks from ep to the closest node to query on a layer
+func (g *hnswGraph) greedy(query []float32, ep int32, level int) int32 {
+	best := g.similarity(query, ep)
+	for changed := true; changed; {
+		changed = false
+		for _, nb := range g.neighbors(ep, level) {
+			if sim := g.similarity(query, nb); sim > best {
+				best, ep, changed = sim, nb, true
+			}
+		}
+	}
+	return ep
+}
+
+// similarity compares a unit-length query with a node's vector
+func (g *hnswGraph) similarity(query []float32, n int32) float32 {
+	return g.Quant.similarity(query, g.Nodes[n].Vector)
+}
+
+// neighbors returns a node's links on a layer, if it reaches that layer
+func (g *hnswGraph) neighbors(n int32, level int) []int32 {
+	if level >= len(g.Nodes[n].Neighbors) {
+		return nil
+	}
+	return g.Nodes[n].Neighbors[level]
+}
+
+// scored is a node with its similarity to the current query
+type scored struct {
+	node int32
+	sim  float32
+}
+
+// searchLayer finds the ef nodes closest to query on a layer, best first
+func (g *hnswGraph) searchLayer(query []float32, ep int32, ef, level int) []scored {
+	visited := map[int32]bool{ep: true}
+	start := scored{ep, g.similarity(query, ep)}
+
+	candidates := &maxHeap{start} // Closest unexplored first
+	results := &minHeap{start}    // Furthest kept first
+
+	for candidates.Len() > 0 {
+		c := heap.Pop(candidates).(scored)
+		if results.Len() >= ef && c.sim < (*results)[0].sim {
+			break
+		}
+
+		for _, nb := range g.neighbors(c.node, level) {
+			if visited[nb] {
+				continue
+			}
+			visited[nb] = true
+
+			sim := g.similarity(query, nb)
+			if results.Len() < ef || sim > (*results)[0].sim {
+				heap.Push(candidates, scored{nb, sim})
+				heap.Push(results, scored{nb, sim})
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+
+	out := make([]scored, results.Len())
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = heap.Pop(results).(scored)
+	}
+	return out
+}
+
+// selectNeighbors picks up to m links from candidates (best first) with the
+// paper's heuristic: a candidate is kept only if it's closer to the new node
+// than to any already kept, which spreads links across clusters. Remaining
+// slots are filled with the closest skipped candidates.
+func (g *hnswGraph) selectNeighbors(candidates []scored, m int) []int32 {
+	selected := make([]int32, 0, m)
+	var skipped []int32
+
+	for _, c := range candidates {
+		if len(selected) == m {
+			break
+		}
+		diverse := true
+		var vector []float32
+		if len(selected) > 0 {
+			vector = g.Quant.decode(g.Nodes[c.node].Vector)
+		}
+		for _, s := range selected {
+			if g.similarity(vector, s) > c.sim {
+				diverse = false
+				break
+			}
+		}
+		if diverse {
+			selected = append(selected, c.node)
+		} else {
+			skipped = append(skipped, c.node)
+		}
+	}
+
+	for _, s := range skipped {
+		if len(selected) == m {
+			break
+		}
+		selected = append(selected, s)
+	}
+	return selected
+}
+
+// prune trims a node's links on a layer back to the maximum
+func (g *hnswGraph) prune(n int32, links []int32, level int) []int32 {
+	vector := g.Quant.decode(g.Nodes[n].Vector)
+	candidates := make([]scored, len(links))
+	for i, link := range links {
+		candidates[i] = scored{link, g.similarity(vector, link)}
+	}
+	sortScored(candidates)
+	return g.selectNeighbors(candidates, maxNeighbors(level))
+}
+
+// sortScored orders candidates best first
+func sortScored(s []scored) {
+	h := minHeap(s)
+	heap.Init(&h)
+	for i := len(s) - 1; i >= 0; i-- {
+		s[i] = heap.Pop(&h).(scored)
+	}
+}
+
+// normalize returns v scaled to unit length
+func normalize(v []float32) []float32 {
+	var norm float64
+	for _, x := range v {
+		norm += float64(x) * float64(x)
+	}
+	out := make([]float32, len(v))
+	if norm == 0 {
+		return out
+	}
+	scale := float32(1 / math.Sqrt(norm))
+	for i, x := range v {
+		out[i] = x * scale
+	}
+	return out
+}
+
+// dot returns the dot product of two vectors of the same length
+func dot(a, b []float32) float32 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var sum float32
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+// maxHeap pops the most similar node first
+type maxHeap []scored
+
+func (h maxHeap) Len() int            { return len(h) }
+func (h maxHeap) Less(i, j int) bool  { return h[i].sim > h[j].sim }
+func (h maxHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *maxHeap) Push(x interface{}) { *h = append(*h, x.(scored)) }
+func (h *maxHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// minHeap pops the least similar node first
+type minHeap []scored
+
+func (h minHeap) Len() int            { return len(h) }
+func (h minHeap) Less(i, j int) bool  { return h[i].sim < h[j].sim }
+func (h minHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *minHeap) Push(x interface{}) { *h = append(*h, x.(scored)) }
+func (h *minHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
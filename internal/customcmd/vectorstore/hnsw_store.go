@@ -0,0 +1,442 @@
+package vectorstore
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// hnswMinVectors is the size below which HNSWStore scans every vector
+// instead of building a graph: exact, and fast enough in memory
+const hnswMinVectors = 1000
+
+// hnswFileVersion changes whenever the persisted graph format does
+const hnswFileVersion = 3
+
+// HNSWStore is a Store that answers searches from memory, using an HNSW
+// graph once it holds enough vectors, while an SQLiteStore remains the
+// source of truth. Vectors stay in the database's quantization, in memory
+// and on disk. The graph is persisted next to the database with the
+// vectors' metadata and updated incrementally as vectors are added and
+// deleted. Opening a store with a current graph reads only the graph file;
+// if it's missing or out of date it's rebuilt from the database.
+type HNSWStore struct {
+	*SQLiteStore
+
+	path    string
+	vectors map[string][]byte // Encoded with quant, shared with the graph's nodes
+	meta    map[string]hnswMeta
+	graph   *hnswGraph // nil below hnswMinVectors
+	dirty   bool       // Changed since the graph was saved
+	mu      sync.RWMutex
+
+	// matching caches how many vectors match each filter searched since the
+	// last change. Searches fill it under the read lock, so it has its own
+	// mutex.
+	matching   map[string]int
+	matchingMu sync.Mutex
+}
+
+// hnswMeta is a vector's metadata as HNSWStore keeps it: the JSON is only
+// decoded for search results, while filters check values pulled out of it
+type hnswMeta struct {
+	JSON     []byte
+	Filename string
+	Fields   filterFields
+}
+
+// newHNSWMeta indexes a vector's metadata JSON
+func newHNSWMeta(metadataJSON []byte) hnswMeta {
+	var metadata map[string]interface{}
+	json.Unmarshal(metadataJSON, &metadata)
+	filename, _ := metadata["filename"].(string)
+	return hnswMeta{JSON: metadataJSON, Filename: filename, Fields: fieldsOf(metadata)}
+}
+
+// decode returns the metadata as stored, for a search result
+func (m hnswMeta) decode() map[string]interface{} {
+	var metadata map[string]interface{}
+	json.Unmarshal(m.JSON, &metadata)
+	return metadata
+}
+
+// hnswFile is the persisted graph and metadata, tagged with the database
+// state they match. The graph's vectors follow it in the file.
+type hnswFile struct {
+	Version   int
+	IndexedAt string
+	Meta      map[string]hnswMeta
+	Graph     hnswGraphFile
+}
+
+// GetHNSWPath returns where the graph for a database is persisted
+func GetHNSWPath(dbPath string) string {
+	return strings.TrimSuffix(dbPath, filepath.Ext(dbPath)) + ".hnsw"
+}
+
+// NewHNSWStore opens the search index for sqlStore: the persisted graph
+// and metadata if they match the database, or else every vector loaded from
+// the database, with the graph rebuilt and saved if there are enough
+func NewHNSWStore(ctx context.Context, sqlStore *SQLiteStore) (*HNSWStore, error) {
+	s := &HNSWStore{
+		SQLiteStore: sqlStore,
+		path:        GetHNSWPath(sqlStore.dbPath),
+		vectors:     make(map[string][]byte),
+		meta:        make(map[string]hnswMeta),
+	}
+
+	if s.loadGraph() {
+		return s, nil
+	}
+
+	err := sqlStore.forEach(ctx, func(id string, vector []byte, metadataJSON []byte) {
+		s.vectors[id] = vector
+		s.meta[id] = newHNSWMeta(metadataJSON)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load vectors: %w", err)
+	}
+
+	if len(s.vectors) < hnswMinVectors {
+		return s, nil
+	}
+
+	// Missing or stale - rebuild and persist
+	s.buildGraph()
+	if err := s.saveGraph(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// loadGraph reads the persisted graph and metadata, reporting whether they
+// hold exactly the vectors in the database as of its last index, encoded
+// the same way
+func (s *HNSWStore) loadGraph() bool {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return false
+	}
+
+	// A bytes.Reader is read exactly as far as the gob goes, leaving the vectors
+	r := bytes.NewReader(data)
+	var file hnswFile
+	if err := gob.NewDecoder(r).Decode(&file); err != nil || file.Version != hnswFileVersion {
+		return false
+	}
+
+	indexedAt, _ := s.getMetadata("indexed_at")
+	if file.IndexedAt != indexedAt {
+		return false
+	}
+
+	graph, err := unflattenGraph(file.Graph, data[len(data)-r.Len():])
+	if err != nil || graph.Quant != s.quant || graph.live() != len(file.Meta) || len(file.Meta) != s.SQLiteStore.Count() {
+		return false
+	}
+	for id := range file.Meta {
+		if _, ok := graph.byID[id]; !ok {
+			return false
+		}
+	}
+
+	s.graph = graph
+	s.meta = file.Meta
+	for id, n := range graph.byID {
+		s.vectors[id] = graph.Nodes[n].Vector
+	}
+	return true
+}
+
+// buildGraph inserts every vector into a new graph, in ID order so the
+// result doesn't depend on map iteration
+func (s *HNSWStore) buildGraph() {
+	ids := make([]string, 0, len(s.vectors))
+	for id := range s.vectors {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	s.graph = newHNSWGraph(s.quant)
+	for _, id := range ids {
+		s.graph.Insert(id, s.vectors[id])
+	}
+	s.dirty = true
+}
+
+// saveGraph persists the graph if it changed, tagged with the database's
+// index time. Caller must hold s.mu or have exclusive access.
+func (s *HNSWStore) saveGraph() error {
+	if s.graph == nil || !s.dirty {
+		return nil
+	}
+
+	if s.graph.NeedsCompaction() {
+		s.graph = s.graph.Compact()
+	}
+
+	graph, vectors, err := s.graph.flatten()
+	if err != nil {
+		return fmt.Errorf("failed to save vector index: %w", err)
+	}
+
+	indexedAt, _ := s.getMetadata("indexed_at")
+	tmp := s.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to save vector index: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	err = gob.NewEncoder(w).Encode(hnswFile{Version: hnswFileVersion, IndexedAt: indexedAt, Meta: s.meta, Graph: graph})
+	if err == nil {
+		_, err = w.Write(vectors)
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("failed to save vector index: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to save vector index: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to save vector index: %w", err)
+	}
+
+	s.dirty = false
+	return nil
+}
+
+// markDirty records an unsaved change. The persisted graph is removed on the
+// first one, so an interrupted update can't leave it looking current.
+// Caller must hold s.mu.
+func (s *HNSWStore) markDirty() {
+	if !s.dirty {
+		os.Remove(s.path)
+		s.dirty = true
+	}
+}
+
+// Add stores a vector with metadata
+func (s *HNSWStore) Add(ctx context.Context, id string, vector []float32, metadata map[string]interface{}) error {
+	if err := s.SQLiteStore.Add(ctx, id, vector, metadata); err != nil {
+		return err
+	}
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to encode metadata: %w", err)
+	}
+
+	// Encoded as the database stores it, so it scores the same after a reload
+	encoded := s.quant.encode(vector)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.vectors[id] = encoded
+	s.meta[id] = newHNSWMeta(metadataJSON)
+	s.matching = nil
+	s.markDirty()
+
+	if s.graph != nil {
+		s.graph.Insert(id, encoded)
+	} else if len(s.vectors) >= hnswMinVectors {
+		s.buildGraph()
+	}
+	return nil
+}
+
+// Search finds the top K most similar vectors
+func (s *HNSWStore) Search(ctx context.Context, query []float32, topK int) ([]SearchResult, error) {
+	return s.SearchFiltered(ctx, query, topK, Filter{})
+}
+
+// SearchFiltered finds the top K most similar vectors whose metadata matches
+// filter. Broad filters take extra candidates from the graph and drop those
+// that don't match; selective ones (or too few survivors) scan the matches.
+func (s *HNSWStore) SearchFiltered(ctx context.Context, query []float32, topK int, filter Filter) ([]SearchResult, error) {
+	if len(query) == 0 {
+		return nil, fmt.Errorf("empty query vector")
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// A filter every vector passes, like the default OS scope when no doc
+	// names an OS, filters nothing and can take the unfiltered path
+	matching := len(s.vectors)
+	if !filter.Empty() {
+		matching = s.countMatching(filter)
+	}
+
+	var results []SearchResult
+	switch {
+	case matching == len(s.vectors) && s.graph != nil:
+		results = s.graph.Search(query, topK)
+	case matching == len(s.vectors):
+		results = s.scan(query, topK, Filter{})
+	default:
+		if s.graph != nil && matching*2 >= len(s.vectors) {
+			for _, r := range s.graph.Search(query, topK*2) {
+				if filter.matchesFields(s.meta[r.ID].Fields) && len(results) < topK {
+					results = append(results, r)
+				}
+			}
+		}
+		if len(results) < min(topK, matching) {
+			results = s.scan(query, topK, filter)
+		}
+	}
+
+	for i := range results {
+		results[i].Metadata = s.meta[results[i].ID].decode()
+	}
+	return results, nil
+}
+
+// countMatching returns how many vectors match filter. Counts are cached
+// until the next change, so repeated queries with the same scope (the
+// usual case) don't walk every vector's metadata. Caller must hold s.mu.
+func (s *HNSWStore) countMatching(filter Filter) int {
+	key := filter.key()
+
+	s.matchingMu.Lock()
+	defer s.matchingMu.Unlock()
+
+	if n, ok := s.matching[key]; ok {
+		return n
+	}
+
+	n := 0
+	for _, meta := range s.meta {
+		if filter.matchesFields(meta.Fields) {
+			n++
+		}
+	}
+	if s.matching == nil {
+		s.matching = make(map[string]int)
+	}
+	s.matching[key] = n
+	return n
+}
+
+// scan compares the query with every vector that matches filter
+func (s *HNSWStore) scan(query []float32, topK int, filter Filter) []SearchResult {
+	query = normalize(query)
+	results := make([]SearchResult, 0, len(s.vectors))
+	for id, vector := range s.vectors {
+		if !filter.matchesFields(s.meta[id].Fields) {
+			continue
+		}
+		results = append(results, SearchResult{ID: id, Score: s.quant.similarity(query, vector)})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if topK < len(results) {
+		results = results[:topK]
+	}
+	return results
+}
+
+// Delete removes a vector by ID
+func (s *HNSWStore) Delete(ctx context.Context, id string) error {
+	if err := s.SQLiteStore.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.remove(id)
+	return nil
+}
+
+// DeleteFiles removes every vector that belongs to the given doc files
+func (s *HNSWStore) DeleteFiles(ctx context.Context, filenames []string) error {
+	if err := s.SQLiteStore.DeleteFiles(ctx, filenames); err != nil {
+		return err
+	}
+
+	files := make(map[string]bool, len(filenames))
+	for _, filename := range filenames {
+		files[filename] = true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, meta := range s.meta {
+		if files[meta.Filename] {
+			s.remove(id)
+		}
+	}
+	return nil
+}
+
+// remove drops a vector from memory and the graph. Caller must hold s.mu.
+func (s *HNSWStore) remove(id string) {
+	if _, ok := s.vectors[id]; !ok {
+		return
+	}
+	delete(s.vectors, id)
+	delete(s.meta, id)
+	s.matching = nil
+	s.markDirty()
+	if s.graph != nil {
+		s.graph.Delete(id)
+	}
+}
+
+// Clear removes all vectors
+func (s *HNSWStore) Clear(ctx context.Context) error {
+	if err := s.SQLiteStore.Clear(ctx); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.vectors = make(map[string][]byte)
+	s.meta = make(map[string]hnswMeta)
+	s.matching = nil
+	s.graph = nil
+	s.dirty = false
+	os.Remove(s.path)
+	return nil
+}
+
+// Count returns the number of stored vectors
+func (s *HNSWStore) Count() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.vectors)
+}
+
+// UpdateIndexTime records that indexing finished and persists the graph to match
+func (s *HNSWStore) UpdateIndexTime() error {
+	if err := s.SQLiteStore.UpdateIndexTime(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Dropping a graph whose deletions stopped it being worth keeping
+	if s.graph != nil && s.graph.live() < hnswMinVectors {
+		s.graph = nil
+		os.Remove(s.path)
+	}
+	return s.saveGraph()
+}
@@ -0,0 +1,460 @@
+package vectorstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// clusteredVectors returns n vectors scattered around a few centres, which
+// is closer to real embeddings than uniform noise and harder for HNSW
+func clusteredVectors(n, dims int, seed int64) [][]float32 {
+	rng := rand.New(rand.NewSource(seed))
+	centres := make([][]float32, 32)
+	for i := range centres {
+		centres[i] = make([]float32, dims)
+		for j := range centres[i] {
+			centres[i][j] = float32(rng.NormFloat64())
+		}
+	}
+
+	vectors := make([][]float32, n)
+	for i := range vectors {
+		centre := centres[rng.Intn(len(centres))]
+		vectors[i] = make([]float32, dims)
+		for j := range vectors[i] {
+			vectors[i][j] = centre[j] + 0.5*float32(rng.NormFloat64())
+		}
+	}
+	return vectors
+}
+
+func vectorID(i int) string {
+	return fmt.Sprintf("cmd_%05d", i)
+}
+
+// bulkStore creates an SQLiteStore holding vectors in a single transaction,
+// as one-at-a-time Adds are too slow for thousands of rows. Vector i belongs
+// to doc file i/10.
+func bulkStore(tb testing.TB, vectors [][]float32) *SQLiteStore {
+	tb.Helper()
+	s, err := NewSQLiteStore(filepath.Join(tb.TempDir(), "embeddings.db"), "test", "model", len(vectors[0]))
+	if err != nil {
+		tb.Fatalf("NewSQLiteStore() error = %v", err)
+	}
+	tb.Cleanup(func() { s.Close() })
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	for i, vector := range vectors {
+		filename := fmt.Sprintf("doc_%04d.md", i/10)
+		metadata, _ := json.Marshal(map[string]interface{}{"command": vectorID(i), "filename": filename})
+		_, err := tx.Exec(`
+			INSERT INTO embeddings (id, command, filename, file_mtime, content_hash, vector, metadata_json)
+			VALUES (?, ?, ?, 0, '', ?, ?)
+		`, vectorID(i), vectorID(i), filename, s.quant.encode(vector), string(metadata))
+		if err != nil {
+			tb.Fatal(err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		tb.Fatal(err)
+	}
+	if err := s.UpdateIndexTime(); err != nil {
+		tb.Fatal(err)
+	}
+	return s
+}
+
+// exactTopK returns the IDs of the k vectors most similar to query
+func exactTopK(vectors map[string][]float32, query []float32, k int) []string {
+	ids := make([]string, 0, len(vectors))
+	for id := range vectors {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return cosineSimilarity(query, vectors[ids[i]]) > cosineSimilarity(query, vectors[ids[j]])
+	})
+	return ids[:min(k, len(ids))]
+}
+
+// recall returns the fraction of the exact top k the store found
+func recall(t *testing.T, s Store, vectors map[string][]float32, queries [][]float32, k int) float64 {
+	t.Helper()
+	var found, total int
+	for _, query := range queries {
+		results, err := s.Search(context.Background(), query, k)
+		if err != nil {
+			t.Fatalf("Search() error = %v", err)
+		}
+		got := make(map[string]bool, len(results))
+		for _, r := range results {
+			got[r.ID] = true
+		}
+		for _, id := range exactTopK(vectors, query, k) {
+			total++
+			if got[id] {
+				found++
+			}
+		}
+	}
+	return float64(found) / float64(total)
+}
+
+func TestHNSWStoreRecall(t *testing.T) {
+	ctx := context.Background()
+	all := clusteredVectors(2100, 64, 1)
+	queries := clusteredVectors(50, 64, 2)
+
+	s, err := NewHNSWStore(ctx, bulkStore(t, all[:2000]))
+	if err != nil {
+		t.Fatalf("NewHNSWStore() error = %v", err)
+	}
+	if s.graph == nil {
+		t.Fatal("no graph built for 2000 vectors")
+	}
+
+	exact := make(map[string][]float32)
+	for i, v := range all[:2000] {
+		exact[vectorID(i)] = v
+	}
+	if r := recall(t, s, exact, queries, 10); r < 0.9 {
+		t.Errorf("recall@10 = %.3f, want >= 0.9", r)
+	}
+
+	// Incremental updates: add 100 vectors, drop 50 doc files (500 vectors)
+	for i := 2000; i < 2100; i++ {
+		if err := s.Add(ctx, vectorID(i), all[i], map[string]interface{}{"filename": "new.md"}); err != nil {
+			t.Fatal(err)
+		}
+		exact[vectorID(i)] = all[i]
+	}
+	var deleted []string
+	for f := 0; f < 50; f++ {
+		deleted = append(deleted, fmt.Sprintf("doc_%04d.md", f))
+		for i := f * 10; i < f*10+10; i++ {
+			delete(exact, vectorID(i))
+		}
+	}
+	if err := s.DeleteFiles(ctx, deleted); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := s.Count(); got != len(exact) {
+		t.Errorf("Count() = %d, want %d", got, len(exact))
+	}
+	if r := recall(t, s, exact, queries, 10); r < 0.9 {
+		t.Errorf("recall@10 after updates = %.3f, want >= 0.9", r)
+	}
+	results, _ := s.Search(ctx, all[5], 10)
+	for _, r := range results {
+		if _, ok := exact[r.ID]; !ok {
+			t.Errorf("Search() returned deleted vector %s", r.ID)
+		}
+		if r.Metadata == nil {
+			t.Errorf("Search() result %s has no metadata", r.ID)
+		}
+	}
+}
+
+func TestHNSWStoreFilterMatchingEverything(t *testing.T) {
+	ctx := context.Background()
+	queries := clusteredVectors(20, 64, 2)
+
+	// No vector names an OS, so an OS filter lets everything through and
+	// should be answered from the graph like an unfiltered search
+	s, err := NewHNSWStore(ctx, bulkStore(t, clusteredVectors(2000, 64, 1)))
+	if err != nil {
+		t.Fatalf("NewHNSWStore() error = %v", err)
+	}
+	if s.graph == nil {
+		t.Fatal("no graph built for 2000 vectors")
+	}
+
+	filter := Filter{OS: []string{"linux"}}
+	for _, q := range queries {
+		want := s.graph.Search(q, 10)
+		got, err := s.SearchFiltered(ctx, q, 10, filter)
+		if err != nil {
+			t.Fatalf("SearchFiltered() error = %v", err)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("SearchFiltered() returned %d results, want %d", len(got), len(want))
+		}
+		for i := range want {
+			if got[i].ID != want[i].ID {
+				t.Fatalf("SearchFiltered() = %s at %d, want the graph's %s", got[i].ID, i, want[i].ID)
+			}
+		}
+	}
+}
+
+func TestHNSWStorePersistence(t *testing.T) {
+	ctx := context.Background()
+	vectors := clusteredVectors(1200, 16, 3)
+	sqlStore := bulkStore(t, vectors)
+
+	s, err := NewHNSWStore(ctx, sqlStore)
+	if err != nil {
+		t.Fatalf("NewHNSWStore() error = %v", err)
+	}
+	path := GetHNSWPath(sqlStore.dbPath)
+	if filepath.Base(path) != "embeddings.hnsw" {
+		t.Errorf("GetHNSWPath() = %s, want embeddings.hnsw next to the database", path)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("graph not saved: %v", err)
+	}
+
+	// Reopening loads the saved graph rather than rebuilding it
+	reopened, err := NewHNSWStore(ctx, sqlStore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.dirty || len(reopened.graph.Nodes) != len(s.graph.Nodes) {
+		t.Error("saved graph wasn't reused")
+	}
+	// Metadata comes from the graph file too
+	if results, _ := reopened.Search(ctx, vectors[7], 1); len(results) != 1 || results[0].Metadata["filename"] != "doc_0000.md" {
+		t.Errorf("Search() after reopening = %v, want %s with its metadata", results, vectorID(7))
+	}
+
+	// An update removes the saved graph until indexing finishes
+	if err := s.Delete(ctx, vectorID(0)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("saved graph kept after an unfinished update")
+	}
+	if err := s.UpdateIndexTime(); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err = NewHNSWStore(ctx, sqlStore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.dirty || reopened.graph.live() != 1199 {
+		t.Error("graph saved after indexing wasn't reused")
+	}
+
+	// A graph saved for another index run is rebuilt
+	if err := sqlStore.setMetadata("indexed_at", "2000-01-01T00:00:00Z"); err != nil {
+		t.Fatal(err)
+	}
+	stale := &HNSWStore{SQLiteStore: sqlStore, path: GetHNSWPath(sqlStore.dbPath), vectors: make(map[string][]byte)}
+	if stale.loadGraph() {
+		t.Error("loaded a graph saved for a different index run")
+	}
+	reopened, err = NewHNSWStore(ctx, sqlStore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.graph == nil || reopened.graph.live() != 1199 {
+		t.Error("stale graph wasn't rebuilt")
+	}
+	if results, _ := reopened.Search(ctx, vectors[0], 5); len(results) != 5 || results[0].ID == vectorID(0) {
+		t.Errorf("Search() after rebuild = %v", results)
+	}
+}
+
+func TestHNSWStoreQuantized(t *testing.T) {
+	ctx := context.Background()
+	vectors := clusteredVectors(1200, 16, 4)
+	sqlStore := bulkStore(t, vectors)
+	if err := sqlStore.Quantize(ctx, QuantizeInt8); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewHNSWStore(ctx, sqlStore)
+	if err != nil {
+		t.Fatalf("NewHNSWStore() error = %v", err)
+	}
+	if got := len(s.vectors[vectorID(0)]); got != 4+16 || s.graph.Quant != QuantizeInt8 {
+		t.Errorf("vectors kept as %d bytes with %s, want 20 bytes with int8", got, s.graph.Quant)
+	}
+
+	// A vector added during the session scores as it will after reopening
+	extra := clusteredVectors(1, 16, 5)[0]
+	if err := s.Add(ctx, "extra", extra, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(s.vectors["extra"]); got != 4+16 {
+		t.Errorf("added vector kept as %d bytes, want 20", got)
+	}
+	before, _ := s.Search(ctx, extra, 1)
+	if err := s.UpdateIndexTime(); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := NewHNSWStore(ctx, sqlStore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.dirty {
+		t.Error("saved graph wasn't reused")
+	}
+	after, _ := reopened.Search(ctx, extra, 1)
+	if len(before) != 1 || len(after) != 1 || before[0].ID != "extra" || after[0].ID != "extra" || before[0].Score != after[0].Score {
+		t.Errorf("Search() = %v in the session, %v after reopening", before, after)
+	}
+
+	// A graph saved with another quantization isn't loaded
+	if err := sqlStore.Quantize(ctx, QuantizeBinary); err != nil {
+		t.Fatal(err)
+	}
+	stale := &HNSWStore{SQLiteStore: sqlStore, path: GetHNSWPath(sqlStore.dbPath), vectors: make(map[string][]byte)}
+	if stale.loadGraph() {
+		t.Error("loaded an int8 graph for a binary database")
+	}
+}
+
+func TestHNSWStoreSmallLibraries(t *testing.T) {
+	ctx := context.Background()
+	vectors := clusteredVectors(hnswMinVectors+10, 8, 4)
+	sqlStore := bulkStore(t, vectors[:hnswMinVectors-1])
+
+	s, err := NewHNSWStore(ctx, sqlStore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.graph != nil {
+		t.Error("graph built below the threshold")
+	}
+	if _, err := os.Stat(GetHNSWPath(sqlStore.dbPath)); !os.IsNotExist(err) {
+		t.Error("graph saved below the threshold")
+	}
+
+	// Small libraries are searched exactly
+	exact := make(map[string][]float32)
+	for i, v := range vectors[:hnswMinVectors-1] {
+		exact[vectorID(i)] = v
+	}
+	if r := recall(t, s, exact, vectors[:20], 10); r != 1 {
+		t.Errorf("recall@10 = %.3f, want 1", r)
+	}
+
+	// Crossing the threshold builds the graph
+	for i := hnswMinVectors - 1; i < len(vectors); i++ {
+		if err := s.Add(ctx, vectorID(i), vectors[i], nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if s.graph == nil || s.graph.live() != len(vectors) {
+		t.Fatal("graph not built after crossing the threshold")
+	}
+
+	if err := s.Clear(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if s.Count() != 0 || s.graph != nil || sqlStore.Count() != 0 {
+		t.Error("Clear() left vectors behind")
+	}
+}
+
+func benchmarkSearch(b *testing.B, newStore func(b *testing.B, vectors [][]float32) Store) {
+	for _, n := range []int{10000, 50000} {
+		b.Run(fmt.Sprintf("%d", n), func(b *testing.B) {
+			vectors := clusteredVectors(n, 384, 1)
+			queries := clusteredVectors(100, 384, 2)
+			s := newStore(b, vectors)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := s.Search(context.Background(), queries[i%len(queries)], 10); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkSearchSQLite is the linear scan over the database
+func BenchmarkSearchSQLite(b *testing.B) {
+	benchmarkSearch(b, func(b *testing.B, vectors [][]float32) Store {
+		return bulkStore(b, vectors)
+	})
+}
+
+// BenchmarkSearchMemory is a linear scan in memory
+func BenchmarkSearchMemory(b *testing.B) {
+	benchmarkSearch(b, func(b *testing.B, vectors [][]float32) Store {
+		s := NewMemoryStore()
+		for i, v := range vectors {
+			s.Add(context.Background(), vectorID(i), v, nil)
+		}
+		return s
+	})
+}
+
+func BenchmarkSearchHNSW(b *testing.B) {
+	benchmarkSearch(b, func(b *testing.B, vectors [][]float32) Store {
+		s, err := NewHNSWStore(context.Background(), bulkStore(b, vectors))
+		if err != nil {
+			b.Fatal(err)
+		}
+		return s
+	})
+}
+
+// BenchmarkOpenAndSearchHNSW is what a one-shot please run pays: opening
+// the cache, loading its saved graph and answering the first query
+func BenchmarkOpenAndSearchHNSW(b *testing.B) {
+	for _, n := range []int{10000, 50000} {
+		b.Run(fmt.Sprintf("%d", n), func(b *testing.B) {
+			ctx := context.Background()
+			queries := clusteredVectors(100, 384, 2)
+			dbPath := bulkStore(b, clusteredVectors(n, 384, 1)).dbPath
+			if _, err := NewHNSWStore(ctx, mustOpen(b, dbPath)); err != nil {
+				b.Fatal(err)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				sqlStore := mustOpen(b, dbPath)
+				s, err := NewHNSWStore(ctx, sqlStore)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if _, err := s.Search(ctx, queries[i%len(queries)], 10); err != nil {
+					b.Fatal(err)
+				}
+				sqlStore.Close()
+			}
+		})
+	}
+}
+
+// BenchmarkOpenAndSearchSQLite is the same without a graph: opening the
+// cache and scanning it
+func BenchmarkOpenAndSearchSQLite(b *testing.B) {
+	for _, n := range []int{10000, 50000} {
+		b.Run(fmt.Sprintf("%d", n), func(b *testing.B) {
+			queries := clusteredVectors(100, 384, 2)
+			dbPath := bulkStore(b, clusteredVectors(n, 384, 1)).dbPath
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				s := mustOpen(b, dbPath)
+				if _, err := s.Search(context.Background(), queries[i%len(queries)], 10); err != nil {
+					b.Fatal(err)
+				}
+				s.Close()
+			}
+		})
+	}
+}
+
+func mustOpen(b *testing.B, dbPath string) *SQLiteStore {
+	b.Helper()
+	s, err := OpenSQLiteStore(dbPath)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return s
+}
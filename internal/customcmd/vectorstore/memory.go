@@ -40,6 +40,11 @@ func (m *MemoryStore) Add(ctx context.Context, id string, vector []float32, meta
 
 // Search finds the top K most similar vectors using cosine similarity
 func (m *MemoryStore) Search(ctx context.Context, query []float32, topK int) ([]SearchResult, error) {
+	return m.SearchFiltered(ctx, query, topK, Filter{})
+}
+
+// SearchFiltered finds the top K most similar vectors whose metadata matches filter
+func (m *MemoryStore) SearchFiltered(ctx context.Context, query []float32, topK int, filter Filter) ([]SearchResult, error) {
 	if len(query) == 0 {
 		return nil, fmt.Errorf("empty query vector")
 	}
@@ -60,6 +65,9 @@ func (m *MemoryStore) Search(ctx context.Context, query []float32, topK int) ([]
 	results := make([]scoredResult, 0, len(m.vectors))
 
 	for id, vector := range m.vectors {
+		if !filter.Matches(m.metadata[id]) {
+			continue
+		}
 		score := cosineSimilarity(query, vector)
 		results = append(results, scoredResult{id: id, score: score})
 	}
@@ -0,0 +1,97 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// MultiStore combines one store per doc source (e.g. project, user and
+// system docs, each with its own cache) behind a single Store. Vectors are
+// added to the store named by their "source" metadata, and searches merge
+// the results of every store the filter allows.
+type MultiStore struct {
+	stores  map[string]Store
+	sources []string // In the order they were set
+}
+
+// NewMultiStore creates an empty MultiStore
+func NewMultiStore() *MultiStore {
+	return &MultiStore{stores: make(map[string]Store)}
+}
+
+// Set uses store for vectors from source
+func (m *MultiStore) Set(source string, store Store) {
+	if _, ok := m.stores[source]; !ok {
+		m.sources = append(m.sources, source)
+	}
+	m.stores[source] = store
+}
+
+// Add stores a vector in the store for its source
+func (m *MultiStore) Add(ctx context.Context, id string, vector []float32, metadata map[string]interface{}) error {
+	source, _ := metadata[MetaSource].(string)
+	store, ok := m.stores[source]
+	if !ok {
+		return fmt.Errorf("no store for source %q", source)
+	}
+	return store.Add(ctx, id, vector, metadata)
+}
+
+// Search finds the top K most similar vectors across all stores
+func (m *MultiStore) Search(ctx context.Context, query []float32, topK int) ([]SearchResult, error) {
+	return m.SearchFiltered(ctx, query, topK, Filter{})
+}
+
+// SearchFiltered finds the top K most similar vectors whose metadata matches
+// filter across all stores, skipping stores for sources the filter excludes
+func (m *MultiStore) SearchFiltered(ctx context.Context, query []float32, topK int, filter Filter) ([]SearchResult, error) {
+	var results []SearchResult
+	for _, source := range m.sources {
+		if len(filter.Sources) > 0 && !anyOf([]string{source}, filter.Sources) {
+			continue
+		}
+		found, err := m.stores[source].SearchFiltered(ctx, query, topK, filter)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", source, err)
+		}
+		results = append(results, found...)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if topK < len(results) {
+		results = results[:topK]
+	}
+	return results, nil
+}
+
+// Delete removes a vector by ID from every store
+func (m *MultiStore) Delete(ctx context.Context, id string) error {
+	for _, source := range m.sources {
+		if err := m.stores[source].Delete(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Clear removes all vectors from every store
+func (m *MultiStore) Clear(ctx context.Context) error {
+	for _, source := range m.sources {
+		if err := m.stores[source].Clear(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Count returns the number of vectors across all stores
+func (m *MultiStore) Count() int {
+	count := 0
+	for _, store := range m.stores {
+		count += store.Count()
+	}
+	return count
+}
@@ -0,0 +1,179 @@
+package vectorstore
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Quantization is how vectors are encoded in the SQLite cache. Vectors are
+// normalised before encoding, so every scheme keeps the direction and drops
+// only the length, which cosine similarity ignores anyway.
+type Quantization string
+
+const (
+	QuantizeNone   Quantization = "none"   // float32, 4 bytes per dimension
+	QuantizeInt8   Quantization = "int8"   // 1 byte per dimension, plus a scale
+	QuantizeBinary Quantization = "binary" // 1 bit per dimension (sign only)
+)
+
+// ParseQuantization validates a configured quantization, defaulting to none
+func ParseQuantization(s string) (Quantization, error) {
+	switch q := Quantization(s); q {
+	case "":
+		return QuantizeNone, nil
+	case QuantizeNone, QuantizeInt8, QuantizeBinary:
+		return q, nil
+	default:
+		return "", fmt.Errorf("unknown quantization %q (want none, int8 or binary)", s)
+	}
+}
+
+// precision ranks schemes by how much of a vector they keep
+func (q Quantization) precision() int {
+	switch q {
+	case QuantizeInt8:
+		return 1
+	case QuantizeBinary:
+		return 0
+	default:
+		return 2
+	}
+}
+
+// encode stores a vector, normalising it first
+func (q Quantization) encode(v []float32) []byte {
+	v = normalize(v)
+	switch q {
+	case QuantizeInt8:
+		return encodeInt8(v)
+	case QuantizeBinary:
+		return encodeBinary(v)
+	default:
+		return encodeVector(v)
+	}
+}
+
+// decode restores a unit-length vector from its encoding
+func (q Quantization) decode(b []byte) []float32 {
+	switch q {
+	case QuantizeInt8:
+		return normalize(decodeInt8(b))
+	case QuantizeBinary:
+		return decodeBinary(b)
+	default:
+		return decodeVector(b)
+	}
+}
+
+// similarity compares a unit-length query with an encoded vector without
+// decoding it, giving the dot product with what decode would return: the
+// cosine similarity
+func (q Quantization) similarity(query []float32, b []byte) float32 {
+	switch q {
+	case QuantizeInt8:
+		if len(b) != 4+len(query) {
+			return 0
+		}
+		// The scale cancels out once the vector is normalised
+		var sum, norm float32
+		for i, x := range query {
+			c := float32(int8(b[4+i]))
+			sum += x * c
+			norm += c * c
+		}
+		if norm == 0 {
+			return 0
+		}
+		return sum / float32(math.Sqrt(float64(norm)))
+	case QuantizeBinary:
+		if len(b) != 4+(len(query)+7)/8 || int(binary.LittleEndian.Uint32(b)) != len(query) {
+			return 0
+		}
+		var sum float32
+		for i, x := range query {
+			if b[4+i/8]&(1<<(i%8)) != 0 {
+				sum += x
+			} else {
+				sum -= x
+			}
+		}
+		return sum / float32(math.Sqrt(float64(len(query))))
+	default:
+		if len(b) != 4*len(query) {
+			return 0
+		}
+		var sum float32
+		for i, x := range query {
+			sum += x * math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:]))
+		}
+		return sum
+	}
+}
+
+// encodeInt8 scales a vector so its largest component is ±127 and rounds
+// each component to a byte, prefixed by the float32 scale
+func encodeInt8(v []float32) []byte {
+	var maxAbs float32
+	for _, x := range v {
+		maxAbs = max(maxAbs, float32(math.Abs(float64(x))))
+	}
+
+	b := make([]byte, 4+len(v))
+	binary.LittleEndian.PutUint32(b, math.Float32bits(maxAbs))
+	if maxAbs == 0 {
+		return b
+	}
+	for i, x := range v {
+		b[4+i] = byte(int8(math.Round(float64(x / maxAbs * 127))))
+	}
+	return b
+}
+
+// decodeInt8 reverses encodeInt8
+func decodeInt8(b []byte) []float32 {
+	if len(b) < 4 {
+		return nil
+	}
+	scale := math.Float32frombits(binary.LittleEndian.Uint32(b)) / 127
+	v := make([]float32, len(b)-4)
+	for i := range v {
+		v[i] = float32(int8(b[4+i])) * scale
+	}
+	return v
+}
+
+// encodeBinary keeps one bit per component, set for positive values,
+// prefixed by the uint32 dimension count
+func encodeBinary(v []float32) []byte {
+	b := make([]byte, 4+(len(v)+7)/8)
+	binary.LittleEndian.PutUint32(b, uint32(len(v)))
+	for i, x := range v {
+		if x > 0 {
+			b[4+i/8] |= 1 << (i % 8)
+		}
+	}
+	return b
+}
+
+// decodeBinary expands the bits to ±1/√dims, a unit vector, so comparing a
+// full-precision query against it still gives a cosine similarity
+func decodeBinary(b []byte) []float32 {
+	if len(b) < 4 {
+		return nil
+	}
+	dims := int(binary.LittleEndian.Uint32(b))
+	if len(b) < 4+(dims+7)/8 {
+		return nil
+	}
+	unit := float32(1 / math.Sqrt(float64(dims)))
+	v := make([]float32, dims)
+	for i := range v {
+		if b[4+i/8]&(1<<(i%8)) != 0 {
+			v[i] = unit
+		} else {
+			v[i] = -unit
+		}
+	}
+	return v
+}
@@ -0,0 +1,219 @@
+package vectorstore
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestQuantizationRecall(t *testing.T) {
+	ctx := context.Background()
+	vectors := clusteredVectors(200, 384, 5)
+	queries := clusteredVectors(50, 384, 6)
+	exact := make(map[string][]float32)
+	for i, v := range vectors {
+		exact[vectorID(i)] = v
+	}
+
+	tests := []struct {
+		quant     Quantization
+		minRecall float64
+		blobSize  int
+	}{
+		{QuantizeNone, 1, 384 * 4},
+		{QuantizeInt8, 0.95, 4 + 384},
+		{QuantizeBinary, 0.4, 4 + 384/8}, // Tight clusters are hard on 1 bit per dimension
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.quant), func(t *testing.T) {
+			s, err := NewSQLiteStore(filepath.Join(t.TempDir(), "embeddings.db"), "test", "model", 384)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+			if err := s.Quantize(ctx, tt.quant); err != nil {
+				t.Fatalf("Quantize() error = %v", err)
+			}
+			if got := len(tt.quant.encode(vectors[0])); got != tt.blobSize {
+				t.Errorf("encoded size = %d bytes, want %d", got, tt.blobSize)
+			}
+
+			for i, v := range vectors {
+				if err := s.Add(ctx, vectorID(i), v, nil); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			r := recall(t, s, exact, queries, 10)
+			t.Logf("%s recall@10 = %.3f", tt.quant, r)
+			if r < tt.minRecall {
+				t.Errorf("recall@10 = %.3f, want >= %.2f", r, tt.minRecall)
+			}
+
+			// Scores stay cosine similarities
+			results, err := s.Search(ctx, vectors[0], 1)
+			if err != nil || len(results) != 1 {
+				t.Fatalf("Search() = %v, %v", results, err)
+			}
+			if want := cosineSimilarity(vectors[0], vectors[0]); math.Abs(float64(results[0].Score-want)) > 0.25 {
+				t.Errorf("self-similarity = %.3f, want about %.3f", results[0].Score, want)
+			}
+		})
+	}
+}
+
+func TestQuantizationSimilarity(t *testing.T) {
+	vectors := clusteredVectors(20, 100, 7)
+	query := normalize(vectors[0])
+	for _, quant := range []Quantization{QuantizeNone, QuantizeInt8, QuantizeBinary} {
+		for i, v := range vectors {
+			encoded := quant.encode(v)
+			want := dot(query, quant.decode(encoded))
+			if got := quant.similarity(query, encoded); math.Abs(float64(got-want)) > 1e-5 {
+				t.Errorf("%s similarity(vector %d) = %.5f, want %.5f as decoded", quant, i, got, want)
+			}
+		}
+		if got := quant.similarity(query[:50], quant.encode(vectors[1])); got != 0 {
+			t.Errorf("%s similarity with mismatched dimensions = %v, want 0", quant, got)
+		}
+	}
+}
+
+func TestSQLiteStoreMigratesV1(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "embeddings.db")
+
+	// A version 1 cache, with vectors stored as they came from the embedder
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`
+		CREATE TABLE metadata (key TEXT PRIMARY KEY, value TEXT NOT NULL);
+		CREATE TABLE embeddings (
+			id TEXT PRIMARY KEY,
+			command TEXT NOT NULL,
+			filename TEXT NOT NULL,
+			file_mtime INTEGER NOT NULL,
+			content_hash TEXT NOT NULL DEFAULT '',
+			vector BLOB NOT NULL,
+			metadata_json TEXT
+		);
+		INSERT INTO metadata VALUES ('version', '1'), ('provider', 'test'), ('model', 'model'), ('dimensions', '2');
+	`)
+	if err == nil {
+		_, err = db.Exec(`INSERT INTO embeddings VALUES ('cmd_a', 'a', 'a.md', 0, 'hash-a', ?, '{}'), ('cmd_b', 'b', 'b.md', 0, 'hash-b', ?, '{}')`,
+			encodeVector([]float32{3, 4}), encodeVector([]float32{0, -2}))
+	}
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := OpenSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("OpenSQLiteStore() error = %v", err)
+	}
+	defer s.Close()
+
+	if version, _ := s.getMetadata("version"); version != "2" {
+		t.Errorf("version = %s after migration, want 2", version)
+	}
+	if s.Quantization() != QuantizeNone {
+		t.Errorf("Quantization() = %s, want none", s.Quantization())
+	}
+
+	results, err := s.Search(ctx, []float32{3, 4}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].ID != "cmd_a" || math.Abs(float64(results[0].Score)-1) > 1e-5 {
+		t.Errorf("Search() after migration = %v", results)
+	}
+	var norm float64
+	s.forEach(ctx, func(id string, vector []byte, _ []byte) {
+		norm = 0
+		for _, x := range s.quant.decode(vector) {
+			norm += float64(x) * float64(x)
+		}
+		if math.Abs(norm-1) > 1e-5 {
+			t.Errorf("%s has norm %.3f after migration, want 1", id, math.Sqrt(norm))
+		}
+	})
+
+	// Content hashes survive, so nothing is re-embedded
+	diff, err := s.Diff([]CommandDoc{{Filename: "a.md", ContentHash: "hash-a"}, {Filename: "b.md", ContentHash: "hash-b"}})
+	if err != nil || !diff.Empty() {
+		t.Errorf("Diff() after migration = %v, %v", diff, err)
+	}
+}
+
+func TestSQLiteStoreQuantize(t *testing.T) {
+	ctx := context.Background()
+	vectors := clusteredVectors(1100, 64, 7)
+	s := bulkStore(t, vectors)
+
+	// Build and save a search graph from the full-precision vectors
+	if _, err := NewHNSWStore(ctx, s); err != nil {
+		t.Fatal(err)
+	}
+	graphPath := GetHNSWPath(s.dbPath)
+	if _, err := os.Stat(graphPath); err != nil {
+		t.Fatal("graph not saved")
+	}
+
+	sizeBefore := fileSize(t, s.dbPath)
+	if err := s.Quantize(ctx, QuantizeInt8); err != nil {
+		t.Fatalf("Quantize(int8) error = %v", err)
+	}
+	if sizeAfter := fileSize(t, s.dbPath); sizeAfter >= sizeBefore*2/3 {
+		t.Errorf("cache is %d bytes after int8 quantization, was %d", sizeAfter, sizeBefore)
+	}
+	if _, err := os.Stat(graphPath); !os.IsNotExist(err) {
+		t.Error("graph of the old vectors kept after quantizing")
+	}
+
+	// The setting persists
+	reopened, err := OpenSQLiteStore(s.dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	if reopened.Quantization() != QuantizeInt8 {
+		t.Errorf("Quantization() after reopening = %s, want int8", reopened.Quantization())
+	}
+	if results, _ := reopened.Search(ctx, vectors[3], 1); len(results) != 1 || results[0].ID != vectorID(3) {
+		t.Errorf("Search() after quantizing = %v", results)
+	}
+
+	// Precision can't be recovered
+	if err := reopened.Quantize(ctx, QuantizeNone); err == nil {
+		t.Error("Quantize(none) from int8 succeeded")
+	}
+	if err := reopened.Quantize(ctx, QuantizeBinary); err != nil {
+		t.Errorf("Quantize(binary) from int8 error = %v", err)
+	}
+}
+
+func TestParseQuantization(t *testing.T) {
+	for input, want := range map[string]Quantization{"": QuantizeNone, "none": QuantizeNone, "int8": QuantizeInt8, "binary": QuantizeBinary} {
+		if got, err := ParseQuantization(input); err != nil || got != want {
+			t.Errorf("ParseQuantization(%q) = %s, %v; want %s", input, got, err, want)
+		}
+	}
+	if _, err := ParseQuantization("fp16"); err == nil {
+		t.Error("ParseQuantization(fp16) succeeded")
+	}
+}
+
+func fileSize(t *testing.T, path string) int64 {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info.Size()
+}
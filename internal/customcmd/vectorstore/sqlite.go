@@ -11,12 +11,18 @@ import (
 	"path/filepath"
 	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	_ "modernc.org/sqlite"
 )
 
+// schemaVersion is the cache layout this code writes. Version 1 stored raw
+// float32 vectors; version 2 stores them normalised, in the encoding named
+// by the "quantization" metadata key.
+const schemaVersion = 2
+
 // SQLiteStore is a persistent vector store using SQLite
 type SQLiteStore struct {
 	db       *sql.DB
@@ -24,6 +30,7 @@ type SQLiteStore struct {
 	provider string
 	model    string
 	dims     int
+	quant    Quantization
 	mu       sync.RWMutex
 }
 
@@ -55,11 +62,13 @@ func NewSQLiteStore(dbPath, provider, model string, dims int) (*SQLiteStore, err
 		return nil, fmt.Errorf("failed to initialize schema: %w", err)
 	}
 
-	// Store metadata
-	if err := store.setMetadata("version", "1"); err != nil {
+	// Bring any existing rows up to the current layout
+	if err := store.migrate(); err != nil {
 		db.Close()
 		return nil, err
 	}
+
+	// Store metadata
 	if err := store.setMetadata("provider", provider); err != nil {
 		db.Close()
 		return nil, err
@@ -98,6 +107,12 @@ func OpenSQLiteStore(dbPath string) (*SQLiteStore, error) {
 		dbPath: dbPath,
 	}
 
+	// Add any columns introduced since the cache was created
+	if err := store.initSchema(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize schema: %w", err)
+	}
+
 	// Load metadata
 	provider, err := store.getMetadata("provider")
 	if err != nil {
@@ -127,9 +142,134 @@ func OpenSQLiteStore(dbPath string) (*SQLiteStore, error) {
 	store.model = model
 	store.dims = dims
 
+	if err := store.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
 	return store, nil
 }
 
+// migrate upgrades the cache to schemaVersion and loads its quantization.
+// Version 1 caches hold raw float32 vectors, which are normalised in place.
+// A new cache has no version yet; migrating its empty table is a no-op.
+func (s *SQLiteStore) migrate() error {
+	version, err := s.getMetadata("version")
+	if err != nil {
+		version = "1"
+	}
+
+	switch version {
+	case "1":
+		if err := s.rewriteVectors(context.Background(), func(b []byte) []byte {
+			return QuantizeNone.encode(decodeVector(b))
+		}); err != nil {
+			return fmt.Errorf("failed to migrate cache: %w", err)
+		}
+		if err := s.setMetadata("quantization", string(QuantizeNone)); err != nil {
+			return err
+		}
+	case strconv.Itoa(schemaVersion):
+	default:
+		return fmt.Errorf("cache version %s is newer than this version of please supports", version)
+	}
+
+	if err := s.setMetadata("version", strconv.Itoa(schemaVersion)); err != nil {
+		return err
+	}
+
+	stored, _ := s.getMetadata("quantization")
+	quant, err := ParseQuantization(stored)
+	if err != nil {
+		return err
+	}
+	s.quant = quant
+	return s.setMetadata("quantization", string(quant))
+}
+
+// Quantization returns how the cache encodes vectors
+func (s *SQLiteStore) Quantization() Quantization {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.quant
+}
+
+// Quantize re-encodes the stored vectors with another quantization and
+// compacts the database file. Stored vectors can only lose precision this
+// way; going back to a finer encoding means clearing and re-embedding them.
+func (s *SQLiteStore) Quantize(ctx context.Context, quant Quantization) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if quant == s.quant {
+		return nil
+	}
+	if quant.precision() > s.quant.precision() {
+		var count int
+		if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM embeddings`).Scan(&count); err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("can't convert %s vectors to %s without re-embedding", s.quant, quant)
+		}
+	}
+
+	from := s.quant
+	if err := s.rewriteVectors(ctx, func(b []byte) []byte {
+		return quant.encode(from.decode(b))
+	}); err != nil {
+		return fmt.Errorf("failed to quantize cache: %w", err)
+	}
+	if err := s.setMetadata("quantization", string(quant)); err != nil {
+		return err
+	}
+	s.quant = quant
+
+	// Return the freed pages to the filesystem
+	_, err := s.db.ExecContext(ctx, `VACUUM`)
+	return err
+}
+
+// rewriteVectors replaces every stored vector blob with convert(blob) in one
+// transaction. Any saved search graph holds the old vectors, so it's removed.
+func (s *SQLiteStore) rewriteVectors(ctx context.Context, convert func([]byte) []byte) error {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, vector FROM embeddings`)
+	if err != nil {
+		return err
+	}
+	blobs := make(map[string][]byte)
+	for rows.Next() {
+		var id string
+		var blob []byte
+		if err := rows.Scan(&id, &blob); err != nil {
+			rows.Close()
+			return err
+		}
+		blobs[id] = convert(blob)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for id, blob := range blobs {
+		if _, err := tx.ExecContext(ctx, `UPDATE embeddings SET vector = ? WHERE id = ?`, blob, id); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	os.Remove(GetHNSWPath(s.dbPath))
+	return nil
+}
+
 // initSchema creates the database schema
 func (s *SQLiteStore) initSchema() error {
 	schema := `
@@ -143,6 +283,7 @@ func (s *SQLiteStore) initSchema() error {
 		command TEXT NOT NULL,
 		filename TEXT NOT NULL,
 		file_mtime INTEGER NOT NULL,
+		content_hash TEXT NOT NULL DEFAULT '',
 		vector BLOB NOT NULL,
 		metadata_json TEXT
 	);
@@ -152,7 +293,52 @@ func (s *SQLiteStore) initSchema() error {
 	CREATE INDEX IF NOT EXISTS idx_mtime ON embeddings(file_mtime);
 	`
 
-	_, err := s.db.Exec(schema)
+	if _, err := s.db.Exec(schema); err != nil {
+		return err
+	}
+
+	// Caches created before content hashes were tracked lack the column;
+	// their rows get an empty hash, so every doc is re-embedded once
+	if err := s.ensureColumn("embeddings", "content_hash", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+
+	// Columns that searches filter on, encoded by joinColumn. Rows from before
+	// they existed are re-embedded (and filled in) because the doc hashes
+	// cover the same fields.
+	for _, column := range []string{"categories", "priority", "source", "os"} {
+		if err := s.ensureColumn("embeddings", column, "TEXT NOT NULL DEFAULT ''"); err != nil {
+			return err
+		}
+	}
+	_, err := s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_source ON embeddings(source)`)
+	return err
+}
+
+// ensureColumn adds a column to a table if it doesn't exist yet
+func (s *SQLiteStore) ensureColumn(table, column, definition string) error {
+	rows, err := s.db.Query(fmt.Sprintf(`PRAGMA table_info(%s)`, table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, table, column, definition))
 	return err
 }
 
@@ -166,7 +352,7 @@ func (s *SQLiteStore) Add(ctx context.Context, id string, vector []float32, meta
 	defer s.mu.Unlock()
 
 	// Encode vector to binary
-	vectorBlob := encodeVector(vector)
+	vectorBlob := s.quant.encode(vector)
 
 	// Encode metadata to JSON
 	metadataJSON, err := json.Marshal(metadata)
@@ -178,18 +364,30 @@ func (s *SQLiteStore) Add(ctx context.Context, id string, vector []float32, meta
 	command, _ := metadata["command"].(string)
 	filename, _ := metadata["filename"].(string)
 	fileMtime, _ := metadata["file_mtime"].(int64)
+	contentHash, _ := metadata["content_hash"].(string)
 
 	// Insert or replace
 	_, err = s.db.ExecContext(ctx, `
-		INSERT OR REPLACE INTO embeddings (id, command, filename, file_mtime, vector, metadata_json)
-		VALUES (?, ?, ?, ?, ?, ?)
-	`, id, command, filename, fileMtime, vectorBlob, string(metadataJSON))
+		INSERT OR REPLACE INTO embeddings (id, command, filename, file_mtime, content_hash, vector, metadata_json,
+			categories, priority, source, os)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, id, command, filename, fileMtime, contentHash, vectorBlob, string(metadataJSON),
+		joinColumn(metadataStrings(metadata[MetaCategories])),
+		joinColumn(metadataStrings(metadata[MetaPriority])),
+		joinColumn(metadataStrings(metadata[MetaSource])),
+		joinColumn(metadataStrings(metadata[MetaOS])))
 
 	return err
 }
 
 // Search finds the top K most similar vectors using cosine similarity
 func (s *SQLiteStore) Search(ctx context.Context, query []float32, topK int) ([]SearchResult, error) {
+	return s.SearchFiltered(ctx, query, topK, Filter{})
+}
+
+// SearchFiltered finds the top K most similar vectors whose metadata matches
+// filter. The filter is applied in SQL, so only matching vectors are read.
+func (s *SQLiteStore) SearchFiltered(ctx context.Context, query []float32, topK int, filter Filter) ([]SearchResult, error) {
 	if len(query) == 0 {
 		return nil, fmt.Errorf("empty query vector")
 	}
@@ -197,8 +395,17 @@ func (s *SQLiteStore) Search(ctx context.Context, query []float32, topK int) ([]
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	// Load all vectors from database
-	rows, err := s.db.QueryContext(ctx, `SELECT id, vector, metadata_json FROM embeddings`)
+	// Stored vectors are unit length, so with a unit query the dot product
+	// is the cosine similarity
+	query = normalize(query)
+
+	// Load the matching vectors from database
+	sqlQuery := `SELECT id, vector, metadata_json FROM embeddings`
+	where, args := filter.where()
+	if where != "" {
+		sqlQuery += ` WHERE ` + where
+	}
+	rows, err := s.db.QueryContext(ctx, sqlQuery, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -222,8 +429,7 @@ func (s *SQLiteStore) Search(ctx context.Context, query []float32, topK int) ([]
 			continue
 		}
 
-		vector := decodeVector(vectorBlob)
-		score := cosineSimilarity(query, vector)
+		score := s.quant.similarity(query, vectorBlob)
 
 		var metadata map[string]interface{}
 		json.Unmarshal([]byte(metadataJSON), &metadata)
@@ -258,6 +464,32 @@ func (s *SQLiteStore) Search(ctx context.Context, query []float32, topK int) ([]
 	return searchResults, nil
 }
 
+// forEach calls fn with every stored vector, as encoded by the store's
+// quantization, and its metadata JSON
+func (s *SQLiteStore) forEach(ctx context.Context, fn func(id string, vector []byte, metadataJSON []byte)) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.QueryContext(ctx, `SELECT id, vector, metadata_json FROM embeddings`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		var vectorBlob []byte
+		var metadataJSON []byte
+
+		if err := rows.Scan(&id, &vectorBlob, &metadataJSON); err != nil {
+			return err
+		}
+
+		fn(id, vectorBlob, metadataJSON)
+	}
+	return rows.Err()
+}
+
 // Delete removes a vector by ID
 func (s *SQLiteStore) Delete(ctx context.Context, id string) error {
 	s.mu.Lock()
@@ -267,6 +499,19 @@ func (s *SQLiteStore) Delete(ctx context.Context, id string) error {
 	return err
 }
 
+// DeleteFiles removes every vector that belongs to the given doc files
+func (s *SQLiteStore) DeleteFiles(ctx context.Context, filenames []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, filename := range filenames {
+		if _, err := s.db.ExecContext(ctx, `DELETE FROM embeddings WHERE filename = ?`, filename); err != nil {
+			return fmt.Errorf("failed to delete embeddings for %s: %w", filename, err)
+		}
+	}
+	return nil
+}
+
 // Clear removes all vectors
 func (s *SQLiteStore) Clear(ctx context.Context) error {
 	s.mu.Lock()
@@ -295,9 +540,10 @@ func (s *SQLiteStore) Close() error {
 	return s.db.Close()
 }
 
-// IsValid checks if the cache is valid for the given documents and configuration
-func (s *SQLiteStore) IsValid(docs []CommandDoc, provider, model string, dims int) (bool, string) {
-	// 1. Check metadata matches
+// Matches checks that the cache was built with the given embedding
+// configuration. Vectors from another model can't be compared, so a
+// mismatch means the whole cache must be rebuilt.
+func (s *SQLiteStore) Matches(provider, model string, dims int) (bool, string) {
 	storedProvider, err := s.getMetadata("provider")
 	if err != nil || storedProvider != provider {
 		return false, fmt.Sprintf("provider changed: %s → %s", storedProvider, provider)
@@ -308,56 +554,113 @@ func (s *SQLiteStore) IsValid(docs []CommandDoc, provider, model string, dims in
 		return false, fmt.Sprintf("model changed: %s → %s", storedModel, model)
 	}
 
+	// Embedders that detect their size from responses don't know it yet
+	if dims == 0 {
+		return true, ""
+	}
+
 	storedDims, err := s.getMetadata("dimensions")
 	if err != nil || storedDims != strconv.Itoa(dims) {
 		return false, fmt.Sprintf("dimensions changed: %s → %d", storedDims, dims)
 	}
 
-	// 2. Build map of expected filenames
-	expectedFiles := make(map[string]time.Time)
-	for _, doc := range docs {
-		expectedFiles[doc.Filename] = doc.UpdatedAt
+	return true, ""
+}
+
+// SetDimensions records the size of the stored vectors, once an embedder
+// that detects it from responses knows it
+func (s *SQLiteStore) SetDimensions(dims int) error {
+	s.mu.Lock()
+	s.dims = dims
+	s.mu.Unlock()
+	return s.setMetadata("dimensions", strconv.Itoa(dims))
+}
+
+// Diff describes how the cached docs differ from the docs on disk
+type Diff struct {
+	Added     []CommandDoc // New docs
+	Updated   []CommandDoc // Docs whose content changed
+	Removed   []string     // Filenames of docs that no longer exist
+	Unchanged int
+}
+
+// Changed returns the docs that need (re-)embedding
+func (d *Diff) Changed() []CommandDoc {
+	return append(append([]CommandDoc{}, d.Added...), d.Updated...)
+}
+
+// Empty reports whether the cache is up to date
+func (d *Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Updated) == 0 && len(d.Removed) == 0
+}
+
+// String summarises the diff, e.g. "3 updated, 1 removed, 40 unchanged"
+func (d *Diff) String() string {
+	var parts []string
+	if len(d.Added) > 0 {
+		parts = append(parts, fmt.Sprintf("%d added", len(d.Added)))
+	}
+	if len(d.Updated) > 0 {
+		parts = append(parts, fmt.Sprintf("%d updated", len(d.Updated)))
+	}
+	if len(d.Removed) > 0 {
+		parts = append(parts, fmt.Sprintf("%d removed", len(d.Removed)))
 	}
+	parts = append(parts, fmt.Sprintf("%d unchanged", d.Unchanged))
+	return strings.Join(parts, ", ")
+}
 
-	// 3. Check all embeddings have corresponding files with matching mtimes
-	rows, err := s.db.Query(`SELECT filename, file_mtime FROM embeddings`)
+// Diff compares the content hashes stored for each doc file against docs.
+// A file whose vectors have mixed hashes (e.g. an interrupted update) counts
+// as updated.
+func (s *SQLiteStore) Diff(docs []CommandDoc) (*Diff, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(`SELECT filename, content_hash FROM embeddings`)
 	if err != nil {
-		return false, "failed to query embeddings"
+		return nil, fmt.Errorf("failed to query embeddings: %w", err)
 	}
 	defer rows.Close()
 
-	cachedFiles := make(map[string]bool)
-
+	cached := make(map[string]string)
 	for rows.Next() {
-		var filename string
-		var fileMtime int64
-
-		if err := rows.Scan(&filename, &fileMtime); err != nil {
-			continue
+		var filename, hash string
+		if err := rows.Scan(&filename, &hash); err != nil {
+			return nil, err
 		}
-
-		cachedFiles[filename] = true
-
-		// Check if file still exists in docs
-		expectedMtime, exists := expectedFiles[filename]
-		if !exists {
-			return false, fmt.Sprintf("file deleted: %s", filename)
+		if existing, ok := cached[filename]; ok && existing != hash {
+			hash = ""
 		}
+		cached[filename] = hash
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-		// Check if mtime matches
-		if expectedMtime.Unix() != fileMtime {
-			return false, fmt.Sprintf("file modified: %s", filename)
+	diff := &Diff{}
+	seen := make(map[string]bool)
+	for _, doc := range docs {
+		seen[doc.Filename] = true
+		hash, ok := cached[doc.Filename]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, doc)
+		case hash == "" || hash != doc.ContentHash:
+			diff.Updated = append(diff.Updated, doc)
+		default:
+			diff.Unchanged++
 		}
 	}
 
-	// 4. Check for new files not in cache
-	for filename := range expectedFiles {
-		if !cachedFiles[filename] {
-			return false, fmt.Sprintf("new file added: %s", filename)
+	for filename := range cached {
+		if !seen[filename] {
+			diff.Removed = append(diff.Removed, filename)
 		}
 	}
+	sort.Strings(diff.Removed)
 
-	return true, ""
+	return diff, nil
 }
 
 // getMetadata retrieves a metadata value
@@ -402,6 +705,7 @@ func decodeVector(b []byte) []float32 {
 // CommandDoc represents a custom command documentation file
 // Duplicated here to avoid circular dependency
 type CommandDoc struct {
-	Filename  string
-	UpdatedAt time.Time
+	Filename    string
+	UpdatedAt   time.Time
+	ContentHash string // Hash of everything that goes into the doc's embeddings
 }
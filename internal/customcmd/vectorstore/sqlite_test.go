@@ -0,0 +1,115 @@
+package vectorstore
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func addDoc(t *testing.T, s *SQLiteStore, id, filename, hash string) {
+	t.Helper()
+	metadata := map[string]interface{}{
+		"command":      id,
+		"filename":     filename,
+		"content_hash": hash,
+	}
+	if err := s.Add(context.Background(), id, []float32{1, 0}, metadata); err != nil {
+		t.Fatalf("Add(%s) error = %v", id, err)
+	}
+}
+
+func filenames(docs []CommandDoc) []string {
+	var names []string
+	for _, doc := range docs {
+		names = append(names, doc.Filename)
+	}
+	return names
+}
+
+func TestSQLiteStoreDiff(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewSQLiteStore(filepath.Join(t.TempDir(), "embeddings.db"), "test", "model", 2)
+	if err != nil {
+		t.Fatalf("NewSQLiteStore() error = %v", err)
+	}
+	defer s.Close()
+
+	addDoc(t, s, "cmd_a", "a.md", "hash-a")
+	addDoc(t, s, "cmd_a#usage", "a.md", "hash-a")
+	addDoc(t, s, "cmd_b", "b.md", "hash-b")
+	addDoc(t, s, "cmd_c", "c.md", "hash-c")
+	addDoc(t, s, "cmd_d", "d.md", "hash-d")
+	addDoc(t, s, "cmd_d#usage", "d.md", "stale") // Interrupted update
+
+	diff, err := s.Diff([]CommandDoc{
+		{Filename: "a.md", ContentHash: "hash-a"},
+		{Filename: "b.md", ContentHash: "hash-b2"},
+		{Filename: "d.md", ContentHash: "hash-d"},
+		{Filename: "e.md", ContentHash: "hash-e"},
+	})
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+
+	if got := filenames(diff.Added); !reflect.DeepEqual(got, []string{"e.md"}) {
+		t.Errorf("Added = %v, want [e.md]", got)
+	}
+	if got := filenames(diff.Updated); !reflect.DeepEqual(got, []string{"b.md", "d.md"}) {
+		t.Errorf("Updated = %v, want [b.md d.md]", got)
+	}
+	if !reflect.DeepEqual(diff.Removed, []string{"c.md"}) {
+		t.Errorf("Removed = %v, want [c.md]", diff.Removed)
+	}
+	if want := "1 added, 2 updated, 1 removed, 1 unchanged"; diff.String() != want {
+		t.Errorf("String() = %q, want %q", diff.String(), want)
+	}
+
+	if err := s.DeleteFiles(ctx, []string{"a.md", "c.md"}); err != nil {
+		t.Fatalf("DeleteFiles() error = %v", err)
+	}
+	if got := s.Count(); got != 3 {
+		t.Errorf("Count() after DeleteFiles = %d, want 3", got)
+	}
+}
+
+func TestSQLiteStoreAddsContentHashColumn(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "embeddings.db")
+
+	// A cache from before content hashes were tracked
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`
+		CREATE TABLE metadata (key TEXT PRIMARY KEY, value TEXT NOT NULL);
+		CREATE TABLE embeddings (
+			id TEXT PRIMARY KEY,
+			command TEXT NOT NULL,
+			filename TEXT NOT NULL,
+			file_mtime INTEGER NOT NULL,
+			vector BLOB NOT NULL,
+			metadata_json TEXT
+		);
+		INSERT INTO embeddings VALUES ('cmd_a', 'a', 'a.md', 0, x'0000803f', '{}');
+	`)
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewSQLiteStore(path, "test", "model", 1)
+	if err != nil {
+		t.Fatalf("NewSQLiteStore() error = %v", err)
+	}
+	defer s.Close()
+
+	diff, err := s.Diff([]CommandDoc{{Filename: "a.md", ContentHash: "hash-a"}})
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+	if len(diff.Updated) != 1 {
+		t.Errorf("legacy rows should be re-embedded, got %s", diff)
+	}
+}
@@ -10,6 +10,10 @@ type Store interface {
 	// Search finds the top K most similar vectors
 	Search(ctx context.Context, query []float32, topK int) ([]SearchResult, error)
 
+	// SearchFiltered finds the top K most similar vectors whose metadata
+	// matches filter
+	SearchFiltered(ctx context.Context, query []float32, topK int, filter Filter) ([]SearchResult, error)
+
 	// Delete removes a vector by ID
 	Delete(ctx context.Context, id string) error
 
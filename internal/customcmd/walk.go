@@ -0,0 +1,232 @@
+package customcmd
+
+import (
+	"bufio"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreFileName lists files and directories under a commands dir that
+// shouldn't be loaded, one .gitignore-style pattern per line
+const IgnoreFileName = ".pleaseignore"
+
+// commandFile is a command doc found by walkCommandDir
+type commandFile struct {
+	Path      string
+	Namespace string // Slash-separated subdirectory, e.g. "k8s/helm"; "" at the top level
+}
+
+// walkCommandDir finds the command docs under dir, including those in
+// subdirectories. README.md, files and directories starting with _ or .,
+// and anything matched by dir/.pleaseignore are skipped. Symlinked
+// directories aren't followed. A missing dir has no docs.
+//
+// Every lookup of command files (loading, reindex checks, HasCommands) goes
+// through here so they agree on what counts as a doc.
+func walkCommandDir(dir string) ([]commandFile, error) {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	ignore, err := loadIgnoreRules(filepath.Join(dir, IgnoreFileName))
+	if err != nil {
+		return nil, err
+	}
+
+	var files []commandFile
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == dir {
+				return err
+			}
+			return nil // Skip unreadable subdirectories
+		}
+		if p == dir {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		name := d.Name()
+
+		if d.IsDir() {
+			if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || ignore.match(rel, true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !isCommandFile(name) || ignore.match(rel, false) {
+			return nil
+		}
+		namespace := path.Dir(rel)
+		if namespace == "." {
+			namespace = ""
+		}
+		files = append(files, commandFile{Path: p, Namespace: namespace})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// isCommandFile reports whether a file name is a command doc rather than a
+// README or a meta file (starting with _, by convention)
+func isCommandFile(name string) bool {
+	return strings.HasSuffix(strings.ToLower(name), ".md") &&
+		!strings.EqualFold(name, "README.md") &&
+		!strings.HasPrefix(name, "_") &&
+		!strings.HasPrefix(name, ".")
+}
+
+// namespaceCategories returns the categories a namespace implies, one per
+// path component: "k8s/helm" → k8s, helm
+func namespaceCategories(namespace string) []string {
+	if namespace == "" {
+		return nil
+	}
+	return strings.Split(namespace, "/")
+}
+
+// setNamespace records the namespace a doc was loaded from and adds its
+// components to the doc's categories
+func (d *CommandDoc) setNamespace(namespace string) {
+	d.Namespace = namespace
+	for _, category := range namespaceCategories(namespace) {
+		if !anyCategory(d.Categories, category) {
+			d.Categories = append(d.Categories, category)
+		}
+	}
+}
+
+// anyCategory reports whether categories includes category, ignoring case
+func anyCategory(categories []string, category string) bool {
+	for _, c := range categories {
+		if strings.EqualFold(c, category) {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoreRule is one pattern from a .pleaseignore file
+type ignoreRule struct {
+	segments []string // Pattern split on /
+	negate   bool     // !pattern re-includes what an earlier rule ignored
+	dirOnly  bool     // pattern/ only matches directories
+	anchored bool     // Contains a /, so it matches from the commands dir root
+}
+
+// ignoreRules are the rules of a .pleaseignore file, in file order
+type ignoreRules []ignoreRule
+
+// loadIgnoreRules reads a .pleaseignore file. It supports the common
+// .gitignore syntax: # comments, ! negation, a trailing / for directories,
+// patterns containing a / anchored at the root, and ** for any number of
+// directories. A missing file has no rules.
+func loadIgnoreRules(filename string) (ignoreRules, error) {
+	f, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var rules ignoreRules
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if rule, ok := parseIgnoreRule(scanner.Text()); ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, scanner.Err()
+}
+
+// parseIgnoreRule parses a .pleaseignore line, returning false for blank
+// lines and comments
+func parseIgnoreRule(line string) (ignoreRule, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	var rule ignoreRule
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if strings.Contains(line, "/") {
+		rule.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+
+	rule.segments = strings.Split(line, "/")
+	return rule, true
+}
+
+// match reports whether a path relative to the commands dir is ignored. As
+// in .gitignore, the last matching rule wins.
+func (rules ignoreRules) match(rel string, isDir bool) bool {
+	ignored := false
+	for _, rule := range rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.matches(rel) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// matches reports whether the rule's pattern matches a relative path
+func (r ignoreRule) matches(rel string) bool {
+	parts := strings.Split(rel, "/")
+	if !r.anchored {
+		// A bare name matches at any depth
+		ok, _ := path.Match(r.segments[0], parts[len(parts)-1])
+		return ok
+	}
+	return matchSegments(r.segments, parts)
+}
+
+// matchSegments matches path components against pattern components, where
+// a ** component matches any number of path components. A trailing ** needs
+// at least one, so dir/** matches what's inside dir but not dir itself.
+func matchSegments(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+	if pattern[0] == "**" {
+		if len(pattern) == 1 {
+			return len(parts) > 0
+		}
+		return matchSegments(pattern[1:], parts) ||
+			(len(parts) > 0 && matchSegments(pattern, parts[1:]))
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], parts[1:])
+}
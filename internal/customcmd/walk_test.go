@@ -0,0 +1,149 @@
+package customcmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestIgnoreRules(t *testing.T) {
+	var rules ignoreRules
+	for _, line := range strings.Split(`
+# Drafts anywhere
+*.draft.md
+archive/
+/legacy/**
+!/legacy/keep.md
+docs/**/old.md
+notes
+`, "\n") {
+		if rule, ok := parseIgnoreRule(line); ok {
+			rules = append(rules, rule)
+		}
+	}
+
+	tests := []struct {
+		rel   string
+		isDir bool
+		want  bool
+	}{
+		{"git.draft.md", false, true},
+		{"aws/s3.draft.md", false, true},
+		{"aws/s3.md", false, false},
+		{"archive", true, true},
+		{"aws/archive", true, true},
+		{"archive", false, false}, // archive/ only matches directories
+		{"legacy", true, false},   // legacy/** matches inside legacy only
+		{"legacy/old.md", false, true},
+		{"legacy/keep.md", false, false},
+		{"aws/legacy/old.md", false, false}, // /legacy is anchored
+		{"docs/old.md", false, true},
+		{"docs/a/b/old.md", false, true},
+		{"notes", true, true},
+		{"k8s/notes", false, true},
+	}
+	for _, tt := range tests {
+		if got := rules.match(tt.rel, tt.isDir); got != tt.want {
+			t.Errorf("match(%q, dir=%v) = %v, want %v", tt.rel, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestWalkCommandDir(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"git.md", "README.md", "_template.md", "notes.txt",
+		"aws/s3.md", "aws/deploy.md", "aws/README.md",
+		"k8s/helm/install.md", "k8s/deploy.md",
+		"_drafts/wip.md", ".git/HEAD.md",
+		"archive/old.md", "aws/s3.draft.md",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ignore := "archive/\n*.draft.md\n"
+	if err := os.WriteFile(filepath.Join(dir, IgnoreFileName), []byte(ignore), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := walkCommandDir(dir)
+	if err != nil {
+		t.Fatalf("walkCommandDir() error = %v", err)
+	}
+	var got []string
+	for _, file := range files {
+		rel, _ := filepath.Rel(dir, file.Path)
+		got = append(got, filepath.ToSlash(rel)+"@"+file.Namespace)
+	}
+	sort.Strings(got)
+	want := []string{"aws/deploy.md@aws", "aws/s3.md@aws", "git.md@", "k8s/deploy.md@k8s", "k8s/helm/install.md@k8s/helm"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("walkCommandDir() = %v, want %v", got, want)
+	}
+
+	if files, err := walkCommandDir(filepath.Join(dir, "missing")); err != nil || len(files) != 0 {
+		t.Errorf("walkCommandDir(missing) = %v, %v; want no files", files, err)
+	}
+}
+
+func TestManagerLoadsNamespaces(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	oldSystem := systemCommandsDir
+	systemCommandsDir = filepath.Join(t.TempDir(), "none")
+	t.Cleanup(func() { systemCommandsDir = oldSystem })
+	t.Chdir(t.TempDir())
+
+	commandsDir := filepath.Join(home, ".please", "commands")
+	writeDoc(t, filepath.Join(commandsDir, "aws"), "deploy", "Deploy to ECS with the aws cli")
+	writeDoc(t, filepath.Join(commandsDir, "k8s", "helm"), "deploy", "Deploy a helm chart")
+	writeDoc(t, commandsDir, "git", "Commit and push")
+
+	m, err := NewManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	got := make(map[string]CommandDoc)
+	for _, doc := range m.GetDocs() {
+		got[doc.Name()] = doc
+	}
+	want := map[string][]string{
+		"aws/deploy":      {"aws"},
+		"k8s/helm/deploy": {"k8s", "helm"},
+		"git":             nil,
+	}
+	if len(got) != len(want) {
+		t.Errorf("loaded %d docs, want %d", len(got), len(want))
+	}
+	for name, categories := range want {
+		doc, ok := got[name]
+		if !ok {
+			t.Errorf("%s wasn't loaded", name)
+			continue
+		}
+		if fmt.Sprint(doc.Categories) != fmt.Sprint(categories) {
+			t.Errorf("%s categories = %v, want %v", name, doc.Categories, categories)
+		}
+	}
+
+	docs := m.GetRelevantDocs("deploy helm chart", 1)
+	if len(docs) == 0 || docs[0].Name() != "k8s/helm/deploy" {
+		t.Errorf("GetRelevantDocs() = %v, want k8s/helm/deploy", commands(docs))
+	}
+
+	if ok, err := HasCommands(); err != nil || !ok {
+		t.Errorf("HasCommands() = %v, %v", ok, err)
+	}
+}
@@ -1,12 +1,46 @@
 package executor
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
-	"runtime"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/iishyfishyy/please/internal/shell"
+	"golang.org/x/term"
 )
 
+// killGracePeriod is how long a timed-out command gets to exit after SIGTERM
+// before the whole process group is killed
+var killGracePeriod = 3 * time.Second
+
+// ErrTimeout is returned when a command is killed because it exceeded its timeout
+var ErrTimeout = errors.New("killed by timeout")
+
+// Options controls how a command is executed
+type Options struct {
+	Timeout    time.Duration // Kill the command after this long (0 = no limit)
+	CPUSeconds int           // CPU time limit in seconds (0 = no limit)
+	MemoryMB   int           // Memory limit in megabytes (0 = no limit)
+	Shell      *shell.Shell  // Shell to run the command in (nil = detect)
+	Stdin      io.Reader     // Command input (nil = os.Stdin)
+	Stdout     io.Writer     // Command output (nil = os.Stdout)
+	Stderr     io.Writer     // Command errors (nil = os.Stderr)
+	Debug      bool
+}
+
+// Result describes how an executed command finished
+type Result struct {
+	ExitCode int
+	TimedOut bool
+	Signal   string // Last signal forwarded to the command, if any
+	Duration time.Duration
+}
+
 // Execute runs a shell command and returns the output
 func Execute(command string) error {
 	return ExecuteWithDebug(command, false)
@@ -14,55 +48,140 @@ func Execute(command string) error {
 
 // ExecuteWithDebug runs a shell command with optional debug logging
 func ExecuteWithDebug(command string, debug bool) error {
-	var cmd *exec.Cmd
-	var shell string
-	var shellArgs []string
-
-	// Determine shell based on OS
-	if runtime.GOOS == "windows" {
-		shell = "cmd"
-		shellArgs = []string{"/C", command}
-		if debug {
-			fmt.Fprintf(os.Stderr, "[DEBUG] Executor: using Windows cmd.exe\n")
-		}
-	} else {
-		shell = os.Getenv("SHELL")
-		if shell == "" {
-			shell = "/bin/sh"
-		}
-		shellArgs = []string{"-c", command}
-		if debug {
-			fmt.Fprintf(os.Stderr, "[DEBUG] Executor: using shell %s\n", shell)
-		}
-	}
+	_, err := Run(command, Options{Debug: debug})
+	return err
+}
+
+// Run executes a shell command in its own process group, forwarding
+// SIGINT/SIGTERM to it and enforcing the timeout and resource limits in opts
+func Run(command string, opts Options) (*Result, error) {
+	debug := opts.Debug
 
+	sh := opts.Shell
+	if sh == nil {
+		sh = shell.Detect()
+	}
 	if debug {
+		fmt.Fprintf(os.Stderr, "[DEBUG] Executor: using shell %s\n", sh)
 		fmt.Fprintf(os.Stderr, "[DEBUG] Executor: executing command: %q\n", command)
 	}
 
-	cmd = exec.Command(shell, shellArgs...)
+	program, args := sh.Invocation(command)
+	program, args = applyLimits(sh, program, args, opts)
 
-	// Set up command to use current stdin/stdout/stderr
+	cmd := exec.Command(program, args...)
+
+	// Set up command to use current stdin/stdout/stderr unless redirected
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
+	if opts.Stdin != nil {
+		cmd.Stdin = opts.Stdin
+	}
+	if opts.Stdout != nil {
+		cmd.Stdout = opts.Stdout
+	}
+	if opts.Stderr != nil {
+		cmd.Stderr = opts.Stderr
+	}
+
+	// Run the command in its own process group so signals and timeouts reach
+	// everything it spawns. With a terminal attached, the group is also made
+	// the foreground group so interactive programs keep working.
+	interactive := opts.Stdin == nil && term.IsTerminal(int(os.Stdin.Fd()))
+	setProcessGroup(cmd, interactive)
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
 
-	// Run the command
-	if err := cmd.Run(); err != nil {
+	result := &Result{ExitCode: -1}
+	start := time.Now()
+
+	if err := cmd.Start(); err != nil {
 		if debug {
-			// Check if it's an exit error with a code
-			if exitError, ok := err.(*exec.ExitError); ok {
-				fmt.Fprintf(os.Stderr, "[DEBUG] Executor: command failed with exit code %d\n", exitError.ExitCode())
-			} else {
-				fmt.Fprintf(os.Stderr, "[DEBUG] Executor: command failed: %v\n", err)
+			fmt.Fprintf(os.Stderr, "[DEBUG] Executor: failed to start command: %v\n", err)
+		}
+		return result, fmt.Errorf("command failed: %w", err)
+	}
+	if interactive {
+		defer restoreForeground()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	var timeoutC, killC <-chan time.Time
+	if opts.Timeout > 0 {
+		timer := time.NewTimer(opts.Timeout)
+		defer timer.Stop()
+		timeoutC = timer.C
+	}
+
+	for {
+		select {
+		case err := <-done:
+			result.Duration = time.Since(start)
+			return finish(result, err, debug)
+
+		case sig := <-sigCh:
+			result.Signal = sig.String()
+			if debug {
+				fmt.Fprintf(os.Stderr, "[DEBUG] Executor: forwarding %s to process group %d\n", sig, cmd.Process.Pid)
 			}
+			signalGroup(cmd, sig)
+
+		case <-timeoutC:
+			result.TimedOut = true
+			if debug {
+				fmt.Fprintf(os.Stderr, "[DEBUG] Executor: timeout of %s reached, terminating\n", opts.Timeout)
+			}
+			signalGroup(cmd, syscall.SIGTERM)
+			killC = time.After(killGracePeriod)
+
+		case <-killC:
+			if debug {
+				fmt.Fprintf(os.Stderr, "[DEBUG] Executor: command ignored SIGTERM, killing process group\n")
+			}
+			killGroup(cmd)
 		}
-		return fmt.Errorf("command failed: %w", err)
 	}
+}
 
-	if debug {
-		fmt.Fprintf(os.Stderr, "[DEBUG] Executor: command completed successfully\n")
+// finish fills in the result from the command's wait error
+func finish(result *Result, err error, debug bool) (*Result, error) {
+	if err == nil {
+		result.ExitCode = 0
+		if result.TimedOut {
+			// Exited cleanly on SIGTERM, but only because we asked it to
+			return result, ErrTimeout
+		}
+		if debug {
+			fmt.Fprintf(os.Stderr, "[DEBUG] Executor: command completed successfully\n")
+		}
+		return result, nil
+	}
+
+	if exitError, ok := err.(*exec.ExitError); ok {
+		result.ExitCode = exitError.ExitCode()
+	}
+
+	if result.TimedOut {
+		if debug {
+			fmt.Fprintf(os.Stderr, "[DEBUG] Executor: command killed by timeout after %s\n", result.Duration.Round(time.Millisecond))
+		}
+		return result, ErrTimeout
 	}
 
-	return nil
+	if debug {
+		// Check if it's an exit error with a code
+		if _, ok := err.(*exec.ExitError); ok {
+			fmt.Fprintf(os.Stderr, "[DEBUG] Executor: command failed with exit code %d\n", result.ExitCode)
+		} else {
+			fmt.Fprintf(os.Stderr, "[DEBUG] Executor: command failed: %v\n", err)
+		}
+	}
+	return result, fmt.Errorf("command failed: %w", err)
 }
@@ -0,0 +1,225 @@
+//go:build !windows
+
+package executor
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/iishyfishyy/please/internal/shell"
+)
+
+var testShell = &shell.Shell{Kind: shell.KindSh, Path: "/bin/sh"}
+
+func TestRunSuccess(t *testing.T) {
+	var out bytes.Buffer
+	result, err := Run("echo hello", Options{Shell: testShell, Stdin: strings.NewReader(""), Stdout: &out})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.ExitCode != 0 || result.TimedOut {
+		t.Errorf("Run() result = %+v, want exit 0 without timeout", result)
+	}
+	if got := strings.TrimSpace(out.String()); got != "hello" {
+		t.Errorf("Run() output = %q, want %q", got, "hello")
+	}
+}
+
+func TestRunExitCode(t *testing.T) {
+	result, err := Run("exit 7", Options{Shell: testShell, Stdin: strings.NewReader("")})
+	if err == nil {
+		t.Fatal("Run() error = nil, want exit error")
+	}
+	if errors.Is(err, ErrTimeout) {
+		t.Errorf("Run() error = %v, want a plain exit error", err)
+	}
+	if result.ExitCode != 7 {
+		t.Errorf("Run() exit code = %d, want 7", result.ExitCode)
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	start := time.Now()
+	result, err := Run("sleep 10", Options{
+		Shell:   testShell,
+		Stdin:   strings.NewReader(""),
+		Timeout: 100 * time.Millisecond,
+	})
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("Run() error = %v, want ErrTimeout", err)
+	}
+	if !result.TimedOut {
+		t.Error("Run() result.TimedOut = false, want true")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Run() took %s, want the command terminated at the timeout", elapsed)
+	}
+}
+
+func TestRunKillsAfterGracePeriod(t *testing.T) {
+	saved := killGracePeriod
+	killGracePeriod = 200 * time.Millisecond
+	defer func() { killGracePeriod = saved }()
+
+	// Ignored signals are inherited across exec, so sleep ignores SIGTERM too
+	// and only SIGKILL can stop the group
+	start := time.Now()
+	result, err := Run("trap '' TERM; sleep 10", Options{
+		Shell:   testShell,
+		Stdin:   strings.NewReader(""),
+		Timeout: 100 * time.Millisecond,
+	})
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("Run() error = %v, want ErrTimeout", err)
+	}
+	if !result.TimedOut {
+		t.Error("Run() result.TimedOut = false, want true")
+	}
+	if elapsed < 300*time.Millisecond {
+		t.Errorf("Run() took %s, want the grace period to pass before the kill", elapsed)
+	}
+	if elapsed > 5*time.Second {
+		t.Errorf("Run() took %s, want the command killed once the grace period ended", elapsed)
+	}
+}
+
+func TestRunForwardsSignalToGroup(t *testing.T) {
+	// The command writes a marker once its trap is installed, so the signal
+	// is only sent when it can be observed
+	dir := t.TempDir()
+	ready := dir + "/ready"
+
+	go func() {
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			if _, err := os.Stat(ready); err == nil {
+				syscall.Kill(os.Getpid(), syscall.SIGTERM)
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	result, err := Run("trap 'exit 3' TERM; touch "+ready+"; sleep 10 & wait", Options{
+		Shell: testShell,
+		Stdin: strings.NewReader(""),
+	})
+	if err == nil {
+		t.Fatal("Run() error = nil, want exit error")
+	}
+	if result.Signal != syscall.SIGTERM.String() {
+		t.Errorf("Run() result.Signal = %q, want %q", result.Signal, syscall.SIGTERM.String())
+	}
+	if result.ExitCode != 3 {
+		t.Errorf("Run() exit code = %d, want 3 from the TERM trap", result.ExitCode)
+	}
+	if result.Duration > 5*time.Second {
+		t.Errorf("Run() took %s, want the forwarded signal to stop the command", result.Duration)
+	}
+}
+
+func TestApplyLimits(t *testing.T) {
+	tests := []struct {
+		name        string
+		shell       *shell.Shell
+		opts        Options
+		cgroups     bool
+		wantProgram string
+		wantArgs    []string
+	}{
+		{
+			name:        "no limits",
+			shell:       testShell,
+			wantProgram: "/bin/sh",
+			wantArgs:    []string{"-c", "ls"},
+		},
+		{
+			name:        "cpu",
+			shell:       testShell,
+			opts:        Options{CPUSeconds: 10},
+			wantProgram: "/bin/sh",
+			wantArgs:    []string{"-c", "ulimit -t 10; ls"},
+		},
+		{
+			name:        "memory without cgroups",
+			shell:       testShell,
+			opts:        Options{MemoryMB: 512},
+			wantProgram: "/bin/sh",
+			wantArgs:    []string{"-c", "ulimit -d 524288; ls"},
+		},
+		{
+			name:        "cpu and memory without cgroups",
+			shell:       testShell,
+			opts:        Options{CPUSeconds: 5, MemoryMB: 64},
+			wantProgram: "/bin/sh",
+			wantArgs:    []string{"-c", "ulimit -t 5; ulimit -d 65536; ls"},
+		},
+		{
+			name:        "memory with systemd",
+			shell:       testShell,
+			opts:        Options{MemoryMB: 512},
+			cgroups:     true,
+			wantProgram: "systemd-run",
+			wantArgs: []string{
+				"--user", "--scope", "--quiet", "--collect",
+				"-p", "MemoryMax=512M", "-p", "MemorySwapMax=0",
+				"/bin/sh", "-c", "ls",
+			},
+		},
+		{
+			name:        "cpu and memory with systemd",
+			shell:       testShell,
+			opts:        Options{CPUSeconds: 5, MemoryMB: 512},
+			cgroups:     true,
+			wantProgram: "systemd-run",
+			wantArgs: []string{
+				"--user", "--scope", "--quiet", "--collect",
+				"-p", "MemoryMax=512M", "-p", "MemorySwapMax=0",
+				"/bin/sh", "-c", "ulimit -t 5; ls",
+			},
+		},
+		{
+			name:        "fish",
+			shell:       &shell.Shell{Kind: shell.KindFish, Path: "/usr/bin/fish"},
+			opts:        Options{CPUSeconds: 10},
+			wantProgram: "/usr/bin/fish",
+			wantArgs:    []string{"-c", "ulimit -t 10; ls"},
+		},
+		{
+			name:        "unsupported shell",
+			shell:       &shell.Shell{Kind: shell.KindNu, Path: "/usr/bin/nu"},
+			opts:        Options{CPUSeconds: 10, MemoryMB: 512},
+			cgroups:     true,
+			wantProgram: "/usr/bin/nu",
+			wantArgs:    []string{"-c", "ls"},
+		},
+	}
+
+	saved := cgroupsAvailable
+	defer func() { cgroupsAvailable = saved }()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cgroups := tt.cgroups
+			cgroupsAvailable = func() bool { return cgroups }
+
+			program, args := tt.shell.Invocation("ls")
+			gotProgram, gotArgs := applyLimits(tt.shell, program, args, tt.opts)
+
+			if gotProgram != tt.wantProgram {
+				t.Errorf("applyLimits() program = %q, want %q", gotProgram, tt.wantProgram)
+			}
+			if !reflect.DeepEqual(gotArgs, tt.wantArgs) {
+				t.Errorf("applyLimits() args = %q, want %q", gotArgs, tt.wantArgs)
+			}
+		})
+	}
+}
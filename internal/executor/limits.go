@@ -0,0 +1,88 @@
+package executor
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/iishyfishyy/please/internal/shell"
+)
+
+// applyLimits wraps the shell invocation so that the configured CPU and
+// memory limits apply to the command and everything it spawns.
+//
+// Memory is capped with a transient cgroup (systemd-run --scope) when a
+// cgroup v2 user session is available, and with a data-segment rlimit
+// otherwise. The data rlimit rather than the address-space one is used so
+// that runtimes which reserve large mappings up front (JVMs, Go binaries)
+// still start; memory mapped by the command itself is not counted. CPU time
+// is always capped with an rlimit.
+func applyLimits(sh *shell.Shell, program string, args []string, opts Options) (string, []string) {
+	if opts.CPUSeconds <= 0 && opts.MemoryMB <= 0 {
+		return program, args
+	}
+
+	if runtime.GOOS == "windows" {
+		if opts.Debug {
+			fmt.Fprintf(os.Stderr, "[DEBUG] Executor: resource limits are not supported on Windows, ignoring\n")
+		}
+		return program, args
+	}
+
+	// ulimit is a builtin in sh, bash, zsh and fish, but not nushell or pwsh
+	if !sh.IsPOSIX() && sh.Kind != shell.KindFish {
+		if opts.Debug {
+			fmt.Fprintf(os.Stderr, "[DEBUG] Executor: resource limits are not supported for %s, ignoring\n", sh.Kind)
+		}
+		return program, args
+	}
+
+	if len(args) != 2 || args[0] != "-c" {
+		return program, args
+	}
+
+	useCgroup := opts.MemoryMB > 0 && cgroupsAvailable()
+
+	prefix := ""
+	if opts.CPUSeconds > 0 {
+		prefix += fmt.Sprintf("ulimit -t %d; ", opts.CPUSeconds)
+	}
+	if opts.MemoryMB > 0 && !useCgroup {
+		prefix += fmt.Sprintf("ulimit -d %d; ", opts.MemoryMB*1024)
+	}
+	args = []string{"-c", prefix + args[1]}
+
+	if opts.Debug {
+		fmt.Fprintf(os.Stderr, "[DEBUG] Executor: applying limits (cpu=%ds, memory=%dMB, cgroup=%v)\n",
+			opts.CPUSeconds, opts.MemoryMB, useCgroup)
+	}
+
+	if useCgroup {
+		wrapped := []string{
+			"--user", "--scope", "--quiet", "--collect",
+			"-p", fmt.Sprintf("MemoryMax=%dM", opts.MemoryMB),
+			"-p", "MemorySwapMax=0",
+			program,
+		}
+		return "systemd-run", append(wrapped, args...)
+	}
+
+	return program, args
+}
+
+// cgroupsAvailable reports whether memory limits can be enforced with a
+// transient systemd scope in the user's cgroup v2 hierarchy
+var cgroupsAvailable = func() bool {
+	if runtime.GOOS != "linux" {
+		return false
+	}
+	if _, err := os.Stat("/sys/fs/cgroup/cgroup.controllers"); err != nil {
+		return false
+	}
+	if os.Getenv("XDG_RUNTIME_DIR") == "" {
+		return false
+	}
+	_, err := exec.LookPath("systemd-run")
+	return err == nil
+}
@@ -0,0 +1,50 @@
+//go:build !windows
+
+package executor
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// setProcessGroup starts the command in a new process group, optionally
+// handing it the terminal's foreground
+func setProcessGroup(cmd *exec.Cmd, foreground bool) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if foreground {
+		cmd.SysProcAttr.Foreground = true
+		cmd.SysProcAttr.Ctty = int(os.Stdin.Fd())
+	}
+}
+
+// restoreForeground moves the terminal's foreground back to our process group
+func restoreForeground() {
+	// tcsetpgrp from a background group raises SIGTTOU, which would stop us
+	signal.Ignore(syscall.SIGTTOU)
+	defer signal.Reset(syscall.SIGTTOU)
+
+	unix.IoctlSetPointerInt(int(os.Stdin.Fd()), unix.TIOCSPGRP, syscall.Getpgrp())
+}
+
+// signalGroup sends sig to every process in the command's process group
+func signalGroup(cmd *exec.Cmd, sig os.Signal) {
+	if cmd.Process == nil {
+		return
+	}
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		s = syscall.SIGTERM
+	}
+	if err := syscall.Kill(-cmd.Process.Pid, s); err != nil {
+		cmd.Process.Signal(s)
+	}
+}
+
+// killGroup forcibly kills every process in the command's process group
+func killGroup(cmd *exec.Cmd) {
+	signalGroup(cmd, syscall.SIGKILL)
+}
@@ -0,0 +1,26 @@
+//go:build windows
+
+package executor
+
+import (
+	"os"
+	"os/exec"
+)
+
+// setProcessGroup is a no-op on Windows; console signals already reach the child
+func setProcessGroup(cmd *exec.Cmd, foreground bool) {}
+
+// restoreForeground is a no-op on Windows
+func restoreForeground() {}
+
+// signalGroup terminates the command; Windows can't deliver other signals
+func signalGroup(cmd *exec.Cmd, sig os.Signal) {
+	killGroup(cmd)
+}
+
+// killGroup forcibly kills the command
+func killGroup(cmd *exec.Cmd) {
+	if cmd.Process != nil {
+		cmd.Process.Kill()
+	}
+}
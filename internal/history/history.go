@@ -12,6 +12,15 @@ const (
 	HistoryFileName = "history.json"
 )
 
+// Execution outcomes recorded in Entry.Status
+const (
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusTimedOut  = "killed by timeout"
+	StatusCancelled = "cancelled"
+	StatusStopped   = "stopped early"
+)
+
 // Entry represents a single command history entry
 type Entry struct {
 	Timestamp       time.Time `json:"timestamp"`
@@ -19,6 +28,9 @@ type Entry struct {
 	FinalCommand    string    `json:"final_command"`
 	Executed        bool      `json:"executed"`
 	Modifications   []string  `json:"modifications,omitempty"`
+	Status          string    `json:"status,omitempty"`
+	ExitCode        int       `json:"exit_code,omitempty"`
+	DurationMs      int64     `json:"duration_ms,omitempty"`
 }
 
 // History manages command history
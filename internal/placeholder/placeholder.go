@@ -0,0 +1,252 @@
+package placeholder
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/iishyfishyy/please/internal/shell"
+)
+
+// sourceTimeout bounds how long a completion source command may run
+const sourceTimeout = 5 * time.Second
+
+// maxOptions caps how many completion values are offered
+const maxOptions = 200
+
+var (
+	angleRe    = regexp.MustCompile(`<([A-Za-z][A-Za-z0-9_.-]*)>`)
+	mustacheRe = regexp.MustCompile(`\{\{\s*([A-Za-z][A-Za-z0-9_.-]*)\s*\}\}`)
+	envRe      = regexp.MustCompile(`\$\{?([A-Z][A-Z0-9_]*[A-Z0-9])\}?`)
+	assignRe   = regexp.MustCompile(`(?:^|[\s;&|(])(?:export\s+|local\s+|read\s+(?:-\w+\s+)*)?([A-Z][A-Z0-9_]*)=|\bfor\s+([A-Z][A-Z0-9_]*)\s+in\b|\bread\s+(?:-\w+\s+)*([A-Z][A-Z0-9_]*)`)
+	safeRe     = regexp.MustCompile(`^[A-Za-z0-9_./:@%+=,-]+$`)
+)
+
+// shellVars are variables the shell sets itself without exporting them, so
+// they're never in the environment but aren't placeholders either
+var shellVars = map[string]bool{
+	"BASHPID": true, "BASH_ARGV": true, "BASH_COMMAND": true, "BASH_LINENO": true,
+	"BASH_REMATCH": true, "BASH_SOURCE": true, "BASH_SUBSHELL": true, "BASH_VERSINFO": true,
+	"BASH_VERSION": true, "COLUMNS": true, "DIRSTACK": true, "EPOCHREALTIME": true,
+	"EPOCHSECONDS": true, "EUID": true, "FUNCNAME": true, "GROUPS": true,
+	"HISTCMD": true, "HOSTNAME": true, "HOSTTYPE": true, "IFS": true,
+	"LINENO": true, "LINES": true, "MACHTYPE": true, "OPTARG": true,
+	"OPTIND": true, "OSTYPE": true, "PIPESTATUS": true, "PPID": true,
+	"PS1": true, "PS2": true, "PS4": true, "RANDOM": true,
+	"REPLY": true, "SECONDS": true, "SHELLOPTS": true, "SRANDOM": true,
+	"UID": true, "ZSH_VERSION": true,
+}
+
+// Spec describes a placeholder in a command doc's frontmatter:
+//
+//	placeholders:
+//	  namespace:
+//	    description: Kubernetes namespace
+//	    source: kubectl get namespaces -o name | cut -d/ -f2
+type Spec struct {
+	Description string `yaml:"description,omitempty"`
+	Source      string `yaml:"source,omitempty"`  // Shell command that lists possible values, one per line
+	Default     string `yaml:"default,omitempty"` // Value suggested when nothing else is known
+}
+
+// Placeholder is an unresolved parameter found in a command
+type Placeholder struct {
+	Name   string   // Normalised name, e.g. "pod-name"
+	Tokens []string // Every spelling found in the command, e.g. "<pod-name>", "$POD_NAME"
+}
+
+// Normalize maps the different spellings of a name to one key:
+// "Pod_Name", "pod-name" and "POD_NAME" all become "pod-name"
+func Normalize(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = strings.NewReplacer("_", "-", " ", "-", ".", "-").Replace(name)
+	return strings.Trim(name, "-")
+}
+
+// Find returns the unresolved placeholders in a command, in order of first
+// appearance. It recognises <name>, {{name}} and $UPPER_NAME outside single
+// quotes, so literals like sed 's/<br>//' and go templates are left alone;
+// $UPPER_NAME only when the variable is neither set in the environment,
+// assigned in the command itself, nor one the shell sets.
+func Find(command string) []Placeholder {
+	type found struct {
+		pos   int
+		token string
+		name  string
+	}
+	var all []found
+
+	for _, re := range []*regexp.Regexp{angleRe, mustacheRe} {
+		for _, m := range re.FindAllStringSubmatchIndex(command, -1) {
+			if quoteAt(command, m[0]) == '\'' {
+				continue
+			}
+			all = append(all, found{m[0], command[m[0]:m[1]], command[m[2]:m[3]]})
+		}
+	}
+
+	assigned := assignedVars(command)
+	for _, m := range envRe.FindAllStringSubmatchIndex(command, -1) {
+		name := command[m[2]:m[3]]
+		if quoteAt(command, m[0]) == '\'' || assigned[name] || shellVars[name] {
+			continue
+		}
+		if _, ok := os.LookupEnv(name); ok {
+			continue
+		}
+		all = append(all, found{m[0], command[m[0]:m[1]], name})
+	}
+
+	sort.SliceStable(all, func(i, j int) bool { return all[i].pos < all[j].pos })
+
+	var result []Placeholder
+	index := make(map[string]int)
+	for _, f := range all {
+		key := Normalize(f.name)
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(result)
+			result = append(result, Placeholder{Name: key})
+			i = len(result) - 1
+		}
+		if !contains(result[i].Tokens, f.token) {
+			result[i].Tokens = append(result[i].Tokens, f.token)
+		}
+	}
+
+	return result
+}
+
+// Fill substitutes values (keyed by normalised name) into the command.
+// Values are quoted to suit where the placeholder appears: single-quoted
+// when bare and escaped when inside double quotes. Tokens inside single
+// quotes are literals, as in Find, and are left alone.
+func Fill(command string, placeholders []Placeholder, values map[string]string) string {
+	for _, p := range placeholders {
+		value, ok := values[p.Name]
+		if !ok {
+			continue
+		}
+		for _, token := range p.Tokens {
+			var out strings.Builder
+			rest := command
+			offset := 0
+			for {
+				i := strings.Index(rest, token)
+				if i == -1 {
+					out.WriteString(rest)
+					break
+				}
+				out.WriteString(rest[:i])
+				if enclosing := quoteAt(command, offset+i); enclosing == '\'' {
+					out.WriteString(token)
+				} else {
+					out.WriteString(quote(value, enclosing))
+				}
+				rest = rest[i+len(token):]
+				offset += i + len(token)
+			}
+			command = out.String()
+		}
+	}
+	return command
+}
+
+// Options runs the spec's source command and returns its output lines as
+// completion candidates. Placeholders already filled in values may be
+// referenced in the source, e.g. "kubectl get pods -n {{namespace}}".
+// The source runs as is, so callers should confirm sources from docs they
+// don't trust before calling this.
+func Options(ctx context.Context, spec Spec, values map[string]string, sh *shell.Shell) ([]string, error) {
+	if spec.Source == "" {
+		return nil, nil
+	}
+
+	source := Fill(spec.Source, Find(spec.Source), values)
+
+	ctx, cancel := context.WithTimeout(ctx, sourceTimeout)
+	defer cancel()
+
+	program, args := sh.Invocation(source)
+	cmd := exec.CommandContext(ctx, program, args...)
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+
+	var options []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || seen[line] {
+			continue
+		}
+		seen[line] = true
+		options = append(options, line)
+		if len(options) >= maxOptions {
+			break
+		}
+	}
+
+	return options, nil
+}
+
+// assignedVars returns variables the command assigns itself (FOO=..., for FOO in, read FOO)
+func assignedVars(command string) map[string]bool {
+	assigned := make(map[string]bool)
+	for _, m := range assignRe.FindAllStringSubmatch(command, -1) {
+		for _, name := range m[1:] {
+			if name != "" {
+				assigned[name] = true
+			}
+		}
+	}
+	return assigned
+}
+
+// quoteAt returns the quote character enclosing the byte offset, or 0
+func quoteAt(command string, pos int) byte {
+	var quote byte
+	for i := 0; i < len(command) && i < pos; i++ {
+		c := command[i]
+		switch {
+		case quote == 0 && c == '\\':
+			i++
+		case quote == '"' && c == '\\':
+			i++
+		case quote == 0 && (c == '\'' || c == '"'):
+			quote = c
+		case c == quote:
+			quote = 0
+		}
+	}
+	return quote
+}
+
+// quote makes a value safe to substitute into a shell command at a
+// position that is bare (0) or inside double quotes
+func quote(value string, enclosing byte) string {
+	if enclosing == '"' {
+		return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`").Replace(value)
+	}
+	if safeRe.MatchString(value) {
+		return value
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// contains checks if a string is in the list
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
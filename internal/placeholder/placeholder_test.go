@@ -0,0 +1,78 @@
+package placeholder
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	t.Setenv("PLEASE_TEST_SET", "1")
+
+	tests := []struct {
+		command string
+		want    []Placeholder
+	}{
+		{
+			command: "kubectl logs <pod-name> -n <namespace>",
+			want: []Placeholder{
+				{Name: "pod-name", Tokens: []string{"<pod-name>"}},
+				{Name: "namespace", Tokens: []string{"<namespace>"}},
+			},
+		},
+		{
+			command: "kubectl logs {{ pod_name }} -n $NAMESPACE && echo $POD_NAME",
+			want: []Placeholder{
+				{Name: "pod-name", Tokens: []string{"{{ pod_name }}", "$POD_NAME"}},
+				{Name: "namespace", Tokens: []string{"$NAMESPACE"}},
+			},
+		},
+		{
+			// Set, assigned, single-quoted and non-placeholder syntax are all ignored
+			command: `echo $PLEASE_TEST_SET; DIR=/tmp; ls $DIR; awk '{print $TOKEN}' f 2>&1 <<EOF`,
+			want:    nil,
+		},
+		{
+			// Single-quoted literals and variables the shell sets itself
+			command: `sed 's/<br>/\n/g' f | grep '<div>'; kubectl get pods -o go-template='{{range .items}}{{.name}}{{end}}'; echo $RANDOM ${PIPESTATUS[0]}`,
+			want:    nil,
+		},
+		{
+			command: `grep "<pattern>" '<literal>' <file>`,
+			want: []Placeholder{
+				{Name: "pattern", Tokens: []string{"<pattern>"}},
+				{Name: "file", Tokens: []string{"<file>"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		got := Find(tt.command)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Find(%q) = %#v, want %#v", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestFill(t *testing.T) {
+	command := `kubectl logs <pod> -n $NS | grep "<pattern>"`
+	got := Fill(command, Find(command), map[string]string{
+		"pod":     "api-7d9f",
+		"ns":      "my team",
+		"pattern": `say "hi"`,
+	})
+
+	want := `kubectl logs api-7d9f -n 'my team' | grep "say \"hi\""`
+	if got != want {
+		t.Errorf("Fill() = %q, want %q", got, want)
+	}
+}
+
+func TestFillLeavesSingleQuotedTokens(t *testing.T) {
+	command := `awk '{print "<name>"}' <name>`
+	got := Fill(command, Find(command), map[string]string{"name": "access.log"})
+
+	want := `awk '{print "<name>"}' access.log`
+	if got != want {
+		t.Errorf("Fill() = %q, want %q", got, want)
+	}
+}
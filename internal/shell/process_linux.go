@@ -0,0 +1,52 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// procDir is where process information is read from; tests point it at a
+// fake tree
+var procDir = "/proc"
+
+// readProcessInfo returns the executable path and parent PID of a process
+func readProcessInfo(pid int) (string, int, error) {
+	dir := filepath.Join(procDir, strconv.Itoa(pid))
+	exe, err := os.Readlink(filepath.Join(dir, "exe"))
+	if err != nil {
+		// Fall back to the command name if the exe link isn't readable
+		comm, cerr := os.ReadFile(filepath.Join(dir, "comm"))
+		if cerr != nil {
+			return "", 0, err
+		}
+		exe = strings.TrimSpace(string(comm))
+	}
+
+	stat, err := os.ReadFile(filepath.Join(dir, "stat"))
+	if err != nil {
+		return "", 0, err
+	}
+	ppid, err := parseStatPPID(string(stat))
+	if err != nil {
+		return "", 0, fmt.Errorf("pid %d: %w", pid, err)
+	}
+
+	return exe, ppid, nil
+}
+
+// parseStatPPID reads the parent PID from a /proc/<pid>/stat line.
+// Format: pid (comm) state ppid ... ; comm may contain spaces or parens.
+func parseStatPPID(stat string) (int, error) {
+	end := strings.LastIndex(stat, ")")
+	if end == -1 {
+		return 0, fmt.Errorf("malformed stat")
+	}
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 2 {
+		return 0, fmt.Errorf("malformed stat")
+	}
+	return strconv.Atoi(fields[1])
+}
@@ -0,0 +1,70 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseStatPPID(t *testing.T) {
+	tests := []struct {
+		stat    string
+		want    int
+		wantErr bool
+	}{
+		{"1234 (zsh) S 1200 1234 1234 34816", 1200, false},
+		{"1234 (tmux: server) S 1 1234 1234 0", 1, false},
+		{"1234 (weird) name)) R 42 1234", 42, false},
+		{"1234 zsh S 1200", 0, true},
+		{"1234 (zsh)", 0, true},
+		{"1234 (zsh) S x", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseStatPPID(tt.stat)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseStatPPID(%q) = %d, %v, want %d (error %v)", tt.stat, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestReadProcessInfo(t *testing.T) {
+	root := t.TempDir()
+	orig := procDir
+	procDir = root
+	t.Cleanup(func() { procDir = orig })
+
+	// 100 has a readable exe link; 200's is hidden, leaving only comm
+	writeProc := func(pid, name, content string) {
+		dir := filepath.Join(root, pid)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeProc("100", "stat", "100 (zsh) S 50 100 100 0")
+	if err := os.Symlink("/usr/bin/zsh", filepath.Join(root, "100", "exe")); err != nil {
+		t.Fatal(err)
+	}
+	writeProc("200", "stat", "200 (fish) S 100 200 200 0")
+	writeProc("200", "comm", "fish\n")
+
+	tests := []struct {
+		pid      int
+		wantExe  string
+		wantPPID int
+		wantErr  bool
+	}{
+		{100, "/usr/bin/zsh", 50, false},
+		{200, "fish", 100, false},
+		{300, "", 0, true},
+	}
+	for _, tt := range tests {
+		exe, ppid, err := readProcessInfo(tt.pid)
+		if (err != nil) != tt.wantErr || exe != tt.wantExe || ppid != tt.wantPPID {
+			t.Errorf("readProcessInfo(%d) = %s, %d, %v; want %s, %d (error %v)", tt.pid, exe, ppid, err, tt.wantExe, tt.wantPPID, tt.wantErr)
+		}
+	}
+}
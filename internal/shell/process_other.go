@@ -0,0 +1,34 @@
+//go:build !linux
+
+package shell
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// readProcessInfo returns the executable path and parent PID of a process
+func readProcessInfo(pid int) (string, int, error) {
+	if runtime.GOOS == "windows" {
+		return "", 0, fmt.Errorf("process inspection not supported on windows")
+	}
+
+	out, err := exec.Command("ps", "-o", "ppid=,comm=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return "", 0, err
+	}
+
+	fields := strings.Fields(strings.TrimSpace(string(out)))
+	if len(fields) < 2 {
+		return "", 0, fmt.Errorf("unexpected ps output for pid %d", pid)
+	}
+	ppid, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return "", 0, err
+	}
+
+	return strings.Join(fields[1:], " "), ppid, nil
+}
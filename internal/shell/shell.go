@@ -0,0 +1,221 @@
+package shell
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Kind identifies a shell dialect
+type Kind string
+
+const (
+	KindSh   Kind = "sh"
+	KindBash Kind = "bash"
+	KindZsh  Kind = "zsh"
+	KindFish Kind = "fish"
+	KindNu   Kind = "nu"
+	KindPwsh Kind = "pwsh"
+	KindCmd  Kind = "cmd"
+)
+
+// Shell describes the shell a command is generated for and executed in
+type Shell struct {
+	Kind Kind
+	Path string // Executable to invoke
+}
+
+// Detect determines the user's interactive shell.
+// It walks up the process tree looking for a known shell, then falls back to
+// $SHELL on Unix and cmd.exe on Windows.
+func Detect() *Shell {
+	if sh := fromAncestors(); sh != nil {
+		return sh
+	}
+
+	if runtime.GOOS == "windows" {
+		if isPowerShellEnv() {
+			if _, err := exec.LookPath("pwsh"); err == nil {
+				return Lookup("pwsh")
+			}
+			return Lookup("powershell")
+		}
+		return &Shell{Kind: KindCmd, Path: "cmd"}
+	}
+
+	if env := os.Getenv("SHELL"); env != "" {
+		if sh := Lookup(env); sh != nil {
+			return sh
+		}
+		// Unknown shell in $SHELL, assume POSIX sh compatible
+		return &Shell{Kind: KindSh, Path: env}
+	}
+
+	return &Shell{Kind: KindSh, Path: "/bin/sh"}
+}
+
+// processInfo returns the executable and parent PID of a process. It's a
+// variable so tests can fake a process tree.
+var processInfo = readProcessInfo
+
+// fromAncestors looks for the nearest known shell among parent processes
+func fromAncestors() *Shell {
+	pid := os.Getppid()
+	for depth := 0; depth < 5 && pid > 1; depth++ {
+		exe, ppid, err := processInfo(pid)
+		if err != nil {
+			return nil
+		}
+		if sh := Lookup(exe); sh != nil {
+			return sh
+		}
+		pid = ppid
+	}
+	return nil
+}
+
+// Lookup maps an executable name or path to a Shell, or nil if it isn't a known shell
+func Lookup(exe string) *Shell {
+	base := strings.ToLower(filepath.Base(exe))
+	base = strings.TrimSuffix(base, ".exe")
+	base = strings.TrimPrefix(base, "-") // login shells show up as "-zsh"
+
+	var kind Kind
+	switch base {
+	case "sh", "dash", "ash", "ksh", "mksh":
+		kind = KindSh
+	case "bash":
+		kind = KindBash
+	case "zsh":
+		kind = KindZsh
+	case "fish":
+		kind = KindFish
+	case "nu", "nushell":
+		kind = KindNu
+	case "pwsh", "powershell":
+		kind = KindPwsh
+	case "cmd":
+		kind = KindCmd
+	default:
+		return nil
+	}
+
+	path := exe
+	if !filepath.IsAbs(path) {
+		if resolved, err := exec.LookPath(strings.TrimPrefix(filepath.Base(exe), "-")); err == nil {
+			path = resolved
+		} else {
+			path = string(kind)
+		}
+	}
+
+	return &Shell{Kind: kind, Path: path}
+}
+
+// isPowerShellEnv guesses whether we were started from PowerShell.
+// PowerShell adds its per-user and per-install module paths to
+// PSModulePath, so a PowerShell session has at least three entries
+// while cmd.exe only sees the system-wide one.
+func isPowerShellEnv() bool {
+	paths := os.Getenv("PSModulePath")
+	return len(strings.Split(paths, string(os.PathListSeparator))) >= 3
+}
+
+// Name returns the short name of the shell
+func (s *Shell) Name() string {
+	return string(s.Kind)
+}
+
+// String returns the shell for display, e.g. "zsh (/bin/zsh)"
+func (s *Shell) String() string {
+	return fmt.Sprintf("%s (%s)", s.Kind, s.Path)
+}
+
+// IsPOSIX reports whether the shell understands POSIX sh syntax
+func (s *Shell) IsPOSIX() bool {
+	switch s.Kind {
+	case KindSh, KindBash, KindZsh:
+		return true
+	}
+	return false
+}
+
+// Invocation returns the program and arguments that run command in this shell
+func (s *Shell) Invocation(command string) (string, []string) {
+	switch s.Kind {
+	case KindCmd:
+		return s.Path, []string{"/C", command}
+	case KindPwsh:
+		return s.Path, []string{"-NoProfile", "-NonInteractive", "-Command", command}
+	default:
+		return s.Path, []string{"-c", command}
+	}
+}
+
+// Dialect describes the shell's syntax for the agent prompt
+func (s *Shell) Dialect() string {
+	switch s.Kind {
+	case KindBash:
+		return "bash (POSIX sh plus bash extensions like [[ ]], arrays and brace expansion)"
+	case KindZsh:
+		return "zsh (POSIX sh compatible; extended globbing like **/*.go is available)"
+	case KindFish:
+		return "fish (NOT POSIX: use `set VAR value` instead of VAR=value, `(cmd)` instead of $(cmd), " +
+			"`; and` / `; or` or &&/||, `for x in ...; ...; end`, and `$status` instead of $?)"
+	case KindNu:
+		return "nushell (NOT POSIX: pipelines carry structured data, use `ls | where size > 1mb`, " +
+			"`$env.VAR` for environment variables, `;` to sequence commands, and `^cmd` to force an external command)"
+	case KindPwsh:
+		return "PowerShell (use cmdlets like Get-ChildItem, Select-String and Where-Object, " +
+			"`$env:VAR` for environment variables, and `;` to sequence commands)"
+	case KindCmd:
+		return "Windows cmd.exe (use dir, findstr, type, `%VAR%` for variables and `&&` to chain commands)"
+	default:
+		return "POSIX sh (avoid bash-only features like [[ ]] and arrays)"
+	}
+}
+
+// Validate checks the command's syntax without running it, using the
+// shell's no-exec mode. It returns nil when the syntax is valid or the
+// shell has no way to check it.
+func (s *Shell) Validate(command string) error {
+	var cmd *exec.Cmd
+	switch s.Kind {
+	case KindSh, KindBash, KindZsh:
+		cmd = exec.Command(s.Path, "-n", "-c", command)
+	case KindFish:
+		cmd = exec.Command(s.Path, "--no-execute", "-c", command)
+	case KindPwsh:
+		// Parse without executing; the command is passed via the environment
+		// to avoid quoting it inside the checker script
+		script := `$e = $null; [void][System.Management.Automation.Language.Parser]::ParseInput($env:PLEASE_SYNTAX_CHECK, [ref]$null, [ref]$e); ` +
+			`if ($e) { $e | ForEach-Object { $_.Message }; exit 1 }`
+		cmd = exec.Command(s.Path, "-NoProfile", "-NonInteractive", "-Command", script)
+		cmd.Env = append(os.Environ(), "PLEASE_SYNTAX_CHECK="+command)
+	default:
+		// nushell and cmd.exe have no no-exec mode
+		return nil
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stdout = &stderr
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if _, ok := err.(*exec.ExitError); !ok {
+			// Shell couldn't be started; nothing to validate with
+			return nil
+		}
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return fmt.Errorf("%s syntax error: %s", s.Kind, msg)
+	}
+
+	return nil
+}
@@ -0,0 +1,173 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeTree replaces processInfo with a process tree of pid → executable,
+// where each pid's parent is pid-1
+func fakeTree(t *testing.T, exes map[int]string) {
+	t.Helper()
+	orig := processInfo
+	t.Cleanup(func() { processInfo = orig })
+	processInfo = func(pid int) (string, int, error) {
+		exe, ok := exes[pid]
+		if !ok {
+			return "", 0, fmt.Errorf("no process %d", pid)
+		}
+		return exe, pid - 1, nil
+	}
+}
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		exe      string
+		wantKind Kind // Empty for not a shell
+		wantPath string
+	}{
+		{"/bin/bash", KindBash, "/bin/bash"},
+		{"/usr/local/bin/zsh", KindZsh, "/usr/local/bin/zsh"},
+		{"/bin/dash", KindSh, "/bin/dash"},
+		{"/usr/bin/fish", KindFish, "/usr/bin/fish"},
+		{"/opt/nushell", KindNu, "/opt/nushell"},
+		{"/opt/microsoft/powershell/7/PWSH.EXE", KindPwsh, "/opt/microsoft/powershell/7/PWSH.EXE"},
+		{"-zsh", KindZsh, "zsh"}, // Login shell, not in the test PATH
+		{"ksh", KindSh, "sh"},
+		{"/usr/bin/python3", "", ""},
+		{"node", "", ""},
+	}
+
+	if runtime.GOOS == "windows" {
+		t.Skip("uses Unix paths")
+	}
+	t.Setenv("PATH", t.TempDir())
+	for _, tt := range tests {
+		sh := Lookup(tt.exe)
+		if tt.wantKind == "" {
+			if sh != nil {
+				t.Errorf("Lookup(%q) = %v, want nil", tt.exe, sh)
+			}
+			continue
+		}
+		if sh == nil || sh.Kind != tt.wantKind || sh.Path != tt.wantPath {
+			t.Errorf("Lookup(%q) = %v, want %s (%s)", tt.exe, sh, tt.wantKind, tt.wantPath)
+		}
+	}
+}
+
+func TestLookupResolvesPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a Unix executable")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "zsh"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+
+	if sh := Lookup("-zsh"); sh == nil || sh.Path != filepath.Join(dir, "zsh") {
+		t.Errorf("Lookup(-zsh) = %v, want the zsh in PATH", sh)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("falls back to cmd.exe on Windows")
+	}
+	ppid := os.Getppid()
+
+	tests := []struct {
+		name string
+		tree map[int]string
+		env  string
+		want Kind
+		path string
+	}{
+		{"nearest ancestor", map[int]string{ppid: "/usr/bin/node", ppid - 1: "/bin/zsh", ppid - 2: "/bin/bash"}, "/bin/bash", KindZsh, "/bin/zsh"},
+		{"too far up", map[int]string{ppid: "a", ppid - 1: "b", ppid - 2: "c", ppid - 3: "d", ppid - 4: "e", ppid - 5: "/bin/zsh"}, "/usr/bin/fish", KindFish, "/usr/bin/fish"},
+		{"unknown $SHELL", nil, "/opt/xonsh", KindSh, "/opt/xonsh"},
+		{"no $SHELL", nil, "", KindSh, "/bin/sh"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeTree(t, tt.tree)
+			t.Setenv("SHELL", tt.env)
+
+			sh := Detect()
+			if sh.Kind != tt.want || sh.Path != tt.path {
+				t.Errorf("Detect() = %v, want %s (%s)", sh, tt.want, tt.path)
+			}
+		})
+	}
+}
+
+func TestInvocation(t *testing.T) {
+	tests := []struct {
+		shell Shell
+		want  string
+	}{
+		{Shell{KindBash, "/bin/bash"}, "/bin/bash -c ls -la"},
+		{Shell{KindZsh, "/bin/zsh"}, "/bin/zsh -c ls -la"},
+		{Shell{KindFish, "/usr/bin/fish"}, "/usr/bin/fish -c ls -la"},
+		{Shell{KindNu, "nu"}, "nu -c ls -la"},
+		{Shell{KindPwsh, "pwsh"}, "pwsh -NoProfile -NonInteractive -Command ls -la"},
+		{Shell{KindCmd, "cmd"}, "cmd /C ls -la"},
+	}
+
+	for _, tt := range tests {
+		name, args := tt.shell.Invocation("ls -la")
+		if args[len(args)-1] != "ls -la" {
+			t.Errorf("%s: command split into %q", tt.shell.Kind, args)
+		}
+		if got := name + " " + strings.Join(args, " "); got != tt.want {
+			t.Errorf("%s Invocation() = %s, want %s", tt.shell.Kind, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	sh, err := findShell("sh")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	tests := []struct {
+		shell   Shell
+		command string
+		wantErr bool
+	}{
+		{Shell{KindSh, sh}, "echo hi | tr a-z A-Z", false},
+		{Shell{KindSh, sh}, "if true; then echo hi", true},
+		{Shell{KindSh, sh}, "rm -rf /nonexistent-please-test", false}, // Checked, not run
+		{Shell{KindCmd, "cmd"}, "if true; then", false},               // No no-exec mode
+		{Shell{KindNu, "nu"}, "if true; then", false},
+		{Shell{KindBash, "/no/such/bash"}, "if true; then", false}, // Can't start, can't check
+	}
+
+	for _, tt := range tests {
+		err := tt.shell.Validate(tt.command)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s Validate(%q) error = %v, want error %v", tt.shell.Kind, tt.command, err, tt.wantErr)
+		}
+		if err != nil && !strings.Contains(err.Error(), "syntax error") {
+			t.Errorf("Validate(%q) error = %v, want a syntax error", tt.command, err)
+		}
+	}
+}
+
+// findShell returns the path to a shell in PATH
+func findShell(name string) (string, error) {
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("%s not in PATH", name)
+}
@@ -0,0 +1,141 @@
+package shell
+
+import (
+	"errors"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// ErrNotSteppable is returned by Split for commands that can't be broken
+// into independent stages (control structures, heredocs, background jobs)
+var ErrNotSteppable = errors.New("command can't be split into stages")
+
+// Stage is one simple command within a command list or pipeline
+type Stage struct {
+	Command string
+	Op      string // Operator joining this stage to the next: "|", "|&", "&&", "||", ";" or "" for the last stage
+}
+
+// IsPipe reports whether the stage's output feeds the next stage
+func (s Stage) IsPipe() bool {
+	return s.Op == "|" || s.Op == "|&"
+}
+
+// fishKeywords start fish constructs that the POSIX grammar reads as plain
+// commands; their bodies can't be run stage by stage either
+var fishKeywords = map[string]bool{
+	"begin": true, "switch": true, "function": true, "end": true,
+	"and": true, "or": true, // combiners depend on the previous command's status
+}
+
+// binaryOps maps the AST's list and pipeline operators to stage operators
+var binaryOps = map[syntax.BinCmdOperator]string{
+	syntax.AndStmt: "&&",
+	syntax.OrStmt:  "||",
+	syntax.Pipe:    "|",
+	syntax.PipeAll: "|&",
+}
+
+// Split breaks a command line into stages at the top-level operators |, |&,
+// &&, || and ; (and newlines). The command is parsed with the bash grammar,
+// so operators inside quotes, expansions, arithmetic and substitutions are
+// left alone. Commands using anything other than simple commands and
+// subshells joined by those operators are not steppable.
+func Split(command string) ([]Stage, error) {
+	file, err := syntax.NewParser(syntax.Variant(syntax.LangBash)).Parse(strings.NewReader(command), "")
+	if err != nil {
+		return nil, ErrNotSteppable
+	}
+
+	var stages []Stage
+	for _, stmt := range file.Stmts {
+		if err := appendStages(&stages, command, stmt); err != nil {
+			return nil, err
+		}
+		stages[len(stages)-1].Op = ";"
+	}
+	if len(stages) > 0 {
+		stages[len(stages)-1].Op = ""
+	}
+
+	return stages, nil
+}
+
+// appendStages flattens a statement's && / || / | tree into stages, taking
+// each stage's text verbatim from the command line
+func appendStages(stages *[]Stage, command string, stmt *syntax.Stmt) error {
+	if stmt.Background || stmt.Coprocess {
+		return ErrNotSteppable
+	}
+
+	if bin, ok := stmt.Cmd.(*syntax.BinaryCmd); ok && !stmt.Negated && len(stmt.Redirs) == 0 {
+		op, ok := binaryOps[bin.Op]
+		if !ok {
+			return ErrNotSteppable
+		}
+		if err := appendStages(stages, command, bin.X); err != nil {
+			return err
+		}
+		(*stages)[len(*stages)-1].Op = op
+		return appendStages(stages, command, bin.Y)
+	}
+
+	if !steppable(stmt) {
+		return ErrNotSteppable
+	}
+
+	end := stmt.End()
+	if stmt.Semicolon.IsValid() {
+		end = stmt.Semicolon
+	}
+	text := strings.TrimSpace(command[stmt.Pos().Offset():end.Offset()])
+	if text == "" {
+		return ErrNotSteppable
+	}
+
+	*stages = append(*stages, Stage{Command: text})
+	return nil
+}
+
+// steppable reports whether a statement can run on its own as one stage
+func steppable(stmt *syntax.Stmt) bool {
+	for _, redir := range stmt.Redirs {
+		// Heredoc bodies sit on the lines after the command
+		if redir.Op == syntax.Hdoc || redir.Op == syntax.DashHdoc {
+			return false
+		}
+	}
+
+	switch cmd := stmt.Cmd.(type) {
+	case *syntax.CallExpr:
+		if len(cmd.Args) > 0 && fishKeywords[cmd.Args[0].Lit()] {
+			return false
+		}
+		return true
+	case *syntax.Subshell, *syntax.DeclClause, *syntax.TestClause, *syntax.ArithmCmd, *syntax.LetClause:
+		return true
+	case *syntax.BinaryCmd:
+		// Negated or redirected as a whole, e.g. ! a | b
+		return true
+	default:
+		// if, for, while, case, { ... }, functions, time and the like
+		return false
+	}
+}
+
+// Join reassembles stages into a single command line
+func Join(stages []Stage) string {
+	var b strings.Builder
+	for i, stage := range stages {
+		b.WriteString(stage.Command)
+		if i < len(stages)-1 && stage.Op != "" {
+			if stage.Op == ";" {
+				b.WriteString("; ")
+			} else {
+				b.WriteString(" " + stage.Op + " ")
+			}
+		}
+	}
+	return b.String()
+}
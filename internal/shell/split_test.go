@@ -0,0 +1,121 @@
+package shell
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []Stage
+	}{
+		{
+			command: "ls -la",
+			want:    []Stage{{Command: "ls -la"}},
+		},
+		{
+			command: "make build && ./bin/app | grep error",
+			want: []Stage{
+				{Command: "make build", Op: "&&"},
+				{Command: "./bin/app", Op: "|"},
+				{Command: "grep error"},
+			},
+		},
+		{
+			command: `echo "a && b | c"; echo 'x || y'`,
+			want: []Stage{
+				{Command: `echo "a && b | c"`, Op: ";"},
+				{Command: `echo 'x || y'`},
+			},
+		},
+		{
+			command: "cat $(ls | head -1) || echo none",
+			want: []Stage{
+				{Command: "cat $(ls | head -1)", Op: "||"},
+				{Command: "echo none"},
+			},
+		},
+		{
+			command: "go test ./... 2>&1 |& tee out.log",
+			want: []Stage{
+				{Command: "go test ./... 2>&1", Op: "|&"},
+				{Command: "tee out.log"},
+			},
+		},
+		{
+			command: `find . -name '*.go' -exec wc -l {} \; | sort -n`,
+			want: []Stage{
+				{Command: `find . -name '*.go' -exec wc -l {} \;`, Op: "|"},
+				{Command: "sort -n"},
+			},
+		},
+		{
+			command: "echo ${a:-x|y} && echo ${b//&&/and} | wc -c",
+			want: []Stage{
+				{Command: "echo ${a:-x|y}", Op: "&&"},
+				{Command: "echo ${b//&&/and}", Op: "|"},
+				{Command: "wc -c"},
+			},
+		},
+		{
+			command: "echo $(( a | b )) || echo $(( 1 && 0 ))",
+			want: []Stage{
+				{Command: "echo $(( a | b ))", Op: "||"},
+				{Command: "echo $(( 1 && 0 ))"},
+			},
+		},
+		{
+			command: "(cd src && make) | tee build.log\nls",
+			want: []Stage{
+				{Command: "(cd src && make)", Op: "|"},
+				{Command: "tee build.log", Op: ";"},
+				{Command: "ls"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := Split(tt.command)
+		if err != nil {
+			t.Errorf("Split(%q) error: %v", tt.command, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Split(%q) = %#v, want %#v", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestSplitNotSteppable(t *testing.T) {
+	for _, command := range []string{
+		"for f in *.go; do wc -l $f; done",
+		"if true; then echo yes; fi",
+		"sleep 10 &",
+		"cat <<EOF\nhi\nEOF",
+		"echo 'unterminated",
+		"ls |",
+		"case x in a|b) echo ab;; esac",
+		"echo start; case $1 in -h|--help) usage;; esac | less",
+		"{ ls; pwd; } | wc -l",
+		"true; and echo fish",
+		"echo (ls | head -1)",
+		"ls &\nwait",
+	} {
+		if _, err := Split(command); !errors.Is(err, ErrNotSteppable) {
+			t.Errorf("Split(%q) error = %v, want ErrNotSteppable", command, err)
+		}
+	}
+}
+
+func TestJoin(t *testing.T) {
+	command := "make build && ./bin/app | grep error; echo done"
+	stages, err := Split(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := Join(stages); got != command {
+		t.Errorf("Join() = %q, want %q", got, command)
+	}
+}
@@ -0,0 +1,76 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+	"golang.org/x/term"
+)
+
+// progressWidth is the number of cells in a progress bar
+const progressWidth = 30
+
+// Progress draws a single-line progress bar that redraws in place. It is
+// safe to update from several goroutines, and draws nothing when stdout
+// isn't a terminal.
+type Progress struct {
+	label   string
+	mu      sync.Mutex
+	enabled bool
+	drawn   bool
+	last    int // Last drawn number of filled cells, to avoid redundant redraws
+}
+
+// NewProgress creates a progress bar with a label shown before it
+func NewProgress(label string) *Progress {
+	return &Progress{
+		label:   label,
+		enabled: term.IsTerminal(int(os.Stdout.Fd())),
+		last:    -1,
+	}
+}
+
+// Update redraws the bar for done out of total items
+func (p *Progress) Update(done, total int) {
+	if p == nil || !p.enabled || total <= 0 {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if done > total {
+		done = total
+	}
+	filled := done * progressWidth / total
+	if filled == p.last && done != total {
+		return
+	}
+	p.last = filled
+	p.drawn = true
+
+	cyan := color.New(color.FgCyan)
+	fmt.Printf("\r%s [%s%s] %d/%d",
+		p.label,
+		cyan.Sprint(strings.Repeat("█", filled)),
+		strings.Repeat("░", progressWidth-filled),
+		done, total)
+}
+
+// Done clears the bar so the next message starts on a fresh line
+func (p *Progress) Done() {
+	if p == nil || !p.enabled {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.drawn {
+		fmt.Print("\r\033[K")
+		p.drawn = false
+	}
+}
@@ -19,6 +19,11 @@ const (
 	ActionModify
 	ActionCopy
 	ActionCancel
+	ActionSave
+	ActionStep
+	ActionSkip
+	ActionFix
+	ActionContinue
 )
 
 // ConfigureAgent prompts the user to select an agent
@@ -49,6 +54,7 @@ func ConfirmCommand(command string) (Action, error) {
 	fmt.Println("  [r] Run it")
 	fmt.Println("  [e] Explain")
 	fmt.Println("  [m] Modify it")
+	fmt.Println("  [s] Step through stage by stage")
 	fmt.Println("  [c] Copy to clipboard")
 	fmt.Println("  [q] Cancel")
 	fmt.Print("\nPress a key: ")
@@ -70,6 +76,8 @@ func ConfirmCommand(command string) (Action, error) {
 		return ActionExplain, nil
 	case 'm', 'M':
 		return ActionModify, nil
+	case 's', 'S':
+		return ActionStep, nil
 	case 'c', 'C':
 		return ActionCopy, nil
 	case 'q', 'Q', '\x1b': // ESC key is \x1b
@@ -83,17 +91,20 @@ func ConfirmCommand(command string) (Action, error) {
 
 // readKey reads a single keypress from the terminal
 func readKey() (rune, error) {
-	// Save the current terminal state
-	oldState, err := term.MakeRaw(int(os.Stdin.Fd()))
-	if err != nil {
-		return 0, err
+	// Save the current terminal state. Piped input has no line discipline
+	// to switch off, so it is read as is.
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		oldState, err := term.MakeRaw(fd)
+		if err != nil {
+			return 0, err
+		}
+		defer term.Restore(fd, oldState)
 	}
-	defer term.Restore(int(os.Stdin.Fd()), oldState)
 
 	// Read a single byte
 	buf := make([]byte, 1)
-	_, err = os.Stdin.Read(buf)
-	if err != nil {
+	if _, err := os.Stdin.Read(buf); err != nil {
 		return 0, err
 	}
 
@@ -161,37 +172,52 @@ func PromptYesNo(message string, defaultValue bool) (bool, error) {
 	return result, nil
 }
 
+// providerOptions are the embedding providers offered by PromptProvider
+var providerOptions = []struct {
+	label    string
+	provider string
+}{
+	{"Local (Ollama) - Private, runs on your machine", "ollama"},
+	{"OpenAI API - Cloud-based, most accurate", "openai"},
+	{"Hugging Face TEI - Self-hosted text-embeddings-inference server", "tei"},
+	{"llama.cpp server - Self-hosted llama-server with --embedding", "llamacpp"},
+	{"Cohere API - Cloud-based, or a Cohere-compatible server", "cohere"},
+	{"Built-in - Offline, no service needed (less accurate than a model)", "local"},
+	{"None - Keyword matching only (faster, less accurate)", "none"},
+}
+
 // PromptProvider prompts for embedding provider selection
 func PromptProvider() (string, error) {
-	var provider string
+	options := make([]string, len(providerOptions))
+	for i, opt := range providerOptions {
+		options[i] = opt.label
+	}
+
+	var choice string
 	prompt := &survey.Select{
 		Message: "Choose embedding provider:",
-		Options: []string{
-			"Local (Ollama) - Private, runs on your machine",
-			"OpenAI API - Cloud-based, most accurate",
-			"None - Keyword matching only (faster, less accurate)",
-		},
-		Default: "Local (Ollama) - Private, runs on your machine",
+		Options: options,
+		Default: options[0],
 	}
 
-	if err := survey.AskOne(prompt, &provider); err != nil {
+	if err := survey.AskOne(prompt, &choice); err != nil {
 		return "", err
 	}
 
-	// Parse selection to provider name
-	if contains(provider, "Ollama") {
-		return "ollama", nil
-	} else if contains(provider, "OpenAI") {
-		return "openai", nil
+	// Map selection to provider name
+	for _, opt := range providerOptions {
+		if opt.label == choice {
+			return opt.provider, nil
+		}
 	}
 	return "none", nil
 }
 
-// PromptAPIKeyStorage prompts for how to store OpenAI API key
-func PromptAPIKeyStorage() (bool, error) {
+// PromptAPIKeyStorage prompts for how to store a provider's API key
+func PromptAPIKeyStorage(provider string) (bool, error) {
 	var choice string
 	prompt := &survey.Select{
-		Message: "OpenAI API key storage:",
+		Message: provider + " API key storage:",
 		Options: []string{
 			"Environment variable (recommended)",
 			"Config file (less secure)",
@@ -368,3 +394,42 @@ func FormatMarkdown(text string) string {
 
 	return result.String()
 }
+
+// PromptPlaceholder asks for the value of a command placeholder, offering
+// tab completion from options when available. An empty answer (with no
+// default) returns "", meaning the placeholder should be left as written.
+func PromptPlaceholder(name, description string, options []string, defaultValue string) (string, error) {
+	message := fmt.Sprintf("Value for <%s>:", name)
+	if description != "" {
+		message = fmt.Sprintf("Value for <%s> (%s):", name, description)
+	}
+
+	if defaultValue == "" && len(options) == 1 {
+		defaultValue = options[0]
+	}
+
+	var value string
+	prompt := &survey.Input{
+		Message: message,
+		Default: defaultValue,
+		Help:    "Leave empty to keep it as written",
+	}
+	if len(options) > 0 {
+		prompt.Help = fmt.Sprintf("%d known values, press Tab to complete; leave empty to keep it as written", len(options))
+		prompt.Suggest = func(toComplete string) []string {
+			var matches []string
+			for _, opt := range options {
+				if contains(opt, toComplete) {
+					matches = append(matches, opt)
+				}
+			}
+			return matches
+		}
+	}
+
+	if err := survey.AskOne(prompt, &value); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(value), nil
+}
@@ -0,0 +1,132 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+var (
+	// Variables are matched before keywords so "$done" isn't split up
+	scriptTokens  = regexp.MustCompile(`\$\{[^}]*\}|\$[A-Za-z_][A-Za-z0-9_]*|\$[0-9@#?*$!-]|\b(?:if|then|elif|else|fi|for|while|until|do|done|case|esac|in|function|return|local|export|readonly|set|trap|exit)\b`)
+	scriptStrings = regexp.MustCompile(`"(?:[^"\\]|\\.)*"|'[^']*'`)
+)
+
+// ConfirmScript shows a generated script and asks the user what to do
+func ConfirmScript(script string) (Action, error) {
+	cyan := color.New(color.FgCyan, color.Bold)
+	cyan.Println("\nGenerated script:")
+	fmt.Println(HighlightScript(script))
+
+	fmt.Println("What would you like to do?")
+	fmt.Println("  [r] Run it")
+	fmt.Println("  [e] Explain")
+	fmt.Println("  [m] Modify it")
+	fmt.Println("  [s] Save to file")
+	fmt.Println("  [c] Copy to clipboard")
+	fmt.Println("  [q] Cancel")
+	fmt.Print("\nPress a key: ")
+
+	key, err := readKey()
+	if err != nil {
+		return ActionCancel, err
+	}
+
+	fmt.Println()
+
+	switch key {
+	case 'r', 'R':
+		return ActionRun, nil
+	case 'e', 'E':
+		return ActionExplain, nil
+	case 'm', 'M':
+		return ActionModify, nil
+	case 's', 'S':
+		return ActionSave, nil
+	case 'c', 'C':
+		return ActionCopy, nil
+	case 'q', 'Q', '\x1b':
+		return ActionCancel, nil
+	default:
+		ShowError("Invalid choice. Please try again.")
+		return ConfirmScript(script)
+	}
+}
+
+// HighlightScript renders a shell script with line numbers and syntax colors
+func HighlightScript(script string) string {
+	gray := color.New(color.FgHiBlack)
+	lines := strings.Split(strings.TrimRight(script, "\n"), "\n")
+	width := len(fmt.Sprint(len(lines)))
+
+	var result strings.Builder
+	for i, line := range lines {
+		result.WriteString(gray.Sprintf("  %*d │ ", width, i+1))
+		result.WriteString(highlightLine(line))
+		result.WriteString("\n")
+	}
+
+	return result.String()
+}
+
+// highlightLine colors comments, strings, variables and keywords in one line
+func highlightLine(line string) string {
+	gray := color.New(color.FgHiBlack)
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, "#") {
+		return gray.Sprint(line)
+	}
+
+	// Split off a trailing comment (a # that starts a word outside quotes)
+	code, comment := splitComment(line)
+
+	yellow := color.New(color.FgYellow)
+	cyan := color.New(color.FgCyan)
+	magenta := color.New(color.FgMagenta, color.Bold)
+
+	// Highlight strings first and leave their contents alone
+	var out strings.Builder
+	last := 0
+	for _, loc := range scriptStrings.FindAllStringIndex(code, -1) {
+		out.WriteString(highlightCode(code[last:loc[0]], cyan, magenta))
+		out.WriteString(yellow.Sprint(code[loc[0]:loc[1]]))
+		last = loc[1]
+	}
+	out.WriteString(highlightCode(code[last:], cyan, magenta))
+
+	if comment != "" {
+		out.WriteString(gray.Sprint(comment))
+	}
+
+	return out.String()
+}
+
+// highlightCode colors variables and keywords in unquoted code
+func highlightCode(code string, vars, keywords *color.Color) string {
+	return scriptTokens.ReplaceAllStringFunc(code, func(tok string) string {
+		if strings.HasPrefix(tok, "$") {
+			return vars.Sprint(tok)
+		}
+		return keywords.Sprint(tok)
+	})
+}
+
+// splitComment separates a trailing shell comment from the code before it
+func splitComment(line string) (string, string) {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i], line[i:]
+		}
+	}
+	return line, ""
+}
@@ -0,0 +1,104 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// ConfirmStep shows the next stage of a stepped command and asks what to do
+func ConfirmStep(index, total int, stage string) (Action, error) {
+	cyan := color.New(color.FgCyan, color.Bold)
+	cyan.Printf("\nStage %d/%d:\n", index, total)
+	fmt.Printf("  %s\n\n", stage)
+
+	fmt.Println("  [r] Run this stage")
+	fmt.Println("  [m] Modify this stage")
+	fmt.Println("  [s] Skip it")
+	fmt.Println("  [q] Stop stepping")
+	fmt.Print("\nPress a key: ")
+
+	key, err := readKey()
+	if err != nil {
+		return ActionCancel, err
+	}
+
+	fmt.Println()
+
+	switch key {
+	case 'r', 'R', '\r':
+		return ActionRun, nil
+	case 'm', 'M':
+		return ActionModify, nil
+	case 's', 'S':
+		return ActionSkip, nil
+	case 'q', 'Q', '\x1b':
+		return ActionCancel, nil
+	default:
+		ShowError("Invalid choice. Please try again.")
+		return ConfirmStep(index, total, stage)
+	}
+}
+
+// ConfirmStepFailure asks what to do after a stage exited with a non-zero code
+func ConfirmStepFailure(exitCode int) (Action, error) {
+	fmt.Println("What would you like to do?")
+	fmt.Println("  [f] Fix this stage (ask the agent using its output)")
+	fmt.Println("  [m] Modify this stage")
+	fmt.Println("  [r] Retry it")
+	fmt.Println("  [c] Continue anyway")
+	fmt.Println("  [q] Stop stepping")
+	fmt.Print("\nPress a key: ")
+
+	key, err := readKey()
+	if err != nil {
+		return ActionCancel, err
+	}
+
+	fmt.Println()
+
+	switch key {
+	case 'f', 'F':
+		return ActionFix, nil
+	case 'm', 'M':
+		return ActionModify, nil
+	case 'r', 'R':
+		return ActionRun, nil
+	case 'c', 'C':
+		return ActionContinue, nil
+	case 'q', 'Q', '\x1b':
+		return ActionCancel, nil
+	default:
+		ShowError("Invalid choice. Please try again.")
+		return ConfirmStepFailure(exitCode)
+	}
+}
+
+// ShowStageOutput prints a stage's captured output (truncated) and exit code
+func ShowStageOutput(output string, exitCode int, maxLines int) {
+	gray := color.New(color.FgHiBlack)
+
+	output = strings.TrimRight(output, "\n")
+	if output != "" {
+		lines := strings.Split(output, "\n")
+		shown := lines
+		if len(lines) > maxLines {
+			shown = lines[:maxLines]
+		}
+		for _, line := range shown {
+			fmt.Printf("  %s %s\n", gray.Sprint("│"), line)
+		}
+		if len(lines) > maxLines {
+			gray.Printf("  … %d more lines\n", len(lines)-maxLines)
+		}
+	} else {
+		gray.Println("  (no output)")
+	}
+
+	if exitCode == 0 {
+		ShowSuccess("exit code 0")
+	} else {
+		ShowError(fmt.Sprintf("exit code %d", exitCode))
+	}
+}